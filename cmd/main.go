@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gogodjzhu/gogobox/pkg/cmd/root"
 	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
@@ -11,8 +15,9 @@ import (
 type exitCode int
 
 const (
-	exitOK    exitCode = 0
-	exitError exitCode = 1
+	exitOK      exitCode = 0
+	exitError   exitCode = 1
+	exitPartial exitCode = 2
 )
 
 func main() {
@@ -28,7 +33,20 @@ func mainRun() exitCode {
 		fmt.Println(err)
 		return exitError
 	}
-	if _, err := mainCmd.ExecuteC(); err != nil {
+	// The first interrupt cancels the running operation, which cleans up
+	// after itself; a second one terminates immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if _, err := mainCmd.ExecuteContextC(ctx); err != nil {
+		var partial *cmdutil.PartialError
+		if errors.As(err, &partial) {
+			return exitPartial
+		}
 		return exitError
 	}
 	return exitOK
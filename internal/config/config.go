@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvConfigFile overrides the location of the config file
+	EnvConfigFile = "GOGOBOX_CONFIG"
+
+	configFileName = "config.yaml"
+)
+
+// Dir returns the directory holding gogobox configuration files. It honors
+// $XDG_CONFIG_HOME and falls back to ~/.config/gogobox.
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gogobox"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "gogobox"), nil
+}
+
+// Path returns the path of the gogobox config file
+func Path() (string, error) {
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// LoadSection decodes the top level section name of the config file into v.
+// A missing file or section leaves v untouched.
+func LoadSection(name string, v interface{}) error {
+	sections, err := readSections()
+	if err != nil {
+		return err
+	}
+	node, ok := sections[name]
+	if !ok {
+		return nil
+	}
+	if err := node.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode config section %s: %w", name, err)
+	}
+	return nil
+}
+
+// SaveSection replaces the top level section name of the config file with v,
+// keeping all other sections as they are
+func SaveSection(name string, v interface{}) error {
+	sections, err := readSections()
+	if err != nil {
+		return err
+	}
+
+	node := yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode config section %s: %w", name, err)
+	}
+	sections[name] = node
+
+	data, err := yaml.Marshal(sections)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// The config may hold credentials, keep it private to the user
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+func readSections() (map[string]yaml.Node, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]yaml.Node{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sections, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return sections, nil
+}
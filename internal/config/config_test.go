@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testSection struct {
+	Name  string `yaml:"name"`
+	Count int    `yaml:"count"`
+}
+
+func TestSaveAndLoadSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "config.yaml")
+	t.Setenv(EnvConfigFile, path)
+
+	// Loading a missing file leaves the value untouched
+	loaded := testSection{Name: "default"}
+	if err := LoadSection("first", &loaded); err != nil {
+		t.Fatalf("LoadSection() unexpected error: %v", err)
+	}
+	if loaded.Name != "default" {
+		t.Errorf("LoadSection() modified value for missing file: %+v", loaded)
+	}
+
+	if err := SaveSection("first", testSection{Name: "one", Count: 1}); err != nil {
+		t.Fatalf("SaveSection() unexpected error: %v", err)
+	}
+	if err := SaveSection("second", testSection{Name: "two", Count: 2}); err != nil {
+		t.Fatalf("SaveSection() unexpected error: %v", err)
+	}
+
+	// Saving a section must keep the others
+	var first, second testSection
+	if err := LoadSection("first", &first); err != nil {
+		t.Fatalf("LoadSection() unexpected error: %v", err)
+	}
+	if err := LoadSection("second", &second); err != nil {
+		t.Fatalf("LoadSection() unexpected error: %v", err)
+	}
+	if first.Name != "one" || first.Count != 1 || second.Name != "two" || second.Count != 2 {
+		t.Errorf("LoadSection() = %+v, %+v", first, second)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("config file not written: %v", err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("config file mode = %v, want 0600", stat.Mode().Perm())
+	}
+}
+
+func TestLoadSectionInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv(EnvConfigFile, path)
+	if err := os.WriteFile(path, []byte("not: [valid"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	var v testSection
+	if err := LoadSection("first", &v); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("LoadSection() error = %v, want parse error mentioning %s", err, path)
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv(EnvConfigFile, "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	path, err := Path()
+	if err != nil {
+		t.Fatalf("Path() unexpected error: %v", err)
+	}
+	if path != filepath.Join("/xdg", "gogobox", "config.yaml") {
+		t.Errorf("Path() = %s", path)
+	}
+}
@@ -0,0 +1,246 @@
+// Package envelope implements client-side envelope encryption: every object is
+// encrypted with its own random data key, which is stored next to the object
+// wrapped (encrypted) with a long-lived key encryption key.
+package envelope
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// Algorithm names the content encryption: AES-256-GCM over segments of
+	// SegmentSize bytes
+	Algorithm = "AES-256-GCM-SEG64K"
+	// WrapAlgorithm names the encryption of data keys with the key
+	// encryption key
+	WrapAlgorithm = "AES-256-GCM"
+
+	// KeySize is the size of data keys and key encryption keys
+	KeySize = 32
+	// NonceSize is the size of the per-object nonce prefix
+	NonceSize = 8
+	// SegmentSize is the plaintext size of every segment but the last
+	SegmentSize = 64 * 1024
+
+	tagSize = 16
+)
+
+// wrapAAD binds wrapped keys to this format
+var wrapAAD = []byte("gogobox-envelope-v1")
+
+// ErrWrongKey is returned when a data key cannot be unwrapped
+var ErrWrongKey = errors.New("wrong encryption key or corrupted wrapped key")
+
+// ErrCorrupted is returned when the ciphertext fails authentication
+var ErrCorrupted = errors.New("encrypted content is corrupted or truncated")
+
+// Header holds what is needed to decrypt an object besides the key
+// encryption key
+type Header struct {
+	WrappedKey []byte
+	Nonce      []byte
+}
+
+// EncryptedSize returns the ciphertext size of size bytes of plaintext, or
+// -1 if size is unknown
+func EncryptedSize(size int64) int64 {
+	if size < 0 {
+		return -1
+	}
+	segments := (size + SegmentSize - 1) / SegmentSize
+	if segments == 0 {
+		// Empty content is sealed as one empty segment
+		segments = 1
+	}
+	return size + segments*tagSize
+}
+
+// DecryptedSize returns the plaintext size of size bytes of ciphertext
+func DecryptedSize(size int64) int64 {
+	if size < 0 {
+		return -1
+	}
+	segments := (size + SegmentSize + tagSize - 1) / (SegmentSize + tagSize)
+	if segments == 0 {
+		segments = 1
+	}
+	return size - segments*tagSize
+}
+
+// NewEncrypter returns a reader of the encryption of r with a new data key,
+// and the header of the ciphertext with the data key wrapped by kek
+func NewEncrypter(r io.Reader, kek []byte) (io.Reader, Header, error) {
+	dataKey := make([]byte, KeySize)
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, Header{}, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, Header{}, err
+	}
+	wrapped, err := wrapKey(kek, dataKey)
+	if err != nil {
+		return nil, Header{}, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, Header{}, err
+	}
+	return &segmentReader{
+		src:       bufio.NewReaderSize(r, SegmentSize),
+		aead:      aead,
+		nonce:     nonce,
+		plaintext: make([]byte, SegmentSize),
+	}, Header{WrappedKey: wrapped, Nonce: nonce}, nil
+}
+
+// Decrypt writes the plaintext of the ciphertext read from r to w. Content
+// that was modified, reordered or truncated fails with ErrCorrupted.
+func Decrypt(w io.Writer, r io.Reader, kek []byte, header Header) error {
+	if len(header.Nonce) != NonceSize {
+		return fmt.Errorf("invalid nonce size %d", len(header.Nonce))
+	}
+	dataKey, err := unwrapKey(kek, header.WrappedKey)
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+
+	src := bufio.NewReaderSize(r, SegmentSize+tagSize)
+	segment := make([]byte, SegmentSize+tagSize)
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(src, segment)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			if err == io.EOF {
+				// The final segment is missing
+				return ErrCorrupted
+			}
+			return err
+		}
+		final := isEOF(src)
+		plaintext, err := aead.Open(segment[:0], segmentNonce(header.Nonce, index), segment[:n], segmentAAD(final))
+		if err != nil {
+			return ErrCorrupted
+		}
+		if _, err := w.Write(plaintext); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// segmentReader seals its source segment by segment. The last segment is
+// authenticated as final, so truncated ciphertexts are detected.
+type segmentReader struct {
+	src       *bufio.Reader
+	aead      cipher.AEAD
+	nonce     []byte
+	index     uint32
+	plaintext []byte
+	sealed    []byte
+	done      bool
+	err       error
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	for len(s.sealed) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.seal()
+	}
+	n := copy(p, s.sealed)
+	s.sealed = s.sealed[n:]
+	return n, nil
+}
+
+// seal encrypts the next segment of the source
+func (s *segmentReader) seal() {
+	n, err := io.ReadFull(s.src, s.plaintext)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		s.err = err
+		return
+	}
+	final := isEOF(s.src)
+	if s.index == ^uint32(0) && !final {
+		s.err = errors.New("content is too large to encrypt")
+		return
+	}
+	s.sealed = s.aead.Seal(s.sealed[:0], segmentNonce(s.nonce, s.index), s.plaintext[:n], segmentAAD(final))
+	s.index++
+	s.done = final
+}
+
+// isEOF reports whether r has no more data
+func isEOF(r *bufio.Reader) bool {
+	_, err := r.Peek(1)
+	return err != nil
+}
+
+func segmentNonce(prefix []byte, index uint32) []byte {
+	nonce := make([]byte, NonceSize+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[NonceSize:], index)
+	return nonce
+}
+
+func segmentAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption keys must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// wrapKey encrypts the data key with kek, prefixed by a random nonce
+func wrapKey(kek, dataKey []byte) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dataKey, wrapAAD), nil
+}
+
+func unwrapKey(kek, wrapped []byte) ([]byte, error) {
+	aead, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	dataKey, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], wrapAAD)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return dataKey, nil
+}
@@ -0,0 +1,101 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func encrypt(t *testing.T, plaintext, kek []byte) ([]byte, Header) {
+	t.Helper()
+	r, header, err := NewEncrypter(bytes.NewReader(plaintext), kek)
+	if err != nil {
+		t.Fatalf("NewEncrypter() unexpected error: %v", err)
+	}
+	ciphertext, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading ciphertext: %v", err)
+	}
+	return ciphertext, header
+}
+
+func TestRoundTrip(t *testing.T) {
+	kek := testKey(1)
+	for _, size := range []int{0, 1, SegmentSize - 1, SegmentSize, SegmentSize + 1, 3*SegmentSize + 17} {
+		plaintext := make([]byte, size)
+		for i := range plaintext {
+			plaintext[i] = byte(i * 7)
+		}
+
+		ciphertext, header := encrypt(t, plaintext, kek)
+		if got, want := int64(len(ciphertext)), EncryptedSize(int64(size)); got != want {
+			t.Errorf("size %d: ciphertext is %d bytes, EncryptedSize() = %d", size, got, want)
+		}
+		if got := DecryptedSize(int64(len(ciphertext))); got != int64(size) {
+			t.Errorf("size %d: DecryptedSize(%d) = %d", size, len(ciphertext), got)
+		}
+		if size > 16 && bytes.Contains(ciphertext, plaintext[:16]) {
+			t.Errorf("size %d: ciphertext contains plaintext", size)
+		}
+
+		var decrypted bytes.Buffer
+		if err := Decrypt(&decrypted, bytes.NewReader(ciphertext), kek, header); err != nil {
+			t.Fatalf("size %d: Decrypt() unexpected error: %v", size, err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Errorf("size %d: decrypted content differs from the plaintext", size)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	kek := testKey(1)
+	plaintext := bytes.Repeat([]byte("secret "), SegmentSize/2)
+	ciphertext, header := encrypt(t, plaintext, kek)
+
+	flipped := bytes.Clone(ciphertext)
+	flipped[10] ^= 1
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		kek        []byte
+		wantErr    error
+	}{
+		{"wrong key", ciphertext, testKey(2), ErrWrongKey},
+		{"modified", flipped, kek, ErrCorrupted},
+		{"truncated at segment boundary", ciphertext[:SegmentSize+tagSize], kek, ErrCorrupted},
+		{"truncated", ciphertext[:len(ciphertext)-1], kek, ErrCorrupted},
+		{"empty", nil, kek, ErrCorrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Decrypt(io.Discard, bytes.NewReader(tt.ciphertext), tt.kek, header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptedSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want int64
+	}{
+		{-1, -1},
+		{0, tagSize},
+		{SegmentSize, SegmentSize + tagSize},
+		{SegmentSize + 1, SegmentSize + 1 + 2*tagSize},
+	}
+	for _, tt := range tests {
+		if got := EncryptedSize(tt.size); got != tt.want {
+			t.Errorf("EncryptedSize(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package secretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gogodjzhu/gogobox/internal/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// EnvSecretsFile overrides the location of the secrets file
+	EnvSecretsFile = "GOGOBOX_SECRETS_FILE"
+
+	secretsFileName = "secrets.enc"
+	fileVersion     = 1
+	kdfScrypt       = "scrypt"
+	cipherAESGCM    = "aes-256-gcm"
+	keyLength       = 32
+	saltLength      = 16
+)
+
+// ErrWrongPassphrase is returned when the secrets file cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted secrets file")
+
+// scryptParams are the key derivation parameters, stored in the file so they
+// can be raised later without breaking existing stores
+type scryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+var defaultParams = scryptParams{N: 1 << 15, R: 8, P: 1}
+
+// encryptedFile is the on-disk format of the secrets store
+type encryptedFile struct {
+	Version    int          `json:"version"`
+	KDF        string       `json:"kdf"`
+	KDFParams  scryptParams `json:"kdfParams"`
+	Salt       []byte       `json:"salt"`
+	Cipher     string       `json:"cipher"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+}
+
+// Store is an unlocked secrets store. Secrets are kept in memory in plaintext
+// and encrypted with a key derived from the passphrase when saved.
+type Store struct {
+	path    string
+	salt    []byte
+	params  scryptParams
+	key     []byte
+	secrets map[string]string
+}
+
+// DefaultPath returns the path of the secrets file
+func DefaultPath() (string, error) {
+	if path := os.Getenv(EnvSecretsFile); path != "" {
+		return path, nil
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, secretsFileName), nil
+}
+
+// Exists reports whether a secrets file exists at path
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Open unlocks the secrets file at path with passphrase. If the file does not
+// exist an empty store is returned, which is created on the first Save.
+func Open(path, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newStore(path, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %w", path, err)
+	}
+	if file.Version != fileVersion || file.KDF != kdfScrypt || file.Cipher != cipherAESGCM {
+		return nil, fmt.Errorf("unsupported secrets file %s (version %d, %s, %s)", path, file.Version, file.KDF, file.Cipher)
+	}
+
+	key, err := deriveKey(passphrase, file.Salt, file.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+
+	return &Store{
+		path:    path,
+		salt:    file.Salt,
+		params:  file.KDFParams,
+		key:     key,
+		secrets: secrets,
+	}, nil
+}
+
+func newStore(path, passphrase string) (*Store, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt, defaultParams)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		path:    path,
+		salt:    salt,
+		params:  defaultParams,
+		key:     key,
+		secrets: map[string]string{},
+	}, nil
+}
+
+// Get returns the named secret
+func (s *Store) Get(name string) (string, bool) {
+	value, ok := s.secrets[name]
+	return value, ok
+}
+
+// Set adds or replaces the named secret
+func (s *Store) Set(name, value string) {
+	s.secrets[name] = value
+}
+
+// Remove deletes the named secret and reports whether it existed
+func (s *Store) Remove(name string) bool {
+	_, ok := s.secrets[name]
+	delete(s.secrets, name)
+	return ok
+}
+
+// Names returns the sorted names of all secrets
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts the secrets with a fresh nonce and writes them to disk
+func (s *Store) Save() error {
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.MarshalIndent(encryptedFile{
+		Version:    fileVersion,
+		KDF:        kdfScrypt,
+		KDFParams:  s.params,
+		Salt:       s.salt,
+		Cipher:     cipherAESGCM,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	// Write to a temp file first so a crash never leaves a truncated store
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+func deriveKey(passphrase string, salt []byte, params scryptParams) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
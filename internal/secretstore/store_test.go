@@ -0,0 +1,90 @@
+package secretstore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+
+	store, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	if len(store.Names()) != 0 {
+		t.Errorf("new store should be empty, got %v", store.Names())
+	}
+	store.Set("minio-prod", "s3cr3t")
+	store.Set("minio-dev", "dev-secret")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	// The secret must not be stored in plaintext
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("secrets file not written: %v", err)
+	}
+	if bytes.Contains(data, []byte("s3cr3t")) {
+		t.Errorf("secrets file contains a plaintext secret")
+	}
+	if stat, _ := os.Stat(path); stat.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode = %v, want 0600", stat.Mode().Perm())
+	}
+
+	reopened, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	if got := reopened.Names(); !reflect.DeepEqual(got, []string{"minio-dev", "minio-prod"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if value, ok := reopened.Get("minio-prod"); !ok || value != "s3cr3t" {
+		t.Errorf("Get() = %q, %v", value, ok)
+	}
+
+	if !reopened.Remove("minio-dev") || reopened.Remove("minio-dev") {
+		t.Errorf("Remove() should report whether the secret existed")
+	}
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+	reopened, _ = Open(path, "correct horse")
+	if _, ok := reopened.Get("minio-dev"); ok {
+		t.Errorf("removed secret still present")
+	}
+}
+
+func TestOpenWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	store, err := Open(path, "right")
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	store.Set("name", "value")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	if _, err := Open(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open() error = %v, want ErrWrongPassphrase", err)
+	}
+	if _, err := Open(path, ""); err == nil {
+		t.Errorf("Open() expected error for empty passphrase")
+	}
+}
+
+func TestOpenCorruptedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := os.WriteFile(path, []byte("garbage"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := Open(path, "passphrase"); err == nil {
+		t.Errorf("Open() expected error for corrupted file")
+	}
+}
@@ -2,13 +2,20 @@ package util
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 func SendGet(url string, header map[string]string, wrap func(response *http.Response) (interface{}, error)) (interface{}, error) {
@@ -97,3 +104,159 @@ func DownloadToTempFile(url string) (string, error) {
 
 	return tmpFile.Name(), nil
 }
+
+// FetchOptions limit the requests of FetchRemote
+type FetchOptions struct {
+	// MaxSize fails responses with more than MaxSize bytes, 0 for no limit
+	MaxSize int64
+	// Timeout bounds connecting and waiting for the response, and every
+	// read of the body, 0 for no limit. Time spent by the consumer between
+	// reads does not count.
+	Timeout time.Duration
+	// MaxRedirects is the number of redirects that are followed
+	MaxRedirects int
+}
+
+// RemoteFile is the response of FetchRemote. Its Body must be closed.
+type RemoteFile struct {
+	Body io.ReadCloser
+	// Size is the Content-Length, -1 if it is unknown
+	Size int64
+	// ContentType is the Content-Type header, "" if there is none
+	ContentType string
+	// Name is the file name of the Content-Disposition header, or the last
+	// segment of the URL path. It is "" if neither names a file.
+	Name string
+}
+
+// ErrTooLarge is returned for responses larger than FetchOptions.MaxSize
+var ErrTooLarge = errors.New("file exceeds the size limit")
+
+// FetchRemote requests an http(s) URL and returns its body as a stream. The
+// request and the body are aborted when ctx is cancelled.
+func FetchRemote(ctx context.Context, rawURL string, opts FetchOptions) (*RemoteFile, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	body := &timeoutReader{timeout: opts.Timeout, cancel: cancel}
+	if opts.Timeout > 0 {
+		body.timer = time.AfterFunc(opts.Timeout, body.expire)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", opts.MaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported URL %s", req.URL)
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		body.Close()
+		if body.expired.Load() {
+			return nil, fmt.Errorf("failed to download %s: no response within %s", rawURL, opts.Timeout)
+		}
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	body.body = resp.Body
+	if resp.StatusCode != http.StatusOK {
+		body.Close()
+		return nil, fmt.Errorf("failed to download %s: status %s", rawURL, resp.Status)
+	}
+	if opts.MaxSize > 0 && resp.ContentLength > opts.MaxSize {
+		body.Close()
+		return nil, fmt.Errorf("failed to download %s: %w (%s > %s)", rawURL, ErrTooLarge,
+			HumanSize(resp.ContentLength), HumanSize(opts.MaxSize))
+	}
+	body.r, body.remaining, body.limited = resp.Body, opts.MaxSize, opts.MaxSize > 0
+	// The timer runs again while the body is read
+	body.stopTimer()
+
+	return &RemoteFile{
+		Body:        body,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		Name:        remoteFileName(rawURL, resp.Header.Get("Content-Disposition")),
+	}, nil
+}
+
+// remoteFileName returns the file name of a Content-Disposition header or
+// the last segment of the URL path
+func remoteFileName(rawURL, disposition string) string {
+	if _, params, err := mime.ParseMediaType(disposition); err == nil {
+		// Names are not trusted to stay in their directory
+		name := path.Base(strings.ReplaceAll(params["filename"], "\\", "/"))
+		if name != "." && name != "/" && name != ".." {
+			return name
+		}
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		if name := path.Base(u.Path); name != "." && name != "/" {
+			return name
+		}
+	}
+	return ""
+}
+
+// timeoutReader fails reads that wait for data longer than Timeout, and
+// reads beyond the size limit. The timer only runs during reads, consumers
+// that are busy elsewhere, like uploading a buffered part, do not stall it.
+type timeoutReader struct {
+	r         io.Reader
+	body      io.Closer
+	timer     *time.Timer
+	timeout   time.Duration
+	cancel    context.CancelFunc
+	expired   atomic.Bool
+	remaining int64
+	limited   bool
+}
+
+func (t *timeoutReader) expire() {
+	t.expired.Store(true)
+	t.cancel()
+}
+
+func (t *timeoutReader) resetTimer() {
+	if t.timer != nil {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+func (t *timeoutReader) stopTimer() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+func (t *timeoutReader) Read(p []byte) (int, error) {
+	t.resetTimer()
+	n, err := t.r.Read(p)
+	t.stopTimer()
+	if t.expired.Load() {
+		return n, fmt.Errorf("no data received for %s", t.timeout)
+	}
+	if t.limited {
+		if int64(n) > t.remaining {
+			return n, ErrTooLarge
+		}
+		t.remaining -= int64(n)
+	}
+	return n, err
+}
+
+func (t *timeoutReader) Close() error {
+	t.stopTimer()
+	t.cancel()
+	if t.body != nil {
+		return t.body.Close()
+	}
+	return nil
+}
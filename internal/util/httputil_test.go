@@ -1,11 +1,18 @@
 package util
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDownloadToTempFile(t *testing.T) {
@@ -35,3 +42,127 @@ func TestDownloadToTempFile(t *testing.T) {
 		t.Errorf("Unexpected file content: %s", string(content))
 	}
 }
+
+func TestFetchRemote(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.7"))
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../../etc/quarterly.csv"`)
+		w.Write([]byte("a,b"))
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 100))
+	})
+	mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 10; i++ {
+			w.Write(bytes.Repeat([]byte("x"), 10))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("start"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+		if n == 0 {
+			http.Redirect(w, r, "/files/report.pdf", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		opts            FetchOptions
+		wantName        string
+		wantContentType string
+		wantContent     string
+		wantErr         string
+	}{
+		{name: "url name", path: "/files/report.pdf", wantName: "report.pdf", wantContentType: "application/pdf", wantContent: "%PDF-1.7"},
+		{name: "content disposition", path: "/download", wantName: "quarterly.csv", wantContent: "a,b"},
+		{name: "redirects", path: "/redirect/2", opts: FetchOptions{MaxRedirects: 3}, wantName: "2", wantContent: "%PDF-1.7"},
+		{name: "too many redirects", path: "/redirect/2", opts: FetchOptions{MaxRedirects: 2}, wantErr: "stopped after 2 redirects"},
+		{name: "content length too large", path: "/big", opts: FetchOptions{MaxSize: 99}, wantErr: "exceeds the size limit"},
+		{name: "stream too large", path: "/chunked", opts: FetchOptions{MaxSize: 99}, wantErr: "exceeds the size limit"},
+		{name: "stream at limit", path: "/chunked", opts: FetchOptions{MaxSize: 100}, wantName: "chunked", wantContent: strings.Repeat("x", 100)},
+		{name: "stalled", path: "/slow", opts: FetchOptions{Timeout: 100 * time.Millisecond}, wantErr: "no data received"},
+		{name: "status", path: "/missing", wantErr: "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := FetchRemote(context.Background(), ts.URL+tt.path, tt.opts)
+			var content []byte
+			if err == nil {
+				content, err = io.ReadAll(remote.Body)
+				remote.Body.Close()
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FetchRemote() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchRemote() unexpected error: %v", err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+			if remote.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", remote.Name, tt.wantName)
+			}
+			if tt.wantContentType != "" && remote.ContentType != tt.wantContentType {
+				t.Errorf("ContentType = %q, want %q", remote.ContentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestFetchRemoteSlowConsumer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			w.Write(bytes.Repeat([]byte("x"), 10))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	timeout := 50 * time.Millisecond
+	remote, err := FetchRemote(context.Background(), ts.URL, FetchOptions{Timeout: timeout})
+	if err != nil {
+		t.Fatalf("FetchRemote() unexpected error: %v", err)
+	}
+	defer remote.Body.Close()
+
+	// Like an upload of a buffered part, the consumer is busy between reads
+	time.Sleep(3 * timeout)
+	buf := make([]byte, 10)
+	var content []byte
+	for {
+		n, err := remote.Body.Read(buf)
+		content = append(content, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() unexpected error: %v", err)
+		}
+		time.Sleep(3 * timeout)
+	}
+	if len(content) != 30 {
+		t.Errorf("read %d bytes, want 30", len(content))
+	}
+}
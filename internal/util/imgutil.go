@@ -7,10 +7,36 @@ import (
 	"image"
 	"image/jpeg"
 	"image/png"
+	"mime"
 	"os"
 	"path/filepath"
 )
 
+// ResizeImage returns the file to store for filename: a resized JPEG copy in
+// the temporary directory for PNG and JPEG images larger than maxSize, which
+// the caller removes, and filename itself for other files
+func ResizeImage(filename string, maxSize int64) (string, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return "", err
+	}
+	if stat.Size() <= maxSize || !IsResizableContentType(DetectContentType(filename)) {
+		return filename, nil
+	}
+	return CompressImage(filename, maxSize, "jpeg")
+}
+
+// IsResizableContentType reports whether images of the content type can be
+// resized
+func IsResizableContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "image/png", "image/jpeg":
+		return true
+	}
+	return false
+}
+
 // CompressImage reads a local image file and compresses it if it exceeds maxSize.
 // The compressed image is saved to /tmp/{random-name}.{format} while maintaining aspect ratio.
 // If the original image is smaller than maxSize, it's saved without compression.
@@ -380,3 +380,51 @@ func TestSaveCompressedImage_QualityLevels(t *testing.T) {
 		t.Errorf("saveCompressedImage() JPEG file should have .jpeg extension, got %v", tempPath)
 	}
 }
+
+func TestResizeImage(t *testing.T) {
+	large, err := createTestImageFile(400, 300, "png")
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+	defer os.Remove(large)
+	text, err := os.CreateTemp("", "test_text_*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text.WriteString(strings.Repeat("not an image ", 1000))
+	text.Close()
+	defer os.Remove(text.Name())
+
+	tests := []struct {
+		name        string
+		filename    string
+		maxSize     int64
+		wantResized bool
+	}{
+		{"Large image", large, 1000, true},
+		{"Small image", large, 1 << 20, false},
+		{"Not an image", text.Name(), 1000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResizeImage(tt.filename, tt.maxSize)
+			if err != nil {
+				t.Fatalf("ResizeImage() unexpected error: %v", err)
+			}
+			if resized := got != tt.filename; resized != tt.wantResized {
+				t.Fatalf("ResizeImage() = %s, resized %v, want %v", got, resized, tt.wantResized)
+			}
+			if tt.wantResized {
+				defer os.Remove(got)
+				if !strings.HasSuffix(got, ".jpeg") {
+					t.Errorf("ResizeImage() = %s, want a JPEG copy", got)
+				}
+			}
+		})
+	}
+
+	if _, err := ResizeImage("/non/existent/file.png", 1000); err == nil {
+		t.Errorf("ResizeImage() expected error for a missing file")
+	}
+}
@@ -0,0 +1,298 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is the number of leading bytes inspected by SniffContentType
+const sniffLen = 512
+
+// DefaultContentType is the content type of unrecognized binary data
+const DefaultContentType = "application/octet-stream"
+
+// extensionTypes maps lower case file extensions to content types
+var extensionTypes = map[string]string{
+	// Images
+	".apng": "image/apng",
+	".avif": "image/avif",
+	".bmp":  "image/bmp",
+	".gif":  "image/gif",
+	".heic": "image/heic",
+	".heif": "image/heif",
+	".ico":  "image/x-icon",
+	".jfif": "image/jpeg",
+	".jpe":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".jxl":  "image/jxl",
+	".png":  "image/png",
+	".psd":  "image/vnd.adobe.photoshop",
+	".svg":  "image/svg+xml",
+	".svgz": "image/svg+xml",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".webp": "image/webp",
+
+	// Audio
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mid":  "audio/midi",
+	".midi": "audio/midi",
+	".mp3":  "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".wav":  "audio/wav",
+	".weba": "audio/webm",
+
+	// Video
+	".3gp":  "video/3gpp",
+	".avi":  "video/x-msvideo",
+	".flv":  "video/x-flv",
+	".m3u8": "application/vnd.apple.mpegurl",
+	".m4v":  "video/mp4",
+	".mkv":  "video/x-matroska",
+	".mov":  "video/quicktime",
+	".mp4":  "video/mp4",
+	".mpeg": "video/mpeg",
+	".mpg":  "video/mpeg",
+	".ogv":  "video/ogg",
+	".ts":   "video/mp2t",
+	".webm": "video/webm",
+	".wmv":  "video/x-ms-wmv",
+
+	// Text and source code
+	".css":         "text/css",
+	".csv":         "text/csv",
+	".htm":         "text/html",
+	".html":        "text/html",
+	".ics":         "text/calendar",
+	".js":          "text/javascript",
+	".json":        "application/json",
+	".jsonld":      "application/ld+json",
+	".log":         "text/plain",
+	".map":         "application/json",
+	".markdown":    "text/markdown",
+	".md":          "text/markdown",
+	".mjs":         "text/javascript",
+	".ndjson":      "application/x-ndjson",
+	".rss":         "application/rss+xml",
+	".atom":        "application/atom+xml",
+	".srt":         "application/x-subrip",
+	".toml":        "application/toml",
+	".tsv":         "text/tab-separated-values",
+	".txt":         "text/plain",
+	".vtt":         "text/vtt",
+	".webmanifest": "application/manifest+json",
+	".xhtml":       "application/xhtml+xml",
+	".xml":         "application/xml",
+	".yaml":        "application/yaml",
+	".yml":         "application/yaml",
+
+	// Documents
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".epub": "application/epub+zip",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".pdf":  "application/pdf",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".rtf":  "application/rtf",
+	".xls":  "application/vnd.ms-excel",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+
+	// Fonts
+	".eot":   "application/vnd.ms-fontobject",
+	".otf":   "font/otf",
+	".ttf":   "font/ttf",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+
+	// Archives and binaries
+	".7z":   "application/x-7z-compressed",
+	".apk":  "application/vnd.android.package-archive",
+	".bz2":  "application/x-bzip2",
+	".deb":  "application/vnd.debian.binary-package",
+	".dmg":  "application/x-apple-diskimage",
+	".gz":   "application/gzip",
+	".iso":  "application/x-iso9660-image",
+	".jar":  "application/java-archive",
+	".rar":  "application/vnd.rar",
+	".rpm":  "application/x-rpm",
+	".tar":  "application/x-tar",
+	".tgz":  "application/gzip",
+	".wasm": "application/wasm",
+	".xz":   "application/x-xz",
+	".zip":  "application/zip",
+	".zst":  "application/zstd",
+}
+
+// preferredExtensions choose among the extensions of a content type
+var preferredExtensions = map[string]string{
+	"image/jpeg":       ".jpg",
+	"image/tiff":       ".tif",
+	"image/svg+xml":    ".svg",
+	"audio/midi":       ".mid",
+	"audio/ogg":        ".ogg",
+	"video/mp4":        ".mp4",
+	"video/mpeg":       ".mpg",
+	"text/html":        ".html",
+	"text/javascript":  ".js",
+	"text/markdown":    ".md",
+	"text/plain":       ".txt",
+	"application/json": ".json",
+	"application/yaml": ".yaml",
+	"application/gzip": ".gz",
+}
+
+// signature is a magic byte sequence at a fixed offset of a file header
+type signature struct {
+	offset int
+	magic  []byte
+	// subtype is the format of RIFF and ISO media containers at offset 8
+	subtype     []byte
+	contentType string
+	// Containers are shared by many formats (zip by docx, jar, epub, ...),
+	// the extension is more specific if it is known
+	container bool
+}
+
+// signatures are the recognized file headers, most specific first
+var signatures = []signature{
+	{magic: []byte("\x89PNG\r\n\x1a\n"), contentType: "image/png"},
+	{magic: []byte("\xff\xd8\xff"), contentType: "image/jpeg"},
+	{magic: []byte("GIF87a"), contentType: "image/gif"},
+	{magic: []byte("GIF89a"), contentType: "image/gif"},
+	{magic: []byte("RIFF"), subtype: []byte("WEBP"), contentType: "image/webp"},
+	{magic: []byte("RIFF"), subtype: []byte("WAVE"), contentType: "audio/wav"},
+	{magic: []byte("RIFF"), subtype: []byte("AVI "), contentType: "video/x-msvideo"},
+	{magic: []byte("II*\x00"), contentType: "image/tiff"},
+	{magic: []byte("MM\x00*"), contentType: "image/tiff"},
+	{magic: []byte("8BPS"), contentType: "image/vnd.adobe.photoshop"},
+	{offset: 4, magic: []byte("ftyp"), subtype: []byte("avif"), contentType: "image/avif"},
+	{offset: 4, magic: []byte("ftyp"), subtype: []byte("heic"), contentType: "image/heic"},
+	{offset: 4, magic: []byte("ftyp"), subtype: []byte("qt  "), contentType: "video/quicktime"},
+	{offset: 4, magic: []byte("ftyp"), subtype: []byte("M4A "), contentType: "audio/mp4"},
+	{offset: 4, magic: []byte("ftyp"), contentType: "video/mp4", container: true},
+	{magic: []byte("\x1a\x45\xdf\xa3"), contentType: "video/webm", container: true},
+	{magic: []byte("OggS"), contentType: "audio/ogg", container: true},
+	{magic: []byte("ID3"), contentType: "audio/mpeg"},
+	{magic: []byte("fLaC"), contentType: "audio/flac"},
+	{magic: []byte("%PDF-"), contentType: "application/pdf"},
+	{magic: []byte("{\\rtf"), contentType: "application/rtf"},
+	{magic: []byte("wOFF"), contentType: "font/woff"},
+	{magic: []byte("wOF2"), contentType: "font/woff2"},
+	{magic: []byte("OTTO"), contentType: "font/otf"},
+	{magic: []byte("PK\x03\x04"), contentType: "application/zip", container: true},
+	{magic: []byte("\x1f\x8b"), contentType: "application/gzip", container: true},
+	{magic: []byte("BZh"), contentType: "application/x-bzip2"},
+	{magic: []byte("\xfd7zXZ\x00"), contentType: "application/x-xz"},
+	{magic: []byte("\x28\xb5\x2f\xfd"), contentType: "application/zstd"},
+	{magic: []byte("7z\xbc\xaf\x27\x1c"), contentType: "application/x-7z-compressed"},
+	{magic: []byte("Rar!\x1a\x07"), contentType: "application/vnd.rar"},
+	{magic: []byte("\x00asm"), contentType: "application/wasm"},
+	{magic: []byte("SQLite format 3\x00"), contentType: "application/vnd.sqlite3"},
+	{magic: []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), contentType: "application/x-ole-storage", container: true},
+}
+
+// ContentTypeByExtension returns the content type registered for the
+// extension of name, or "" if it is unknown
+func ContentTypeByExtension(name string) string {
+	return extensionTypes[strings.ToLower(filepath.Ext(name))]
+}
+
+// ExtensionByContentType returns the extension (with dot) of files of the
+// content type, or "" if it is unknown. Parameters are ignored.
+func ExtensionByContentType(contentType string) string {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	// Extensions are tried in order so the result does not depend on map
+	// iteration
+	best := ""
+	for ext, typ := range extensionTypes {
+		if typ == mediaType && (best == "" || ext < best) {
+			best = ext
+		}
+	}
+	return best
+}
+
+// SniffContentType returns the content type recognized from the magic bytes
+// at the start of header, or "" if there are none. container reports
+// signatures that are shared by several formats.
+func SniffContentType(header []byte) (contentType string, container bool) {
+	for _, sig := range signatures {
+		end := sig.offset + len(sig.magic)
+		if len(header) < end || !bytes.Equal(header[sig.offset:end], sig.magic) {
+			continue
+		}
+		if sig.subtype != nil && (len(header) < 12 || !bytes.Equal(header[8:12], sig.subtype)) {
+			continue
+		}
+		return sig.contentType, sig.container
+	}
+	return "", false
+}
+
+// DetectContentType returns the content type of the named file from the
+// signature of its header and its extension. Distinct signatures win over
+// the extension, so mislabeled images are served correctly, and containers
+// are only used for unknown extensions. Files without either are sniffed as
+// text or fall back to DefaultContentType. Files that cannot be read, e.g.
+// URLs or object keys, are typed by their extension alone.
+func DetectContentType(name string) string {
+	header, _ := readHeader(name)
+	return DetectContentTypeOf(name, header)
+}
+
+// DetectContentTypeOf is DetectContentType for an already read file header
+func DetectContentTypeOf(name string, header []byte) string {
+	sniffed, container := SniffContentType(header)
+	if sniffed != "" && !container {
+		return sniffed
+	}
+	if byExtension := ContentTypeByExtension(name); byExtension != "" {
+		return byExtension
+	}
+	if sniffed != "" {
+		return sniffed
+	}
+	if len(header) > 0 {
+		// Recognizes text and markup
+		if detected := http.DetectContentType(header); strings.HasPrefix(detected, "text/") {
+			return detected
+		}
+	}
+	return DefaultContentType
+}
+
+// IsImageContentType reports whether contentType is an image type
+func IsImageContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// readHeader reads up to sniffLen bytes from the start of the named file
+func readHeader(name string) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectContentTypeOf(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	zip := []byte("PK\x03\x04\x14\x00\x06\x00")
+	mp4 := []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00")
+
+	tests := []struct {
+		name   string
+		file   string
+		header []byte
+		want   string
+	}{
+		{"extension only", "logo.svg", nil, "image/svg+xml"},
+		{"upper case extension", "CLIP.MP4", nil, "video/mp4"},
+		{"json", "data.json", []byte(`{"a": 1}`), "application/json"},
+		{"html", "index.html", []byte("<!DOCTYPE html>"), "text/html"},
+		{"webp", "photo.webp", nil, "image/webp"},
+		{"signature without extension", "upload", png, "image/png"},
+		{"signature beats wrong extension", "screenshot.jpg", png, "image/png"},
+		{"extension beats container", "report.docx", zip, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"container without known extension", "bundle.bin", zip, "application/zip"},
+		{"mp4 brand", "movie", mp4, "video/mp4"},
+		{"webp signature", "image", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"wav signature", "sound", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "audio/wav"},
+		{"text without extension", "Makefile", []byte("all:\n\tgo build ./...\n"), "text/plain; charset=utf-8"},
+		{"binary without extension", "blob", []byte{0x00, 0x01, 0x02, 0xff}, DefaultContentType},
+		{"nothing known", "", nil, DefaultContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentTypeOf(tt.file, tt.header); got != tt.want {
+				t.Errorf("DetectContentTypeOf(%q) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "noext")
+	if err := os.WriteFile(file, []byte("GIF89a\x01\x00\x01\x00"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if got := DetectContentType(file); got != "image/gif" {
+		t.Errorf("DetectContentType() = %v, want image/gif", got)
+	}
+	// Missing files and URLs fall back to the extension
+	if got := DetectContentType("https://example.com/a.css"); got != "text/css" {
+		t.Errorf("DetectContentType() of a URL = %v, want text/css", got)
+	}
+	if got := DetectContentType(dir); got != DefaultContentType {
+		t.Errorf("DetectContentType() of a directory = %v", got)
+	}
+}
+
+func TestExtensionByContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"image/png", ".png"},
+		{"image/jpeg", ".jpg"},
+		{"text/plain; charset=utf-8", ".txt"},
+		{"Application/PDF", ".pdf"},
+		{"application/x-unknown", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtensionByContentType(tt.contentType); got != tt.want {
+			t.Errorf("ExtensionByContentType(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the number of light modules around a QR code
+const qrQuietZone = 2
+
+// RenderQRCode returns text as a QR code drawn with block characters, two
+// rows of modules per line. Light modules are drawn and dark ones left blank,
+// which scans on the dark background of most terminals.
+func RenderQRCode(text string) (string, error) {
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	first, last := -qrQuietZone, code.Size+qrQuietZone
+	for y := first; y < last; y += 2 {
+		for x := first; x < last; x++ {
+			top := !code.Black(x, y)
+			// The quiet zone below the code is light
+			bottom := y+1 >= last || !code.Black(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"rsc.io/qr"
+)
+
+func TestRenderQRCode(t *testing.T) {
+	text := "http://192.168.1.5:8080/?token=secret"
+	got, err := RenderQRCode(text)
+	if err != nil {
+		t.Fatalf("RenderQRCode() unexpected error: %v", err)
+	}
+	code, _ := qr.Encode(text, qr.L)
+
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	width := code.Size + 2*qrQuietZone
+	if want := (width + 1) / 2; len(lines) != want {
+		t.Fatalf("RenderQRCode() has %d lines, want %d", len(lines), want)
+	}
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(line); n != width {
+			t.Errorf("line %d has %d characters, want %d", i, n, width)
+		}
+	}
+	// The quiet zone is light, the finder patterns start with dark modules
+	if lines[0] != strings.Repeat("█", width) {
+		t.Errorf("first line = %q, want the quiet zone", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "██ ") {
+		t.Errorf("second line = %q, want the top of a finder pattern", lines[1])
+	}
+
+	if _, err := RenderQRCode(strings.Repeat("x", 4000)); err == nil {
+		t.Errorf("RenderQRCode() expected error for text too long for a QR code")
+	}
+}
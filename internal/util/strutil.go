@@ -1,5 +1,12 @@
 package util
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
 // SplitWorker split string by separatorChars, and keep the separator
 func SplitWorker(str string, separatorChars []string) []string {
 	if str == "" {
@@ -41,3 +48,50 @@ func Contains(arr []string, str string) bool {
 	}
 	return false
 }
+
+// HumanSize formats a byte count using binary units, e.g. 1536 -> "1.5KiB"
+func HumanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseSize parses a byte count with an optional unit suffix. Both binary
+// ("KiB", "MiB", ...) and short ("K", "M", "KB", "MB", ...) suffixes are
+// interpreted as powers of 1024, e.g. "16MiB", "16M" and "16MB" are equal.
+func ParseSize(s string) (int64, error) {
+	str := strings.TrimSpace(s)
+	i := len(str)
+	for i > 0 && (str[i-1] < '0' || str[i-1] > '9') && str[i-1] != '.' {
+		i--
+	}
+	number, suffix := str[:i], strings.ToUpper(strings.TrimSpace(str[i:]))
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+
+	exp := -1
+	if suffix != "" && suffix != "B" {
+		exp = strings.IndexByte("KMGTPE", suffix[0])
+		if rest := suffix[1:]; exp < 0 || (rest != "" && rest != "B" && rest != "IB") {
+			return 0, fmt.Errorf("invalid size unit: %s", s)
+		}
+	}
+	for ; exp >= 0; exp-- {
+		value *= 1024
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which does not fit
+	if value >= math.MaxInt64 {
+		return 0, fmt.Errorf("size too large: %s", s)
+	}
+	return int64(value), nil
+}
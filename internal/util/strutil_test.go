@@ -48,3 +48,59 @@ func Test_splitWorker(t *testing.T) {
 		})
 	}
 }
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{1536, "1.5KiB"},
+		{5 * 1024 * 1024, "5.0MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := HumanSize(tt.size); got != tt.want {
+			t.Errorf("HumanSize(%d) = %s, want %s", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"0", 0, false},
+		{"512B", 512, false},
+		{"16MiB", 16 * 1024 * 1024, false},
+		{"16M", 16 * 1024 * 1024, false},
+		{"16mb", 16 * 1024 * 1024, false},
+		{"1.5KiB", 1536, false},
+		{" 2 GiB ", 2 * 1024 * 1024 * 1024, false},
+		{"", 0, true},
+		{"MiB", 0, true},
+		{"12XB", 0, true},
+		{"-1", 0, true},
+		{"16I", 0, true},
+		{"16KBI", 0, true},
+		{"7EiB", 7 << 60, false},
+		{"10E", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
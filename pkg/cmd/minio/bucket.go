@@ -0,0 +1,248 @@
+package minio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+)
+
+type MakeBucketOptions struct {
+	Config         *MinIOConfig
+	IgnoreExisting bool
+	Output         string
+}
+
+type RemoveBucketOptions struct {
+	Config *MinIOConfig
+	Force  bool
+	Yes    bool
+	Output string
+}
+
+// bucketResult is the JSON representation of mb and rb results
+type bucketResult struct {
+	Bucket         string `json:"bucket"`
+	Region         string `json:"region,omitempty"`
+	Created        bool   `json:"created,omitempty"`
+	Removed        bool   `json:"removed,omitempty"`
+	ObjectsRemoved int    `json:"objectsRemoved,omitempty"`
+}
+
+func NewCmdMinIOMakeBucket(f *cmdutil.Factory) *cobra.Command {
+	opts := &MakeBucketOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "mb [flags] [bucket]",
+		Short: "Create a bucket",
+		Long: `Create a bucket, by default the configured one.
+
+The bucket is created in the configured region (--region or the region of the
+profile), or the server's default region if none is set.`,
+		Example: `  # Create the bucket of the current profile
+  gogobox minio mb
+
+  # Create another bucket in a region, succeeding if it already exists
+  gogobox minio mb --region eu-west-1 --ignore-existing backups`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runMakeBucket(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().BoolVarP(&opts.IgnoreExisting, "ignore-existing", "p", false, "Do not fail if the bucket already exists")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputText, "Output format: text or json")
+
+	return cmd
+}
+
+func NewCmdMinIORemoveBucket(f *cmdutil.Factory) *cobra.Command {
+	opts := &RemoveBucketOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rb [flags] [bucket]",
+		Short: "Remove a bucket",
+		Long: `Remove a bucket, by default the configured one.
+
+Only empty buckets can be removed. With --force every object in the bucket is
+deleted first, after confirming like rm -r does unless --yes is given.`,
+		Example: `  # Remove an empty bucket
+  gogobox minio rb old-bucket
+
+  # Remove a bucket and all of its objects
+  gogobox minio rb --force --yes scratch`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runRemoveBucket(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Delete all objects of the bucket before removing it")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Delete the objects without asking for confirmation")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputText, "Output format: text or json")
+
+	return cmd
+}
+
+func runMakeBucket(ctx context.Context, f *cmdutil.Factory, opts *MakeBucketOptions, args []string) error {
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+
+	bucketName := opts.Config.BucketName
+	result := bucketResult{Bucket: bucketName, Region: opts.Config.Region, Created: true}
+	if err := client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{Region: opts.Config.Region}); err != nil {
+		code := minio.ToErrorResponse(err).Code
+		if !opts.IgnoreExisting || (code != "BucketAlreadyOwnedByYou" && code != "BucketAlreadyExists") {
+			return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
+		}
+		result.Created = false
+	}
+
+	if opts.Output == OutputJSON {
+		return writeJSON(f.IOStreams.Out, result)
+	}
+	if result.Created {
+		fmt.Fprintf(f.IOStreams.Out, "Created bucket %s\n", bucketName)
+	} else {
+		fmt.Fprintf(f.IOStreams.Out, "Bucket %s already exists\n", bucketName)
+	}
+	return nil
+}
+
+func runRemoveBucket(ctx context.Context, f *cmdutil.Factory, opts *RemoveBucketOptions, args []string) error {
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+
+	bucketName := opts.Config.BucketName
+	result := bucketResult{Bucket: bucketName, Removed: true}
+	if opts.Force {
+		objects, err := listAllObjects(ctx, client, bucketName, "")
+		if err != nil {
+			return err
+		}
+		keys := make([]string, len(objects))
+		for i, object := range objects {
+			keys[i] = object.Key
+		}
+		if len(keys) > 0 && !opts.Yes {
+			confirmed, err := confirmRemove(f.IOStreams, bucketName, keys)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return errors.New("deletion cancelled")
+			}
+		}
+		if err := removeObjects(ctx, client, bucketName, keys); err != nil {
+			return err
+		}
+		result.ObjectsRemoved = len(keys)
+	}
+	if err := client.RemoveBucket(ctx, bucketName); err != nil {
+		if minio.ToErrorResponse(err).Code == "BucketNotEmpty" {
+			return fmt.Errorf("failed to remove bucket %s: bucket is not empty (use --force to delete its objects)", bucketName)
+		}
+		return fmt.Errorf("failed to remove bucket %s: %w", bucketName, err)
+	}
+
+	if opts.Output == OutputJSON {
+		return writeJSON(f.IOStreams.Out, result)
+	}
+	if opts.Force {
+		fmt.Fprintf(f.IOStreams.Out, "Removed bucket %s and %d objects\n", bucketName, result.ObjectsRemoved)
+	} else {
+		fmt.Fprintf(f.IOStreams.Out, "Removed bucket %s\n", bucketName)
+	}
+	return nil
+}
+
+// newBucketClient validates the configuration of a bucket command, whose
+// optional argument overrides the configured bucket, and creates a client
+// without requiring the bucket to exist
+func newBucketClient(cfg *MinIOConfig, output string, args []string) (*minio.Client, error) {
+	if len(args) > 0 {
+		cfg.BucketName = args[0]
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration error: %w", err)
+	}
+	switch output {
+	case OutputText, OutputJSON:
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", output)
+	}
+	return newS3Client(cfg)
+}
+
+// listAllObjects lists every object below prefix
+func listAllObjects(ctx context.Context, client *minio.Client, bucketName, prefix string) ([]minio.ObjectInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects []minio.ObjectInfo
+	for object := range client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", object.Err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// removeObjects deletes the keys with multi-object delete requests
+func removeObjects(ctx context.Context, client *minio.Client, bucketName string, keys []string) error {
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+		for _, key := range keys {
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: key}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var err error
+	for removeErr := range client.RemoveObjects(ctx, bucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		// Drain the channel so the sending goroutine finishes
+		if err == nil {
+			err = fmt.Errorf("failed to delete object %s: %w", removeErr.ObjectName, removeErr.Err)
+		}
+	}
+	return err
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(out io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
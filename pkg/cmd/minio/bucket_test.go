@@ -0,0 +1,251 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/gogodjzhu/gogobox/pkg/s3fake"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+// copyConfig returns a copy of cfg, bucket commands change its bucket name
+func copyConfig(cfg *MinIOConfig) *MinIOConfig {
+	c := *cfg
+	return &c
+}
+
+func TestRunMakeAndRemoveBucket(t *testing.T) {
+	fake, cfg := newFakeS3(t, map[string][]byte{"a.txt": []byte("a"), "sub/b.txt": []byte("b")})
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+
+	if err := runMakeBucket(context.Background(), f, &MakeBucketOptions{Config: copyConfig(cfg), Output: OutputJSON}, []string{"new-bucket"}); err != nil {
+		t.Fatalf("runMakeBucket() unexpected error: %v", err)
+	}
+	var result bucketResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil || result.Bucket != "new-bucket" || !result.Created {
+		t.Errorf("runMakeBucket() printed %s (%v), want created new-bucket", out.String(), err)
+	}
+	if !fake.BucketExists("new-bucket") {
+		t.Fatalf("bucket was not created")
+	}
+
+	err := runMakeBucket(context.Background(), f, &MakeBucketOptions{Config: copyConfig(cfg), Output: OutputText}, []string{"new-bucket"})
+	if err == nil || !strings.Contains(err.Error(), "failed to create bucket new-bucket") {
+		t.Errorf("runMakeBucket() of existing bucket error = %v, want already exists error", err)
+	}
+	out.Reset()
+	if err := runMakeBucket(context.Background(), f, &MakeBucketOptions{Config: copyConfig(cfg), IgnoreExisting: true, Output: OutputText}, []string{"new-bucket"}); err != nil {
+		t.Fatalf("runMakeBucket() with --ignore-existing unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "already exists") {
+		t.Errorf("runMakeBucket() printed %q, want already exists note", out.String())
+	}
+
+	// The configured bucket holds objects
+	err = runRemoveBucket(context.Background(), f, &RemoveBucketOptions{Config: copyConfig(cfg), Output: OutputText}, nil)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("runRemoveBucket() of non-empty bucket error = %v, want --force hint", err)
+	}
+	// Deleting the objects needs confirmation, which is impossible without a
+	// terminal
+	err = runRemoveBucket(context.Background(), f, &RemoveBucketOptions{Config: copyConfig(cfg), Force: true, Output: OutputText}, nil)
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("runRemoveBucket() --force without --yes error = %v, want --yes hint", err)
+	}
+	if len(fake.keys()) != 2 {
+		t.Errorf("objects were deleted without confirmation: %v", fake.keys())
+	}
+	out.Reset()
+	if err := runRemoveBucket(context.Background(), f, &RemoveBucketOptions{Config: copyConfig(cfg), Force: true, Yes: true, Output: OutputJSON}, nil); err != nil {
+		t.Fatalf("runRemoveBucket() --force unexpected error: %v", err)
+	}
+	result = bucketResult{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil || !result.Removed || result.ObjectsRemoved != 2 {
+		t.Errorf("runRemoveBucket() printed %s (%v), want 2 objects removed", out.String(), err)
+	}
+	if fake.BucketExists(cfg.BucketName) || len(fake.keys()) != 0 {
+		t.Errorf("bucket %s still exists with %v", cfg.BucketName, fake.keys())
+	}
+
+	if err := runRemoveBucket(context.Background(), f, &RemoveBucketOptions{Config: copyConfig(cfg), Output: OutputText}, []string{"new-bucket"}); err != nil {
+		t.Fatalf("runRemoveBucket() unexpected error: %v", err)
+	}
+	if fake.BucketExists("new-bucket") {
+		t.Errorf("bucket new-bucket was not removed")
+	}
+}
+
+func TestRunPolicy(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+
+	get := func() string {
+		out.Reset()
+		if err := runPolicyGet(context.Background(), f, &PolicyOptions{Config: copyConfig(cfg), Output: OutputText}, nil); err != nil {
+			t.Fatalf("runPolicyGet() unexpected error: %v", err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+
+	if got := get(); got != "test-bucket: private" {
+		t.Errorf("policy of new bucket = %q, want private", got)
+	}
+
+	for _, level := range []string{PolicyDownload, PolicyPublic, PolicyUpload} {
+		if err := runPolicySet(context.Background(), f, &PolicyOptions{Config: copyConfig(cfg), Output: OutputText}, level, nil); err != nil {
+			t.Fatalf("runPolicySet(%s) unexpected error: %v", level, err)
+		}
+		if got := get(); got != "test-bucket: "+level {
+			t.Errorf("policy after set %s = %q", level, got)
+		}
+	}
+
+	// A policy for a prefix leaves the rest of the bucket private
+	if err := runPolicySet(context.Background(), f, &PolicyOptions{Config: copyConfig(cfg), Output: OutputText}, PolicyPrivate, nil); err != nil {
+		t.Fatalf("runPolicySet(private) unexpected error: %v", err)
+	}
+	if _, ok := fake.BucketConfig(cfg.BucketName, s3fake.ConfigPolicy); ok {
+		t.Errorf("private policy was stored instead of removing the bucket policy")
+	}
+	if err := runPolicySet(context.Background(), f, &PolicyOptions{Config: copyConfig(cfg), Prefix: "public/", Output: OutputText}, PolicyDownload, nil); err != nil {
+		t.Fatalf("runPolicySet(download, public/) unexpected error: %v", err)
+	}
+	out.Reset()
+	if err := runPolicyGet(context.Background(), f, &PolicyOptions{Config: copyConfig(cfg), Prefix: "public/", Output: OutputJSON}, nil); err != nil {
+		t.Fatalf("runPolicyGet() unexpected error: %v", err)
+	}
+	var result policyResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil || result.Policy != PolicyDownload || len(result.Document) == 0 {
+		t.Errorf("runPolicyGet() printed %s (%v), want download with document", out.String(), err)
+	}
+	if got := get(); got != "test-bucket: custom" {
+		t.Errorf("policy of bucket root = %q, want custom", got)
+	}
+
+	if err := runPolicySet(context.Background(), f, &PolicyOptions{Config: copyConfig(cfg), Output: OutputText}, "everyone", nil); err == nil {
+		t.Errorf("runPolicySet() accepted an unknown policy")
+	}
+}
+
+func TestRunLifecycle(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+
+	list := func() []lifecycleRule {
+		out.Reset()
+		if err := runLifecycleList(context.Background(), f, &LifecycleOptions{Config: copyConfig(cfg), Output: OutputJSON}, nil); err != nil {
+			t.Fatalf("runLifecycleList() unexpected error: %v", err)
+		}
+		var rules []lifecycleRule
+		if err := json.Unmarshal(out.Bytes(), &rules); err != nil {
+			t.Fatalf("runLifecycleList() printed invalid JSON %s: %v", out.String(), err)
+		}
+		return rules
+	}
+
+	if rules := list(); len(rules) != 0 {
+		t.Errorf("rules of new bucket = %v, want none", rules)
+	}
+
+	// A rule with settings the command does not know about is kept unchanged
+	transition := `<Rule><ID>archive</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>`
+	fake.SetBucketConfig(cfg.BucketName, s3fake.ConfigLifecycle, `<LifecycleConfiguration>`+transition+`</LifecycleConfiguration>`)
+
+	add := &LifecycleOptions{Config: copyConfig(cfg), ID: "tmp", Prefix: "tmp/", Days: 7, Output: OutputText}
+	if err := runLifecycleAdd(context.Background(), f, add, nil); err != nil {
+		t.Fatalf("runLifecycleAdd() unexpected error: %v", err)
+	}
+	add = &LifecycleOptions{Config: copyConfig(cfg), Prefix: "old/", NoncurrentDays: 30, Output: OutputText}
+	if err := runLifecycleAdd(context.Background(), f, add, nil); err != nil {
+		t.Fatalf("runLifecycleAdd() unexpected error: %v", err)
+	}
+	// Same ID replaces the rule
+	add = &LifecycleOptions{Config: copyConfig(cfg), ID: "tmp", Prefix: "tmp/", Days: 3, Output: OutputText}
+	if err := runLifecycleAdd(context.Background(), f, add, nil); err != nil {
+		t.Fatalf("runLifecycleAdd() unexpected error: %v", err)
+	}
+
+	rules := list()
+	if len(rules) != 3 {
+		t.Fatalf("rules = %+v, want 3", rules)
+	}
+	if rules[0].ID != "archive" || rules[0].Prefix != "logs/" {
+		t.Errorf("rules[0] = %+v, want the archive rule", rules[0])
+	}
+	if rules[1].ID != "tmp" || rules[1].Days != 3 {
+		t.Errorf("rules[1] = %+v, want tmp expiring after 3 days", rules[1])
+	}
+	if rules[2].ID == "" || rules[2].Prefix != "old/" || rules[2].NoncurrentDays != 30 {
+		t.Errorf("rules[2] = %+v, want generated ID for old/", rules[2])
+	}
+	document, _ := fake.BucketConfig(cfg.BucketName, s3fake.ConfigLifecycle)
+	var stored lifecycle.Configuration
+	if err := xml.Unmarshal([]byte(document), &stored); err != nil {
+		t.Fatalf("stored lifecycle is invalid: %v", err)
+	}
+	if archive := stored.Rules[0].Transition; archive.Days != 30 || archive.StorageClass != "GLACIER" {
+		t.Errorf("lifecycle lost unknown settings: %s", document)
+	}
+
+	for _, id := range []string{"archive", "tmp", rules[2].ID} {
+		if err := runLifecycleRemove(context.Background(), f, &LifecycleOptions{Config: copyConfig(cfg), ID: id, Output: OutputText}, nil); err != nil {
+			t.Fatalf("runLifecycleRemove(%s) unexpected error: %v", id, err)
+		}
+	}
+	if _, ok := fake.BucketConfig(cfg.BucketName, s3fake.ConfigLifecycle); ok {
+		t.Errorf("removing the last rule did not delete the lifecycle configuration")
+	}
+
+	if err := runLifecycleRemove(context.Background(), f, &LifecycleOptions{Config: copyConfig(cfg), ID: "missing", Output: OutputText}, nil); err == nil {
+		t.Errorf("runLifecycleRemove() of missing rule succeeded")
+	}
+	if err := runLifecycleAdd(context.Background(), f, &LifecycleOptions{Config: copyConfig(cfg), Output: OutputText}, nil); err == nil || !strings.Contains(err.Error(), "--days") {
+		t.Errorf("runLifecycleAdd() without days error = %v, want --days error", err)
+	}
+}
+
+func TestRunVersioning(t *testing.T) {
+	_, cfg := newFakeS3(t, nil)
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"", VersioningUnversioned},
+		{VersioningEnabled, VersioningEnabled},
+		{VersioningSuspended, VersioningSuspended},
+		{"", VersioningSuspended},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		if err := runVersioning(context.Background(), f, &VersioningOptions{Config: copyConfig(cfg), Output: OutputJSON}, tt.status, nil); err != nil {
+			t.Fatalf("runVersioning(%q) unexpected error: %v", tt.status, err)
+		}
+		var result versioningResult
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil || result.Status != tt.want {
+			t.Errorf("runVersioning(%q) printed %s (%v), want status %s", tt.status, out.String(), err, tt.want)
+		}
+	}
+}
+
+func TestBucketCommandErrors(t *testing.T) {
+	_, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+
+	if err := runVersioning(context.Background(), f, &VersioningOptions{Config: copyConfig(cfg), Output: OutputJSON}, "", []string{"missing-bucket"}); err == nil {
+		t.Errorf("runVersioning() of missing bucket succeeded")
+	}
+	if err := runMakeBucket(context.Background(), f, &MakeBucketOptions{Config: copyConfig(cfg), Output: "yaml"}, nil); err == nil || !strings.Contains(err.Error(), "unsupported output format") {
+		t.Errorf("runMakeBucket() error = %v, want output format error", err)
+	}
+}
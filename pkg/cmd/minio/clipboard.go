@@ -0,0 +1,120 @@
+package minio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+)
+
+// ClipboardSource is the source of clipboard uploads in the output
+const ClipboardSource = "clipboard"
+
+// clipboardImageCommands read a PNG image from the clipboard, tried in order
+// per operating system. Commands with a "{file}" argument write the image to
+// that file, the others to stdout.
+var clipboardImageCommands = map[string][][]string{
+	"linux": {
+		{"wl-paste", "--no-newline", "--type", "image/png"},
+		{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"},
+	},
+	"darwin": {
+		{"pngpaste", "-"},
+		{"osascript", "-e", `write (the clipboard as «class PNGf») to (open for access (POSIX file "{file}") with write permission)`},
+	},
+	"windows": {
+		{"powershell", "-NoProfile", "-Command",
+			`Add-Type -AssemblyName System.Windows.Forms; $img = [System.Windows.Forms.Clipboard]::GetImage(); if ($img) { $img.Save('{file}', [System.Drawing.Imaging.ImageFormat]::Png) }`},
+	},
+}
+
+// clipboardSource writes the image in the clipboard to a temporary file.
+// Text is never uploaded, it may be a password that was just copied. name is
+// the path keys are derived from, "clipboard.png" if empty.
+func clipboardSource(name string) (uploadSource, error) {
+	data, err := readClipboardImage(clipboardImageCommands[runtime.GOOS])
+	if err != nil {
+		return uploadSource{}, fmt.Errorf("no image in the clipboard (%w)", err)
+	}
+
+	file, err := os.CreateTemp("", "gogobox-clipboard-*.png")
+	if err != nil {
+		return uploadSource{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		os.Remove(file.Name())
+		return uploadSource{}, fmt.Errorf("failed to write clipboard to %s: %w", file.Name(), err)
+	}
+
+	if name == "" {
+		name = "clipboard.png"
+	}
+	return uploadSource{Path: file.Name(), RelPath: name}, nil
+}
+
+// readClipboardImage runs the commands until one returns an image
+func readClipboardImage(commands [][]string) ([]byte, error) {
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("reading images from the clipboard is not supported on %s", runtime.GOOS)
+	}
+
+	var errs []string
+	for _, command := range commands {
+		data, err := runClipboardCommand(command)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", command[0], err))
+			continue
+		}
+		if !util.IsImageContentType(util.DetectContentTypeOf("", data)) {
+			errs = append(errs, fmt.Sprintf("%s: not an image", command[0]))
+			continue
+		}
+		return data, nil
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+func runClipboardCommand(command []string) ([]byte, error) {
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var target string
+	args := make([]string, len(command)-1)
+	for i, arg := range command[1:] {
+		if strings.Contains(arg, "{file}") {
+			if target == "" {
+				file, err := os.CreateTemp("", "gogobox-clipboard-*.png")
+				if err != nil {
+					return nil, err
+				}
+				file.Close()
+				target = file.Name()
+				defer os.Remove(target)
+			}
+			arg = strings.ReplaceAll(arg, "{file}", target)
+		}
+		args[i] = arg
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	if target != "" {
+		return os.ReadFile(target)
+	}
+	return stdout.Bytes(), nil
+}
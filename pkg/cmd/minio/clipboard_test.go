@@ -0,0 +1,93 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestRunUploadFromClipboard(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake clipboard commands need sh")
+	}
+	png := "\\211PNG\\r\\n\\032\\n"
+
+	tests := []struct {
+		name     string
+		commands [][]string
+		opts     UploadOptions
+		wantKey  string
+		wantData string
+		wantOut  string
+		wantErr  string
+	}{
+		{
+			name:     "image to stdout",
+			commands: [][]string{{"sh", "-c", "printf '" + png + "'"}},
+			opts:     UploadOptions{KeyTemplate: "{basename}.{ext}"},
+			wantKey:  "clipboard.png",
+			wantData: "\x89PNG\r\n\x1a\n",
+		},
+		{
+			name: "image to file",
+			commands: [][]string{
+				{"gogobox-no-such-command"},
+				{"sh", "-c", "printf '" + png + "' > '{file}'"},
+			},
+			opts:     UploadOptions{KeyTemplate: "{basename}.{ext}", Name: "shot.png", PrintURLs: true, Format: "markdown"},
+			wantKey:  "shot.png",
+			wantData: "\x89PNG\r\n\x1a\n",
+			wantOut:  "![shot.png](",
+		},
+		{
+			// Copied text may be a secret, it is never uploaded
+			name:     "text",
+			commands: [][]string{{"sh", "-c", "echo copied password"}},
+			opts:     UploadOptions{KeyTemplate: "{basename}.{ext}"},
+			wantErr:  "no image in the clipboard",
+		},
+		{
+			name:     "unreadable",
+			commands: [][]string{{"sh", "-c", "echo no clipboard >&2; exit 1"}},
+			opts:     UploadOptions{KeyTemplate: "{basename}.{ext}"},
+			wantErr:  "no clipboard",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := clipboardImageCommands[runtime.GOOS]
+			t.Cleanup(func() {
+				clipboardImageCommands[runtime.GOOS] = commands
+			})
+			clipboardImageCommands[runtime.GOOS] = tt.commands
+
+			fake, cfg := newFakeS3(t, nil)
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+			opts := tt.opts
+			opts.Config = cfg
+			opts.FromClipboard = true
+
+			err := runUpload(context.Background(), f, &opts, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runUpload() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runUpload() unexpected error: %v", err)
+			}
+			if got := string(fake.object(tt.wantKey)); got != tt.wantData {
+				t.Errorf("object %s = %q, want %q", tt.wantKey, got, tt.wantData)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+)
+
+type CopyOptions struct {
+	Config    *MinIOConfig
+	ToBucket  string
+	Recursive bool
+	Move      bool
+}
+
+// copyPair is a server-side copy of one object
+type copyPair struct {
+	source, target string
+	size           int64
+}
+
+func NewCmdMinIOCopy(f *cmdutil.Factory) *cobra.Command {
+	return newCmdCopy(f, false)
+}
+
+func NewCmdMinIOMove(f *cmdutil.Factory) *cobra.Command {
+	return newCmdCopy(f, true)
+}
+
+func newCmdCopy(f *cmdutil.Factory, move bool) *cobra.Command {
+	opts := &CopyOptions{
+		Config: NewDefaultConfig(),
+		Move:   move,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "cp [flags] <source> <target>",
+		Short: "Copy objects on the server",
+		Long: `Copy objects on the server, without downloading them.
+
+The target is an object key, or a "directory" the source is copied into if it
+ends with "/". With --recursive every object below the source prefix is
+copied below the target prefix. --to-bucket copies into another bucket.`,
+		Example: `  # Copy an object to a new key
+  gogobox minio cp 202401/report.pdf archive/report-2024.pdf
+
+  # Copy everything below photos/ into another bucket
+  gogobox minio cp -r --to-bucket backup photos/ photos/`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runCopy(cmd.Context(), f, opts, args[0], args[1])
+		},
+	}
+	if move {
+		cmd.Use = "mv [flags] <source> <target>"
+		cmd.Short = "Move objects on the server"
+		cmd.Long = `Move objects on the server: copy them to the target and delete the source.
+
+The target is an object key, or a "directory" the source is moved into if it
+ends with "/". With --recursive every object below the source prefix is
+moved below the target prefix. --to-bucket moves into another bucket.`
+		cmd.Example = `  # Rename an object
+  gogobox minio mv draft.md published/post.md
+
+  # Move a prefix to another bucket
+  gogobox minio mv -r --to-bucket archive 2023/ 2023/`
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().StringVar(&opts.ToBucket, "to-bucket", "", "Bucket to copy into (default the source bucket)")
+	cmd.Flags().BoolVarP(&opts.Recursive, "recursive", "r", false, "Copy every object below the source prefix")
+
+	return cmd
+}
+
+func runCopy(ctx context.Context, f *cmdutil.Factory, opts *CopyOptions, source, target string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	minioClient, err := newClient(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+	sourceBucket, targetBucket := opts.Config.BucketName, opts.ToBucket
+	if targetBucket == "" {
+		targetBucket = sourceBucket
+	} else if targetBucket != sourceBucket {
+		exists, err := minioClient.BucketExists(ctx, targetBucket)
+		if err != nil {
+			return fmt.Errorf("failed to check bucket existence: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("bucket '%s' does not exist", targetBucket)
+		}
+	}
+
+	pairs, err := planCopy(ctx, minioClient, sourceBucket, source, target, opts.Recursive)
+	if err != nil {
+		return err
+	}
+
+	verb := "copy"
+	if opts.Move {
+		verb = "move"
+	}
+	for _, pair := range pairs {
+		if sourceBucket == targetBucket && pair.source == pair.target {
+			return fmt.Errorf("cannot %s %s onto itself", verb, pair.source)
+		}
+	}
+
+	for _, pair := range pairs {
+		if err := copyObject(ctx, minioClient, sourceBucket, pair, targetBucket); err != nil {
+			return err
+		}
+		if opts.Move {
+			if err := minioClient.RemoveObject(ctx, sourceBucket, pair.source, minio.RemoveObjectOptions{}); err != nil {
+				return fmt.Errorf("failed to delete %s after copying it: %w", pair.source, err)
+			}
+		}
+		fmt.Fprintf(f.IOStreams.Out, "%s %s/%s -> %s/%s\n", verb, sourceBucket, pair.source, targetBucket, pair.target)
+	}
+	return nil
+}
+
+// planCopy maps the source objects to their target keys
+func planCopy(ctx context.Context, client *minio.Client, bucketName, source, target string, recursive bool) ([]copyPair, error) {
+	if !recursive {
+		info, err := client.StatObject(ctx, bucketName, source, minio.StatObjectOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat object %s: %w", source, err)
+		}
+		if target == "" || strings.HasSuffix(target, "/") {
+			target += path.Base(source)
+		}
+		return []copyPair{{source: source, target: target, size: info.Size}}, nil
+	}
+
+	// Prefixes are treated as directories, "a" must not match "ab/c"
+	if source != "" && !strings.HasSuffix(source, "/") {
+		source += "/"
+	}
+	if target != "" && !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	objects, err := listAllObjects(ctx, client, bucketName, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no objects below %s", source)
+	}
+
+	pairs := make([]copyPair, len(objects))
+	for i, object := range objects {
+		pairs[i] = copyPair{source: object.Key, target: target + strings.TrimPrefix(object.Key, source), size: object.Size}
+	}
+	return pairs, nil
+}
+
+// copyObject copies an object on the server. Objects larger than 5GiB cannot
+// be copied in one request and are copied in parts.
+func copyObject(ctx context.Context, client *minio.Client, sourceBucket string, pair copyPair, targetBucket string) error {
+	src := minio.CopySrcOptions{Bucket: sourceBucket, Object: pair.source}
+	dst := minio.CopyDestOptions{Bucket: targetBucket, Object: pair.target}
+
+	var err error
+	if pair.size > maxPartSize {
+		_, err = client.ComposeObject(ctx, dst, src)
+	} else {
+		_, err = client.CopyObject(ctx, dst, src)
+	}
+	if err != nil {
+		var resp minio.ErrorResponse
+		if errors.As(err, &resp) && resp.Code == "NoSuchKey" {
+			return fmt.Errorf("failed to copy %s: object does not exist", pair.source)
+		}
+		return fmt.Errorf("failed to copy %s to %s: %w", pair.source, pair.target, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package minio
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// iamTimeout bounds the requests to the instance metadata endpoints, which
+// do not answer outside of cloud instances
+const iamTimeout = 5 * time.Second
+
+// newCredentialChain returns the credentials of configurations without
+// access key, taken from the first provider that has some:
+//
+//   - AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//   - MINIO_ROOT_USER and MINIO_ROOT_PASSWORD
+//   - the AWS shared credentials file (AWS_SHARED_CREDENTIALS_FILE, AWS_PROFILE)
+//   - the mc config file (~/.mc/config.json, MINIO_ALIAS)
+//   - IAM: web identity tokens (AWS_WEB_IDENTITY_TOKEN_FILE), ECS task roles
+//     and EC2 instance profiles
+//
+// Requests are anonymous if no provider has credentials. The providers are
+// only asked once then, so anonymous requests outside of cloud instances do
+// not each wait for the metadata endpoints.
+func newCredentialChain(signer credentials.SignatureType) *credentials.Credentials {
+	providers := []credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+		&credentials.FileAWSCredentials{},
+		&credentials.FileMinioClient{},
+		&credentials.IAM{Client: &http.Client{Timeout: iamTimeout}},
+	}
+	for i, provider := range providers {
+		providers[i] = signerProvider{Provider: provider, signer: signer}
+	}
+	return credentials.New(&anonymousOnceChain{Chain: credentials.Chain{Providers: providers}})
+}
+
+// anonymousOnceChain keeps the anonymous credentials of a chain whose
+// providers had none, minio-go's chain asks all of them again on every
+// request
+type anonymousOnceChain struct {
+	credentials.Chain
+	anonymous bool
+}
+
+func (c *anonymousOnceChain) Retrieve() (credentials.Value, error) {
+	value, err := c.Chain.Retrieve()
+	c.anonymous = err == nil && value.SignerType.IsAnonymous()
+	return value, err
+}
+
+func (c *anonymousOnceChain) IsExpired() bool {
+	return !c.anonymous && c.Chain.IsExpired()
+}
+
+// signerProvider signs the credentials of its provider with the configured
+// signature version, the providers of the chain always sign with v4
+type signerProvider struct {
+	credentials.Provider
+	signer credentials.SignatureType
+}
+
+func (p signerProvider) Retrieve() (credentials.Value, error) {
+	value, err := p.Provider.Retrieve()
+	if err == nil && !value.SignerType.IsAnonymous() {
+		value.SignerType = p.signer
+	}
+	return value, err
+}
@@ -0,0 +1,41 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// DedupeKeyTemplate names objects by content so that identical files share
+// one object
+const DedupeKeyTemplate = "{sha256}.{ext}"
+
+// pendingDedupeUploads returns the indexes of the files that still need to be
+// uploaded when objects are named by content: the first file of every key,
+// unless an object of the same size already exists under it.
+func pendingDedupeUploads(ctx context.Context, store ObjectStore, filenames, objectNames []string) ([]int, error) {
+	seen := map[string]bool{}
+	var pending []int
+	for i, objectName := range objectNames {
+		if seen[objectName] {
+			continue
+		}
+		seen[objectName] = true
+
+		stat, err := os.Stat(filenames[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file stats for %s: %w", filenames[i], err)
+		}
+		info, err := store.Stat(ctx, objectName, minio.StatObjectOptions{})
+		if err == nil && info.Size == stat.Size() {
+			continue
+		}
+		if err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("failed to check object %s: %w", objectName, err)
+		}
+		pending = append(pending, i)
+	}
+	return pending, nil
+}
@@ -0,0 +1,70 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestPendingDedupeUploads(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte("content of "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	_, cfg := newFakeS3(t, map[string][]byte{
+		"present": []byte("content of b.txt"),
+		"partial": []byte("content"),
+	})
+
+	// c duplicates a within the run, d's object has the wrong size
+	objectNames := []string{"new", "present", "new", "partial"}
+	pending, err := pendingDedupeUploads(context.Background(), newTestStore(t, cfg), files, objectNames)
+	if err != nil {
+		t.Fatalf("pendingDedupeUploads() unexpected error: %v", err)
+	}
+	if want := []int{0, 3}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("pendingDedupeUploads() = %v, want %v", pending, want)
+	}
+}
+
+func TestRunUploadDedupe(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	dir := t.TempDir()
+	data := []byte("screenshot")
+	var files []string
+	for _, name := range []string{"one.png", "two.png"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	sum := sha256.Sum256(data)
+	want := []string{"shots/" + hex.EncodeToString(sum[:]) + ".png"}
+
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{Config: cfg, Dedupe: true, Prefix: "shots", Concurrency: 2}
+	for run := 1; run <= 2; run++ {
+		if err := runUpload(context.Background(), f, opts, files); err != nil {
+			t.Fatalf("runUpload() run %d unexpected error: %v", run, err)
+		}
+		if keys := fake.keys(); !reflect.DeepEqual(keys, want) {
+			t.Errorf("run %d: uploaded keys = %v, want %v", run, keys, want)
+		}
+		if fake.Stats().Puts != 1 {
+			t.Errorf("run %d: %d PUT requests, want 1 in total", run, fake.Stats().Puts)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package minio
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gogodjzhu/gogobox/internal/envelope"
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+type DownloadOptions struct {
+	Config    *MinIOConfig
+	OutputDir string
+	Parallel  int
+	Force     bool
+
+	// Decryption with a key from KeyFile or the KeySecret secret
+	SSEC      bool
+	KeyFile   string
+	KeySecret string
+
+	// encryption is loaded by runDownload, nil without a key
+	encryption *objectEncryption
+}
+
+func NewCmdMinIODownload(f *cmdutil.Factory) *cobra.Command {
+	opts := &DownloadOptions{
+		Config:    NewDefaultConfig(),
+		OutputDir: ".",
+		Parallel:  4,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "download [flags] <object1> [object2] ...",
+		Short: "Download objects from MinIO",
+		Long: `Download objects from a MinIO server to the local filesystem.
+
+Arguments ending with "/" are treated as prefixes and every object below them
+is downloaded, keeping the key layout relative to the prefix's parent.
+
+The command will:
+- Download objects concurrently with a pool of workers
+- Resume partially downloaded files using range requests
+- Verify the size (and MD5 ETag when available) of every downloaded file
+- Skip files that already exist locally with matching content
+
+Objects uploaded with --sse-c are downloaded with --sse-c and the same key.
+Objects encrypted on the client (upload --encrypt) are recognized by their
+metadata and decrypted with the key given by --key-file or --key-secret.`,
+		Example: `  # Download a single object to the current directory
+  gogobox minio download -e localhost:9000 -a mykey -s mysecret -b mybucket 202401/image.jpg
+
+  # Download a whole prefix into ./backup with 8 workers
+  gogobox minio download -e localhost:9000 -a mykey -s mysecret -b mybucket -o backup -p 8 202401/
+
+  # Download and decrypt objects encrypted on the client
+  gogobox minio download --key-secret artifacts-key releases/`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runDownload(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	// Download options flags
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", ".", "Directory to save downloaded files to")
+	cmd.Flags().IntVarP(&opts.Parallel, "parallel", "p", 4, "Number of concurrent downloads")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Download even if an up-to-date local file exists")
+	cmd.Flags().BoolVar(&opts.SSEC, "sse-c", false, "Objects are encrypted on the server with the key (SSE-C)")
+	cmd.Flags().StringVar(&opts.KeyFile, "key-file", "", "File with the 32 byte encryption key (raw, hex or base64)")
+	cmd.Flags().StringVar(&opts.KeySecret, "key-secret", "", "Name of the secret in the secrets store holding the encryption key")
+
+	return cmd
+}
+
+// downloadTask describes one object to download and where to store it
+type downloadTask struct {
+	ObjectName string
+	LocalPath  string
+}
+
+// downloadResult is the outcome of a single downloadTask
+type downloadResult struct {
+	Task    downloadTask
+	Skipped bool
+	Err     error
+}
+
+func runDownload(ctx context.Context, f *cmdutil.Factory, opts *DownloadOptions, args []string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	encryption, err := loadObjectEncryption(f.IOStreams, opts.KeyFile, opts.KeySecret, opts.SSEC, false)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	opts.encryption = encryption
+	if encryption != nil {
+		if err := requireS3Backend(opts.Config, "decryption"); err != nil {
+			return fmt.Errorf("configuration error: %w", err)
+		}
+	}
+
+	store, err := newStore(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+
+	tasks, rejected, err := collectDownloadTasks(ctx, store, opts.OutputDir, args)
+	if err != nil {
+		return err
+	}
+
+	results := append(rejected, downloadObjects(ctx, store, opts, tasks)...)
+
+	// Display results
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(f.IOStreams.Out, "Failed %s: %v\n", result.Task.ObjectName, result.Err)
+		case result.Skipped:
+			fmt.Fprintf(f.IOStreams.Out, "Skipped %s (up to date)\n", result.Task.ObjectName)
+		default:
+			fmt.Fprintf(f.IOStreams.Out, "Downloaded %s -> %s\n", result.Task.ObjectName, result.Task.LocalPath)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d downloads failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// collectDownloadTasks expands the arguments into download tasks, listing
+// every object below arguments that end with "/". Objects whose keys would be
+// stored outside of outputDir are returned as failed results.
+func collectDownloadTasks(ctx context.Context, store ObjectStore, outputDir string, args []string) ([]downloadTask, []downloadResult, error) {
+	var tasks []downloadTask
+	var rejected []downloadResult
+
+	add := func(objectName, rel string) {
+		task := downloadTask{ObjectName: objectName}
+		localPath, err := localObjectPath(outputDir, rel)
+		if err != nil {
+			rejected = append(rejected, downloadResult{Task: task, Err: err})
+			return
+		}
+		task.LocalPath = localPath
+		tasks = append(tasks, task)
+	}
+
+	for _, arg := range args {
+		if !strings.HasSuffix(arg, "/") {
+			add(arg, path.Base(arg))
+			continue
+		}
+
+		objects, err := store.List(ctx, arg, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list objects under %s: %w", arg, err)
+		}
+		for _, object := range objects {
+			// Skip directory markers
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			add(object.Key, relativeObjectPath(arg, object.Key))
+		}
+	}
+
+	return tasks, rejected, nil
+}
+
+// localObjectPath returns the file below dir for the slash separated path
+// rel, paths that would leave dir such as "../x" are rejected
+func localObjectPath(dir, rel string) (string, error) {
+	local := filepath.FromSlash(rel)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("object key would be stored outside of %s", dir)
+	}
+	return filepath.Join(dir, local), nil
+}
+
+// relativeObjectPath returns the key relative to the parent of prefix, so that
+// downloading "a/b/" stores "a/b/c.png" as "b/c.png"
+func relativeObjectPath(prefix, objectName string) string {
+	parent := path.Dir(strings.TrimSuffix(prefix, "/"))
+	if parent == "." || parent == "/" {
+		return objectName
+	}
+	return strings.TrimPrefix(objectName, parent+"/")
+}
+
+// downloadObjects runs the tasks on a pool of opts.Parallel workers. Results
+// are returned in the same order as tasks.
+func downloadObjects(ctx context.Context, store ObjectStore, opts *DownloadOptions, tasks []downloadTask) []downloadResult {
+	results := make([]downloadResult, len(tasks))
+	taskCh := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < util.MinInt(util.MaxInt(opts.Parallel, 1), len(tasks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range taskCh {
+				// Nothing is started after an interrupt
+				if err := ctx.Err(); err != nil {
+					results[idx] = downloadResult{Task: tasks[idx], Err: err}
+					continue
+				}
+				skipped, err := downloadObject(ctx, store, tasks[idx], opts.Force, opts.encryption)
+				results[idx] = downloadResult{Task: tasks[idx], Skipped: skipped, Err: err}
+			}
+		}()
+	}
+
+	for i := range tasks {
+		taskCh <- i
+	}
+	close(taskCh)
+	wg.Wait()
+
+	return results
+}
+
+// downloadObject downloads a single object. Data is written to a ".part" file
+// named after the object's ETag, so an interrupted download of the same object
+// version is resumed with a range request instead of starting over. Client-side
+// encrypted objects are decrypted once their ciphertext is complete.
+func downloadObject(ctx context.Context, store ObjectStore, task downloadTask, force bool, enc *objectEncryption) (bool, error) {
+	info, err := store.Stat(ctx, task.ObjectName, enc.statOptions())
+	if err != nil {
+		return false, fmt.Errorf("failed to stat object: %w", err)
+	}
+	encrypted := isClientEncrypted(info.Metadata)
+	if encrypted && enc == nil {
+		return false, errors.New("the object is encrypted, pass --key-file or --key-secret")
+	}
+	// The ETags of SSE-C objects are not the MD5 of their content
+	etag := info.ETag
+	if enc != nil && enc.sse != nil {
+		etag = ""
+	}
+
+	// Skip files that are already complete
+	if !force {
+		localSize, localETag := info.Size, etag
+		if encrypted {
+			localSize, localETag = envelope.DecryptedSize(info.Size), ""
+		}
+		if err := verifyDownload(task.LocalPath, localSize, localETag); err == nil {
+			return true, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(task.LocalPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	partPath := task.LocalPath + "." + info.ETag + ".part"
+	if info.ETag == "" {
+		// Partial files of objects without ETag may belong to another
+		// version, start over
+		partPath = task.LocalPath + ".part"
+		os.Remove(partPath)
+	}
+	removeStaleParts(task.LocalPath, partPath)
+	var offset int64
+	if stat, err := os.Stat(partPath); err == nil {
+		offset = stat.Size()
+		if offset > info.Size {
+			// The partial file cannot belong to this object, start over
+			os.Remove(partPath)
+			offset = 0
+		}
+	}
+
+	if offset < info.Size {
+		if err := fetchObjectRange(ctx, store, task.ObjectName, info.ETag, partPath, offset, enc); err != nil {
+			return false, err
+		}
+	} else if info.Size == 0 {
+		// Empty objects have no range to fetch
+		if err := os.WriteFile(partPath, nil, 0644); err != nil {
+			return false, fmt.Errorf("failed to create %s: %w", partPath, err)
+		}
+	}
+
+	if err := verifyDownload(partPath, info.Size, etag); err != nil {
+		// Corrupted data must not be resumed from
+		os.Remove(partPath)
+		return false, err
+	}
+
+	if encrypted {
+		return false, decryptDownload(partPath, task.LocalPath, info.Metadata, enc)
+	}
+	if err := os.Rename(partPath, task.LocalPath); err != nil {
+		return false, fmt.Errorf("failed to move downloaded file into place: %w", err)
+	}
+	return false, nil
+}
+
+// removeStaleParts removes the partial downloads of localPath other than
+// partPath, they belong to versions of the object that changed since
+func removeStaleParts(localPath, partPath string) {
+	entries, err := os.ReadDir(filepath.Dir(localPath))
+	if err != nil {
+		return
+	}
+	prefix := filepath.Base(localPath) + "."
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || name == filepath.Base(partPath) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".part") {
+			continue
+		}
+		// "a.txt.b.txt.<etag>.part" belongs to a.txt.b.txt, not to a.txt
+		if etag := strings.TrimSuffix(name[len(prefix):], ".part"); etag != "" && !strings.Contains(etag, ".") {
+			os.Remove(filepath.Join(filepath.Dir(localPath), name))
+		}
+	}
+}
+
+// decryptDownload decrypts the downloaded ciphertext at partPath into
+// localPath and removes it
+func decryptDownload(partPath, localPath string, metadata http.Header, enc *objectEncryption) error {
+	ciphertext, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	defer ciphertext.Close()
+
+	tmpPath := localPath + ".decrypting"
+	plaintext, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", tmpPath, err)
+	}
+	err = enc.open(plaintext, ciphertext, metadata)
+	if closeErr := plaintext.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to decrypt object: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, localPath); err != nil {
+		return fmt.Errorf("failed to move downloaded file into place: %w", err)
+	}
+	ciphertext.Close()
+	os.Remove(partPath)
+	return nil
+}
+
+// fetchObjectRange appends the object's content starting at offset to partPath
+func fetchObjectRange(ctx context.Context, store ObjectStore, objectName, etag, partPath string, offset int64, enc *objectEncryption) error {
+	getOpts := enc.getOptions()
+	if etag != "" {
+		getOpts.SetMatchETag(etag)
+	}
+	if offset > 0 {
+		if err := getOpts.SetRange(offset, 0); err != nil {
+			return fmt.Errorf("failed to set range: %w", err)
+		}
+	}
+
+	object, err := store.Get(ctx, objectName, getOpts)
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer object.Close()
+
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", partPath, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, object); err != nil {
+		return fmt.Errorf("failed to download object: %w", err)
+	}
+	return nil
+}
+
+// verifyDownload checks that the local file has the expected size and, for
+// single-part uploads whose ETag is the content MD5, the expected checksum
+func verifyDownload(localPath string, size int64, etag string) error {
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	if stat.Size() != size {
+		return fmt.Errorf("size mismatch for %s: got %d, want %d", localPath, stat.Size(), size)
+	}
+
+	if !isMD5ETag(etag) {
+		return nil
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", localPath, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, etag) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", localPath, sum, etag)
+	}
+	return nil
+}
+
+// isMD5ETag reports whether the ETag is a plain MD5 hex digest. Multipart
+// ETags ("<md5>-<parts>") cannot be verified without knowing the part size.
+func isMD5ETag(etag string) bool {
+	if len(etag) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(etag)
+	return err == nil
+}
@@ -0,0 +1,233 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/gogodjzhu/gogobox/pkg/s3fake"
+)
+
+// fakeS3 is an in-memory S3 endpoint serving the test bucket
+type fakeS3 struct {
+	*s3fake.Server
+	bucket string
+}
+
+// keys returns the sorted keys of the test bucket
+func (f *fakeS3) keys() []string {
+	return f.Keys(f.bucket)
+}
+
+// object returns the content of key, nil if it does not exist
+func (f *fakeS3) object(key string) []byte {
+	object, _ := f.Object(f.bucket, key)
+	return object.Data
+}
+
+// header returns the metadata stored with key
+func (f *fakeS3) header(key string) http.Header {
+	object, _ := f.Object(f.bucket, key)
+	return object.Header
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newFakeS3 serves objects from the test bucket of a fake S3 server
+func newFakeS3(t *testing.T, objects map[string][]byte) (*fakeS3, *MinIOConfig) {
+	fake := &fakeS3{Server: s3fake.New("test-bucket"), bucket: "test-bucket"}
+	for key, data := range objects {
+		fake.PutObject(fake.bucket, key, data, nil)
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, &MinIOConfig{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		BucketName:      fake.bucket,
+	}
+}
+
+func TestRunDownload(t *testing.T) {
+	objects := map[string][]byte{
+		"a/b/one.txt":     []byte("first object"),
+		"a/b/sub/two.txt": []byte("second object"),
+		"a/b/empty.txt":   {},
+		"other.txt":       []byte("third object"),
+	}
+	_, cfg := newFakeS3(t, objects)
+	outDir := t.TempDir()
+
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	opts := &DownloadOptions{Config: cfg, OutputDir: outDir, Parallel: 2}
+
+	if err := runDownload(context.Background(), f, opts, []string{"a/b/", "other.txt"}); err != nil {
+		t.Fatalf("runDownload() unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"b/one.txt":     "first object",
+		"b/sub/two.txt": "second object",
+		"b/empty.txt":   "",
+		"other.txt":     "third object",
+	}
+	for rel, want := range expected {
+		got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Errorf("expected file %s: %v", rel, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("file %s = %q, want %q", rel, got, want)
+		}
+	}
+
+	// A second run must skip everything
+	out.Reset()
+	if err := runDownload(context.Background(), f, opts, []string{"a/b/", "other.txt"}); err != nil {
+		t.Fatalf("runDownload() unexpected error: %v", err)
+	}
+	if got := strings.Count(out.String(), "Skipped"); got != 4 {
+		t.Errorf("expected 4 skipped downloads, got output:\n%s", out.String())
+	}
+}
+
+func TestRunDownloadOutsideOutputDir(t *testing.T) {
+	objects := map[string][]byte{
+		"prefix/ok.txt":          []byte("fine"),
+		"prefix/../../.bashrc":   []byte("rm -rf ~"),
+		"prefix/sub/../../x.txt": []byte("escapes the prefix only"),
+	}
+	_, cfg := newFakeS3(t, objects)
+	root := t.TempDir()
+	outDir := filepath.Join(root, "out")
+
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	opts := &DownloadOptions{Config: cfg, OutputDir: outDir, Parallel: 2}
+
+	err := runDownload(context.Background(), f, opts, []string{"prefix/"})
+	if err == nil || !strings.Contains(out.String(), "Failed prefix/../../.bashrc") {
+		t.Errorf("runDownload() error = %v, want the escaping key reported:\n%s", err, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, ".bashrc")); !os.IsNotExist(err) {
+		t.Errorf("object was written outside of the output directory")
+	}
+	for rel, want := range map[string]string{"prefix/ok.txt": "fine", "x.txt": "escapes the prefix only"} {
+		if got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(rel))); err != nil || string(got) != want {
+			t.Errorf("file %s = %q, %v, want %q", rel, got, err, want)
+		}
+	}
+}
+
+func TestDownloadObjectResume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	fake, cfg := newFakeS3(t, map[string][]byte{"big.bin": content})
+	outDir := t.TempDir()
+
+	// Simulate an interrupted download
+	localPath := filepath.Join(outDir, "big.bin")
+	partPath := localPath + "." + md5Hex(content) + ".part"
+	if err := os.WriteFile(partPath, content[:300], 0644); err != nil {
+		t.Fatalf("Failed to create part file: %v", err)
+	}
+	// Left behind by a version of the object that changed, and by another object
+	stalePath := localPath + "." + md5Hex([]byte("old")) + ".part"
+	otherPath := localPath + ".old." + md5Hex([]byte("other")) + ".part"
+	for _, name := range []string{stalePath, otherPath} {
+		if err := os.WriteFile(name, []byte("old"), 0644); err != nil {
+			t.Fatalf("Failed to create part file: %v", err)
+		}
+	}
+
+	skipped, err := downloadObject(context.Background(), newTestStore(t, cfg), downloadTask{ObjectName: "big.bin", LocalPath: localPath}, false, nil)
+	if err != nil {
+		t.Fatalf("downloadObject() unexpected error: %v", err)
+	}
+	if skipped {
+		t.Errorf("downloadObject() skipped a partial download")
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("resumed download content mismatch")
+	}
+	if _, err := os.Stat(partPath); !os.IsNotExist(err) {
+		t.Errorf("part file should be removed after download")
+	}
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Errorf("part file of an old version should be removed")
+	}
+	if _, err := os.Stat(otherPath); err != nil {
+		t.Errorf("part file of another object was removed: %v", err)
+	}
+	if ranges := fake.Stats().Ranges; len(ranges) != 1 || ranges[0] != "bytes=300-" {
+		t.Errorf("expected a single range request from byte 300, got %v", ranges)
+	}
+}
+
+func TestVerifyDownload(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "file.txt")
+	content := []byte("hello world")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		size    int64
+		etag    string
+		wantErr bool
+	}{
+		{"Matching MD5", int64(len(content)), md5Hex(content), false},
+		{"Multipart ETag only checks size", int64(len(content)), "abc-2", false},
+		{"Size mismatch", 1, md5Hex(content), true},
+		{"Checksum mismatch", int64(len(content)), strings.Repeat("0", 32), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDownload(file, tt.size, tt.etag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyDownload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRelativeObjectPath(t *testing.T) {
+	tests := []struct {
+		prefix     string
+		objectName string
+		want       string
+	}{
+		{"202401/", "202401/a.png", "202401/a.png"},
+		{"a/b/", "a/b/c.png", "b/c.png"},
+		{"a/b/", "a/b/c/d.png", "b/c/d.png"},
+		{"/", "x.png", "x.png"},
+	}
+
+	for _, tt := range tests {
+		if got := relativeObjectPath(tt.prefix, tt.objectName); got != tt.want {
+			t.Errorf("relativeObjectPath(%s, %s) = %s, want %s", tt.prefix, tt.objectName, got, tt.want)
+		}
+	}
+}
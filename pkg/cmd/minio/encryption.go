@@ -0,0 +1,181 @@
+package minio
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gogodjzhu/gogobox/internal/envelope"
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmd/secrets"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// User metadata of client-side encrypted objects, stored as x-amz-meta-*
+const (
+	metaEncryption = "Gogobox-Encryption"
+	metaKeyWrap    = "Gogobox-Key-Wrap"
+	metaWrappedKey = "Gogobox-Wrapped-Key"
+	metaNonce      = "Gogobox-Nonce"
+)
+
+// objectEncryption encrypts uploads and decrypts downloads with a 32 byte
+// key: on the server with SSE-C, or on the client with envelope encryption
+type objectEncryption struct {
+	key []byte
+	// sse is set for SSE-C
+	sse encrypt.ServerSide
+	// client enables envelope encryption of uploads, downloads are decrypted
+	// whenever their metadata says so
+	client bool
+}
+
+// validateUploadEncryption checks the combination of the encryption flags
+func validateUploadEncryption(opts *UploadOptions) error {
+	hasKey := opts.KeyFile != "" || opts.KeySecret != ""
+	switch {
+	case opts.SSEC && opts.Encrypt:
+		return errors.New("--sse-c and --encrypt cannot be combined")
+	case !opts.SSEC && !opts.Encrypt:
+		if hasKey {
+			return errors.New("--key-file and --key-secret need --sse-c or --encrypt")
+		}
+		return nil
+	case !hasKey:
+		return errors.New("--sse-c and --encrypt need --key-file or --key-secret")
+	case opts.Journal != "" || opts.Resume != "":
+		return errors.New("encrypted uploads cannot be journaled")
+	case opts.Dedupe:
+		return errors.New("encrypted uploads cannot be deduplicated")
+	}
+	return nil
+}
+
+// loadObjectEncryption reads the key from keyFile or the keySecret secret.
+// It returns nil without a key.
+func loadObjectEncryption(streams *cmdutil.IOStreams, keyFile, keySecret string, sseC, client bool) (*objectEncryption, error) {
+	var data []byte
+	switch {
+	case keyFile != "" && keySecret != "":
+		return nil, errors.New("--key-file and --key-secret cannot be combined")
+	case keyFile != "":
+		var err error
+		if data, err = os.ReadFile(keyFile); err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+	case keySecret != "":
+		secret, err := secrets.Lookup(streams, keySecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key: %w", err)
+		}
+		data = []byte(secret)
+	case sseC:
+		return nil, errors.New("--sse-c needs --key-file or --key-secret")
+	default:
+		return nil, nil
+	}
+
+	key, err := parseEncryptionKey(data)
+	if err != nil {
+		return nil, err
+	}
+	enc := &objectEncryption{key: key, client: client}
+	if sseC {
+		if enc.sse, err = encrypt.NewSSEC(key); err != nil {
+			return nil, err
+		}
+	}
+	return enc, nil
+}
+
+// parseEncryptionKey accepts 32 raw bytes, 64 hex digits or base64
+func parseEncryptionKey(data []byte) ([]byte, error) {
+	if len(data) == envelope.KeySize {
+		return data, nil
+	}
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == envelope.KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == envelope.KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("encryption keys must be %d bytes, raw, as hex or in base64 (e.g. openssl rand -base64 32)", envelope.KeySize)
+}
+
+// getOptions returns the options for reading objects, a nil objectEncryption
+// reads unencrypted objects
+func (e *objectEncryption) getOptions() minio.GetObjectOptions {
+	if e == nil {
+		return minio.GetObjectOptions{}
+	}
+	return minio.GetObjectOptions{ServerSideEncryption: e.sse}
+}
+
+func (e *objectEncryption) statOptions() minio.StatObjectOptions {
+	return e.getOptions()
+}
+
+// seal prepares the upload of size bytes read from r: SSE-C only adds its
+// headers, envelope encryption returns the ciphertext reader and size and
+// records the wrapped data key in the user metadata. The ciphertext is
+// stored as binary data.
+func (e *objectEncryption) seal(r io.Reader, size int64, putOpts *minio.PutObjectOptions) (io.Reader, int64, error) {
+	if e == nil {
+		return r, size, nil
+	}
+	if e.sse != nil {
+		putOpts.ServerSideEncryption = e.sse
+	}
+	if !e.client {
+		return r, size, nil
+	}
+
+	encrypted, header, err := envelope.NewEncrypter(r, e.key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	putOpts.ContentType, putOpts.ContentEncoding = util.DefaultContentType, ""
+	putOpts.UserMetadata = mergeMaps(putOpts.UserMetadata, map[string]string{
+		metaEncryption: envelope.Algorithm,
+		metaKeyWrap:    envelope.WrapAlgorithm,
+		metaWrappedKey: base64.StdEncoding.EncodeToString(header.WrappedKey),
+		metaNonce:      base64.StdEncoding.EncodeToString(header.Nonce),
+	})
+	return encrypted, envelope.EncryptedSize(size), nil
+}
+
+// isClientEncrypted reports whether the object metadata describes an
+// envelope encrypted object
+func isClientEncrypted(metadata http.Header) bool {
+	return metadata.Get("X-Amz-Meta-"+metaEncryption) != ""
+}
+
+// open writes the plaintext of the envelope encrypted object read from r to w
+func (e *objectEncryption) open(w io.Writer, r io.Reader, metadata http.Header) error {
+	if e == nil {
+		return errors.New("the object is encrypted, pass --key-file or --key-secret")
+	}
+	if algorithm := metadata.Get("X-Amz-Meta-" + metaEncryption); algorithm != envelope.Algorithm {
+		return fmt.Errorf("unsupported encryption %s", algorithm)
+	}
+	if wrap := metadata.Get("X-Amz-Meta-" + metaKeyWrap); wrap != envelope.WrapAlgorithm {
+		return fmt.Errorf("unsupported key wrapping %s", wrap)
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(metadata.Get("X-Amz-Meta-" + metaWrappedKey))
+	if err != nil {
+		return fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(metadata.Get("X-Amz-Meta-" + metaNonce))
+	if err != nil {
+		return fmt.Errorf("invalid nonce: %w", err)
+	}
+	return envelope.Decrypt(w, r, e.key, envelope.Header{WrappedKey: wrappedKey, Nonce: nonce})
+}
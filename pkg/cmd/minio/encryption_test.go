@@ -0,0 +1,209 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/internal/secretstore"
+	"github.com/gogodjzhu/gogobox/pkg/cmd/secrets"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+var testEncryptionKey = bytes.Repeat([]byte{0x42}, 32)
+
+func writeKeyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+	return path
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"raw", string(testEncryptionKey), false},
+		{"hex", hex.EncodeToString(testEncryptionKey) + "\n", false},
+		{"base64", base64.StdEncoding.EncodeToString(testEncryptionKey) + "\n", false},
+		{"too short", "c2hvcnQ=", true},
+		{"garbage", "not a key", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parseEncryptionKey([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEncryptionKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(key, testEncryptionKey) {
+				t.Errorf("parseEncryptionKey() = %x, want %x", key, testEncryptionKey)
+			}
+		})
+	}
+}
+
+func TestValidateUploadEncryption(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    UploadOptions
+		wantErr string
+	}{
+		{"none", UploadOptions{}, ""},
+		{"client", UploadOptions{Encrypt: true, KeyFile: "key"}, ""},
+		{"sse-c", UploadOptions{SSEC: true, KeySecret: "key"}, ""},
+		{"both", UploadOptions{SSEC: true, Encrypt: true, KeyFile: "key"}, "cannot be combined"},
+		{"no key", UploadOptions{Encrypt: true}, "need --key-file"},
+		{"key only", UploadOptions{KeyFile: "key"}, "need --sse-c or --encrypt"},
+		{"journal", UploadOptions{Encrypt: true, KeyFile: "key", Journal: "upload.journal"}, "journaled"},
+		{"dedupe", UploadOptions{SSEC: true, KeyFile: "key", Dedupe: true}, "deduplicated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUploadEncryption(&tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateUploadEncryption() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateUploadEncryption() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientEncryptionRoundTrip(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	keyFile := writeKeyFile(t, hex.EncodeToString(testEncryptionKey))
+	plaintext := strings.Repeat("confidential artifact ", 5000)
+	file := filepath.Join(t.TempDir(), "artifact.txt")
+	if err := os.WriteFile(file, []byte(plaintext), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	uploadOpts := &UploadOptions{Config: cfg, KeyTemplate: "{basename}.{ext}", Encrypt: true, KeyFile: keyFile}
+	if err := runUpload(context.Background(), f, uploadOpts, []string{file}); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+	stored := fake.object("artifact.txt")
+	if len(stored) == 0 || bytes.Contains(stored, []byte("confidential")) {
+		t.Fatalf("stored object is missing or not encrypted")
+	}
+	header := fake.header("artifact.txt")
+	if header.Get("X-Amz-Meta-Gogobox-Encryption") == "" || header.Get("X-Amz-Meta-Gogobox-Wrapped-Key") == "" {
+		t.Errorf("stored metadata %v lacks the wrapped key", header)
+	}
+	if got := header.Get("Content-Type"); got != "application/octet-stream" {
+		t.Errorf("stored Content-Type = %q, want application/octet-stream", got)
+	}
+
+	tests := []struct {
+		name    string
+		opts    DownloadOptions
+		wantErr string
+	}{
+		{"no key", DownloadOptions{}, "the object is encrypted"},
+		{"wrong key", DownloadOptions{KeyFile: writeKeyFile(t, strings.Repeat("00", 32))}, "wrong encryption key"},
+		{"key file", DownloadOptions{KeyFile: keyFile}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+			opts := tt.opts
+			opts.Config, opts.OutputDir, opts.Parallel = cfg, t.TempDir(), 1
+
+			err := runDownload(context.Background(), f, &opts, []string{"artifact.txt"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(out.String(), tt.wantErr) {
+					t.Fatalf("runDownload() error = %v, output %q, want %q", err, out.String(), tt.wantErr)
+				}
+				if _, err := os.Stat(filepath.Join(opts.OutputDir, "artifact.txt")); err == nil {
+					t.Errorf("a file was written despite the failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runDownload() unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(opts.OutputDir, "artifact.txt"))
+			if err != nil || string(got) != plaintext {
+				t.Fatalf("downloaded file differs from the plaintext (%v)", err)
+			}
+
+			// The decrypted file is recognized as up to date
+			out.Reset()
+			if err := runDownload(context.Background(), f, &opts, []string{"artifact.txt"}); err != nil {
+				t.Fatalf("runDownload() unexpected error: %v", err)
+			}
+			if !strings.Contains(out.String(), "Skipped") {
+				t.Errorf("second download was not skipped: %q", out.String())
+			}
+		})
+	}
+}
+
+func TestClientEncryptionKeySecret(t *testing.T) {
+	t.Setenv(secretstore.EnvSecretsFile, filepath.Join(t.TempDir(), "secrets.enc"))
+	t.Setenv(secrets.EnvPassphrase, "passphrase")
+	streams := &cmdutil.IOStreams{In: strings.NewReader(""), Out: &bytes.Buffer{}}
+	store, err := secrets.Unlock(streams)
+	if err != nil {
+		t.Fatalf("Unlock() unexpected error: %v", err)
+	}
+	store.Set("artifacts-key", base64.StdEncoding.EncodeToString(testEncryptionKey))
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	_, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: streams}
+	uploadOpts := &UploadOptions{Config: cfg, KeyTemplate: "{basename}.{ext}", Encrypt: true, KeySecret: "artifacts-key"}
+	if err := runUpload(context.Background(), f, uploadOpts, writeTestFiles(t, 1)); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+
+	// The same key read from a file decrypts the object
+	downloadOpts := &DownloadOptions{Config: cfg, OutputDir: t.TempDir(), Parallel: 1, KeyFile: writeKeyFile(t, string(testEncryptionKey))}
+	if err := runDownload(context.Background(), f, downloadOpts, []string{"file0.txt"}); err != nil {
+		t.Fatalf("runDownload() unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(downloadOpts.OutputDir, "file0.txt")); string(got) != "content 0" {
+		t.Errorf("downloaded content = %q, want %q", got, "content 0")
+	}
+}
+
+func TestSSECUpload(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	keyFile := writeKeyFile(t, base64.StdEncoding.EncodeToString(testEncryptionKey))
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+
+	uploadOpts := &UploadOptions{Config: cfg, KeyTemplate: "{basename}.{ext}", SSEC: true, KeyFile: keyFile, Output: OutputJSON}
+	if err := runUpload(context.Background(), f, uploadOpts, writeTestFiles(t, 1)); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+	if got := fake.header("file0.txt").Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"); got != "AES256" {
+		t.Errorf("SSE-C algorithm header = %q, want AES256", got)
+	}
+
+	downloadOpts := &DownloadOptions{Config: cfg, OutputDir: t.TempDir(), Parallel: 1, SSEC: true, KeyFile: keyFile}
+	if err := runDownload(context.Background(), f, downloadOpts, []string{"file0.txt"}); err != nil {
+		t.Fatalf("runDownload() unexpected error: %v", err)
+	}
+
+	downloadOpts.KeyFile = ""
+	if err := runDownload(context.Background(), f, downloadOpts, []string{"file0.txt"}); err == nil || !strings.Contains(err.Error(), "--sse-c needs") {
+		t.Errorf("runDownload() error = %v, want missing key error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package minio
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is read from the root of the local directory of a sync
+const IgnoreFile = ".gogoboxignore"
+
+// globRule is a gitignore style pattern. Patterns without a slash match a
+// file or directory name at any depth, others are anchored at the root.
+// "**" matches across directories and a trailing "/" only matches
+// directories.
+type globRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func newGlobRule(pattern string) (*globRule, error) {
+	rule := &globRule{}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	if !anchored {
+		expr.WriteString("(^|.*/)")
+	} else {
+		expr.WriteString("^")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					expr.WriteString("(.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' in %q", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	rule.pattern = re
+	return rule, nil
+}
+
+func (r *globRule) match(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	return r.pattern.MatchString(relPath)
+}
+
+// pathFilter decides which relative paths take part in a sync
+type pathFilter struct {
+	includes []*globRule
+	excludes []*globRule
+}
+
+// newPathFilter builds the filter from --include and --exclude patterns and
+// the rules of ignoreFile, which may be missing. Later rules override
+// earlier ones, so "!pattern" in the ignore file re-includes paths.
+func newPathFilter(includes, excludes []string, ignoreFile string) (*pathFilter, error) {
+	filter := &pathFilter{}
+	for _, pattern := range includes {
+		rule, err := newGlobRule(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --include: %w", err)
+		}
+		filter.includes = append(filter.includes, rule)
+	}
+
+	if ignoreFile != "" {
+		file, err := os.Open(ignoreFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", ignoreFile, err)
+		}
+		if err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for line := 1; scanner.Scan(); line++ {
+				pattern := strings.TrimSpace(scanner.Text())
+				if pattern == "" || strings.HasPrefix(pattern, "#") {
+					continue
+				}
+				rule, err := newGlobRule(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", ignoreFile, line, err)
+				}
+				filter.excludes = append(filter.excludes, rule)
+			}
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", ignoreFile, err)
+			}
+		}
+	}
+
+	for _, pattern := range excludes {
+		rule, err := newGlobRule(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude: %w", err)
+		}
+		filter.excludes = append(filter.excludes, rule)
+	}
+	return filter, nil
+}
+
+// Match reports whether the file at relPath (slash separated) is synced. A
+// file is included or excluded when any of its parent directories is.
+func (f *pathFilter) Match(relPath string) bool {
+	if path.Base(relPath) == IgnoreFile {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	if len(f.includes) > 0 && !f.included(parts) {
+		return false
+	}
+	for i := range parts {
+		if f.excluded(strings.Join(parts[:i+1], "/"), i < len(parts)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *pathFilter) included(parts []string) bool {
+	for i := range parts {
+		for _, rule := range f.includes {
+			if rule.match(strings.Join(parts[:i+1], "/"), i < len(parts)-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *pathFilter) excluded(relPath string, isDir bool) bool {
+	excluded := false
+	for _, rule := range f.excludes {
+		if rule.match(relPath, isDir) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
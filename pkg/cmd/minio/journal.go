@@ -0,0 +1,376 @@
+package minio
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// defaultJournalPartSize is the part size of journaled uploads when no
+// --part-size is given. Parts are the unit of resumption.
+const defaultJournalPartSize = 16 * 1024 * 1024
+
+// uploadJournal records the progress of an upload run, down to single parts
+// of multipart uploads, so an interrupted run can be continued with --resume
+type uploadJournal struct {
+	mu   sync.Mutex
+	path string
+
+	Endpoint string        `json:"endpoint"`
+	Bucket   string        `json:"bucket"`
+	PartSize int64         `json:"partSize"`
+	Files    []journalFile `json:"files"`
+}
+
+// journalFile is the upload state of a single file
+type journalFile struct {
+	Source     string        `json:"source"`
+	ObjectName string        `json:"objectName"`
+	Size       int64         `json:"size"`
+	UploadID   string        `json:"uploadId,omitempty"`
+	Parts      []journalPart `json:"parts,omitempty"`
+	Done       bool          `json:"done"`
+
+	// Metadata holds the headers, user metadata and tags of the object,
+	// resolved when the journal was written
+	Metadata *objectMetadata `json:"metadata,omitempty"`
+
+	// Temporary marks sources created by processing (resized images,
+	// downloads), which are removed once the run completes
+	Temporary bool `json:"temporary,omitempty"`
+}
+
+// journalPart is a completed part of a multipart upload
+type journalPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// newUploadJournal creates a journal for uploading sources as objectNames to
+// the bucket of cfg. temporary holds the sources created by processing,
+// metadata resolves the metadata of every object.
+func newUploadJournal(path string, cfg *MinIOConfig, sources, objectNames []string, temporary map[string]bool, partSize int64, metadata *uploadMetadata) *uploadJournal {
+	if partSize == 0 {
+		partSize = defaultJournalPartSize
+	}
+	journal := &uploadJournal{
+		path:     path,
+		Endpoint: cfg.Endpoint,
+		Bucket:   cfg.BucketName,
+		PartSize: partSize,
+		Files:    make([]journalFile, len(sources)),
+	}
+	for i, source := range sources {
+		journal.Files[i].Source = source
+		journal.Files[i].ObjectName = objectNames[i]
+		journal.Files[i].Temporary = temporary[source]
+		objectMeta := metadata.For(source)
+		journal.Files[i].Metadata = &objectMeta
+	}
+	return journal
+}
+
+// loadUploadJournal reads the journal of an interrupted run
+func loadUploadJournal(path string) (*uploadJournal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	journal := &uploadJournal{path: path}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %s: %w", path, err)
+	}
+	if journal.PartSize < minPartSize || len(journal.Files) == 0 {
+		return nil, fmt.Errorf("invalid journal %s", path)
+	}
+	return journal, nil
+}
+
+// Sources returns the files recorded in the journal
+func (j *uploadJournal) Sources() []string {
+	sources := make([]string, len(j.Files))
+	for i, file := range j.Files {
+		sources[i] = file.Source
+	}
+	return sources
+}
+
+// TemporaryFiles returns the sources created by processing
+func (j *uploadJournal) TemporaryFiles() []string {
+	var files []string
+	for _, file := range j.Files {
+		if file.Temporary {
+			files = append(files, file.Source)
+		}
+	}
+	return files
+}
+
+// ObjectNames returns the object keys recorded in the journal
+func (j *uploadJournal) ObjectNames() []string {
+	objectNames := make([]string, len(j.Files))
+	for i, file := range j.Files {
+		objectNames[i] = file.ObjectName
+	}
+	return objectNames
+}
+
+// Check makes sure the journal belongs to the configured bucket
+func (j *uploadJournal) Check(cfg *MinIOConfig) error {
+	if j.Endpoint != cfg.Endpoint || j.Bucket != cfg.BucketName {
+		return fmt.Errorf("journal %s was written for %s/%s, not %s/%s",
+			j.path, j.Endpoint, j.Bucket, cfg.Endpoint, cfg.BucketName)
+	}
+	return nil
+}
+
+// File returns a copy of the state of file idx
+func (j *uploadJournal) File(idx int) journalFile {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	file := j.Files[idx]
+	file.Parts = append([]journalPart(nil), file.Parts...)
+	return file
+}
+
+// Update modifies the state of file idx and persists the journal
+func (j *uploadJournal) Update(idx int, update func(file *journalFile)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	update(&j.Files[idx])
+	return j.save()
+}
+
+// Save persists the journal
+func (j *uploadJournal) Save() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.save()
+}
+
+// Remove deletes the journal file after a completed run
+func (j *uploadJournal) Remove() error {
+	return os.Remove(j.path)
+}
+
+func (j *uploadJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	// Replace atomically, a torn journal would make the run unresumable
+	tmpPath := j.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// uploadFileJournaled uploads file idx of the journal, skipping work recorded
+// as done. Files larger than the journal's part size are uploaded part by
+// part, and every completed part is persisted before the next one starts.
+func uploadFileJournaled(ctx context.Context, client *minio.Client, filename string, journal *uploadJournal, idx int, progress *transferProgress) error {
+	entry := journal.File(idx)
+
+	// Objects recorded as done are skipped while they are still present,
+	// their (temporary) source file may be gone already
+	if entry.Done {
+		if info, err := client.StatObject(ctx, journal.Bucket, entry.ObjectName, minio.StatObjectOptions{}); err == nil && info.Size == entry.Size {
+			progress.Start(idx, entry.Size)
+			progress.Add(idx, entry.Size)
+			return nil
+		}
+	}
+
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("failed to get file stats for %s: %w", filename, err)
+	}
+
+	if entry.Size != stat.Size() || entry.Done {
+		// The file changed since the journal was written, or its object is
+		// gone again; start it over
+		if entry.UploadID != "" {
+			client.RemoveIncompleteUpload(ctx, journal.Bucket, entry.ObjectName)
+		}
+		if err := journal.Update(idx, func(file *journalFile) {
+			file.Size = stat.Size()
+			file.UploadID = ""
+			file.Parts = nil
+			file.Done = false
+		}); err != nil {
+			return err
+		}
+		entry = journal.File(idx)
+	}
+
+	progress.Start(idx, entry.Size)
+
+	// Skip objects that are already present with the same content
+	if info, err := client.StatObject(ctx, journal.Bucket, entry.ObjectName, minio.StatObjectOptions{}); err == nil &&
+		info.Size == entry.Size && matchesETag(filename, entry.Size, journal.PartSize, info.ETag) {
+		progress.Add(idx, entry.Size)
+		return journal.Update(idx, func(file *journalFile) {
+			file.Done = true
+		})
+	}
+
+	// Journals of older versions carry no metadata
+	metadata := objectMetadata{ContentType: getContentType(filename)}
+	if entry.Metadata != nil {
+		metadata = *entry.Metadata
+	}
+	if entry.Size <= journal.PartSize {
+		file, err := os.Open(filename)
+		if err != nil {
+			return fmt.Errorf("failed to open file %s: %w", filename, err)
+		}
+		defer file.Close()
+
+		putOpts := metadata.putOptions()
+		putOpts.Progress = progress.Reader(idx)
+		// The client's own part size would store files above it as multipart
+		// objects, whose ETags do not match the journal part size on resume
+		putOpts.PartSize = uint64(journal.PartSize)
+		if _, err := client.PutObject(ctx, journal.Bucket, entry.ObjectName, file, entry.Size, putOpts); err != nil {
+			return fmt.Errorf("failed to upload file %s: %w", filename, err)
+		}
+	} else if err := uploadPartsJournaled(ctx, client, filename, journal, idx, metadata, progress); err != nil {
+		if !isNoSuchUpload(err) {
+			return err
+		}
+		// The upload expired or was aborted, start the file over once
+		if err := journal.Update(idx, func(file *journalFile) {
+			file.UploadID = ""
+			file.Parts = nil
+		}); err != nil {
+			return err
+		}
+		if err := uploadPartsJournaled(ctx, client, filename, journal, idx, metadata, progress); err != nil {
+			return err
+		}
+	}
+
+	return journal.Update(idx, func(file *journalFile) {
+		file.Done = true
+		file.UploadID = ""
+		file.Parts = nil
+	})
+}
+
+// uploadPartsJournaled runs or continues the multipart upload of file idx
+func uploadPartsJournaled(ctx context.Context, client *minio.Client, filename string, journal *uploadJournal, idx int, metadata objectMetadata, progress *transferProgress) error {
+	core := minio.Core{Client: client}
+	entry := journal.File(idx)
+
+	if entry.UploadID == "" {
+		uploadID, err := core.NewMultipartUpload(ctx, journal.Bucket, entry.ObjectName, metadata.putOptions())
+		if err != nil {
+			return fmt.Errorf("failed to start multipart upload of %s: %w", filename, err)
+		}
+		if err := journal.Update(idx, func(file *journalFile) {
+			file.UploadID = uploadID
+		}); err != nil {
+			return err
+		}
+		entry = journal.File(idx)
+	}
+
+	completed := map[int]journalPart{}
+	for _, part := range entry.Parts {
+		completed[part.Number] = part
+		progress.Add(idx, part.Size)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	number := 1
+	for offset := int64(0); offset < entry.Size; offset += journal.PartSize {
+		size := journal.PartSize
+		if remaining := entry.Size - offset; remaining < size {
+			size = remaining
+		}
+		if _, ok := completed[number]; !ok {
+			reader := io.TeeReader(io.NewSectionReader(file, offset, size), progress.Writer(idx))
+			part, err := core.PutObjectPart(ctx, journal.Bucket, entry.ObjectName, entry.UploadID, number, reader, size, minio.PutObjectPartOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to upload part %d of %s: %w", number, filename, err)
+			}
+			completed[number] = journalPart{Number: number, ETag: part.ETag, Size: size}
+			if err := journal.Update(idx, func(file *journalFile) {
+				file.Parts = append(file.Parts, completed[number])
+			}); err != nil {
+				return err
+			}
+		}
+		number++
+	}
+
+	parts := make([]minio.CompletePart, 0, len(completed))
+	for _, part := range completed {
+		parts = append(parts, minio.CompletePart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	if _, err := core.CompleteMultipartUpload(ctx, journal.Bucket, entry.ObjectName, entry.UploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload of %s: %w", filename, err)
+	}
+	return nil
+}
+
+// isNoSuchUpload reports whether err is the server's answer for an unknown
+// multipart upload ID
+func isNoSuchUpload(err error) bool {
+	var errResp minio.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Code == "NoSuchUpload"
+}
+
+// matchesETag reports whether the local file has the given S3 ETag, computing
+// the multipart form ("<md5 of part md5s>-<parts>") for the journal part size
+func matchesETag(filename string, size, partSize int64, etag string) bool {
+	file, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	if !strings.Contains(etag, "-") {
+		hash := md5.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return false
+		}
+		return strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), etag)
+	}
+
+	var sums []byte
+	parts := 0
+	for offset := int64(0); offset < size; offset += partSize {
+		hash := md5.New()
+		if _, err := io.Copy(hash, io.NewSectionReader(file, offset, partSize)); err != nil {
+			return false
+		}
+		sums = hash.Sum(sums)
+		parts++
+	}
+	sum := md5.Sum(sums)
+	return strings.EqualFold(fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), parts), etag)
+}
@@ -0,0 +1,204 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestRunUploadResume(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	if err := os.WriteFile(small, []byte("small file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	big := filepath.Join(dir, "big.bin")
+	bigData := bytes.Repeat([]byte("0123456789abcdef"), 12*1024*1024/16)
+	if err := os.WriteFile(big, bigData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	journalPath := filepath.Join(dir, "upload.journal")
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+
+	// Interrupt the multipart upload after its first part
+	fake.FailPartsAfter(1)
+	opts := &UploadOptions{Config: cfg, Concurrency: 1, PartSize: minPartSize, Journal: journalPath}
+	err := runUpload(context.Background(), f, opts, []string{small, big})
+	if err == nil || !strings.Contains(err.Error(), "--resume "+journalPath) {
+		t.Fatalf("runUpload() error = %v, want resume hint", err)
+	}
+
+	journal, err := loadUploadJournal(journalPath)
+	if err != nil {
+		t.Fatalf("loadUploadJournal() unexpected error: %v", err)
+	}
+	if !journal.Files[0].Done {
+		t.Errorf("small file not recorded as done: %+v", journal.Files[0])
+	}
+	if entry := journal.Files[1]; entry.Done || entry.UploadID == "" || len(entry.Parts) != 1 {
+		t.Errorf("big file entry = %+v, want one recorded part", entry)
+	}
+	if _, ok := fake.Object(fake.bucket, journal.Files[0].ObjectName); !ok {
+		t.Errorf("small file was rolled back in a journaled run")
+	}
+
+	// The completed file is skipped, so its source is not needed anymore
+	os.Remove(small)
+	fake.FailPartsAfter(0)
+	if err := runUpload(context.Background(), f, &UploadOptions{Config: cfg, Concurrency: 1, Resume: journalPath}, nil); err != nil {
+		t.Fatalf("runUpload() resume unexpected error: %v", err)
+	}
+
+	if fake.Stats().PartPuts != 3 {
+		t.Errorf("uploaded %d parts in total, want 3 (1 + 2 resumed)", fake.Stats().PartPuts)
+	}
+	if got := fake.object(journal.Files[1].ObjectName); !bytes.Equal(got, bigData) {
+		t.Errorf("resumed object has %d bytes, want %d", len(got), len(bigData))
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Errorf("journal was not removed after a completed run: %v", err)
+	}
+}
+
+func TestRunUploadResumeLargePartSize(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	dir := t.TempDir()
+	// Above the client's default part size, below the journal's
+	file := filepath.Join(dir, "file.bin")
+	if err := os.WriteFile(file, bytes.Repeat([]byte("0123456789abcdef"), 17*1024*1024/16), 0644); err != nil {
+		t.Fatal(err)
+	}
+	journalPath := filepath.Join(dir, "upload.journal")
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+
+	opts := &UploadOptions{Config: cfg, Concurrency: 1, PartSize: 32 * 1024 * 1024, Journal: journalPath}
+	if err := runUpload(context.Background(), f, opts, []string{file}); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+	if stats := fake.Stats(); stats.Puts != 1 || stats.PartPuts != 0 {
+		t.Errorf("uploaded with %d puts and %d parts, want a single put", stats.Puts, stats.PartPuts)
+	}
+
+	// A resumed run recognizes the object as complete
+	journal := newUploadJournal(journalPath, cfg, []string{file}, fake.keys(), nil, opts.PartSize, nil)
+	if err := journal.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := runUpload(context.Background(), f, &UploadOptions{Config: cfg, Concurrency: 1, Resume: journalPath}, nil); err != nil {
+		t.Fatalf("runUpload() resume unexpected error: %v", err)
+	}
+	if stats := fake.Stats(); stats.Puts != 1 {
+		t.Errorf("resumed run uploaded the complete object again")
+	}
+}
+
+func TestRunUploadResumeFirstFailure(t *testing.T) {
+	defer func(retries int) { minio.MaxRetry = retries }(minio.MaxRetry)
+	minio.MaxRetry = 1
+
+	fake, cfg := newFakeS3(t, nil)
+	dir := t.TempDir()
+	// Empty files have nothing to record before their upload
+	file := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	journalPath := filepath.Join(dir, "upload.journal")
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+
+	// Nothing completes, the journal must still be written
+	fake.FailPuts(1)
+	opts := &UploadOptions{Config: cfg, Concurrency: 1, Journal: journalPath}
+	err := runUpload(context.Background(), f, opts, []string{file})
+	if err == nil || !strings.Contains(err.Error(), "--resume "+journalPath) {
+		t.Fatalf("runUpload() error = %v, want resume hint", err)
+	}
+	journal, err := loadUploadJournal(journalPath)
+	if err != nil {
+		t.Fatalf("journal was not written: %v", err)
+	}
+
+	if err := runUpload(context.Background(), f, &UploadOptions{Config: cfg, Concurrency: 1, Resume: journalPath}, nil); err != nil {
+		t.Fatalf("runUpload() resume unexpected error: %v", err)
+	}
+	if _, ok := fake.Object(fake.bucket, journal.Files[0].ObjectName); !ok {
+		t.Errorf("resumed object %s is missing", journal.Files[0].ObjectName)
+	}
+}
+
+func TestRunUploadResumeErrors(t *testing.T) {
+	_, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	dir := t.TempDir()
+
+	journal := newUploadJournal(filepath.Join(dir, "other.journal"), &MinIOConfig{Endpoint: "other:9000", BucketName: "other"}, []string{"a.txt"}, []string{"a.txt"}, nil, 0, nil)
+	if err := journal.Save(); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.journal")
+	if err := os.WriteFile(invalid, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		resume  string
+		wantErr string
+	}{
+		{name: "missing journal", resume: filepath.Join(dir, "missing.journal"), wantErr: "failed to read journal"},
+		{name: "invalid journal", resume: invalid, wantErr: "failed to parse journal"},
+		{name: "other bucket", resume: journal.path, wantErr: "was written for other:9000/other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runUpload(context.Background(), f, &UploadOptions{Config: cfg, Resume: tt.resume}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runUpload() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesETag(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 25)
+	file := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Multipart ETag of 10 byte parts: md5 of the concatenated part md5s
+	var sums []byte
+	for _, part := range [][]byte{data[:10], data[10:20], data[20:]} {
+		sum := md5.Sum(part)
+		sums = append(sums, sum[:]...)
+	}
+	multipart := fmt.Sprintf("%s-3", md5Hex(sums))
+
+	tests := []struct {
+		name     string
+		partSize int64
+		etag     string
+		want     bool
+	}{
+		{name: "plain md5", partSize: 10, etag: md5Hex(data), want: true},
+		{name: "plain md5 mismatch", partSize: 10, etag: md5Hex([]byte("other")), want: false},
+		{name: "multipart", partSize: 10, etag: multipart, want: true},
+		{name: "multipart other part size", partSize: 5, etag: multipart, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesETag(file, int64(len(data)), tt.partSize, tt.etag); got != tt.want {
+				t.Errorf("matchesETag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
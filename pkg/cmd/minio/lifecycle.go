@@ -0,0 +1,322 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/cobra"
+)
+
+type LifecycleOptions struct {
+	Config         *MinIOConfig
+	ID             string
+	Prefix         string
+	Days           int
+	NoncurrentDays int
+	Output         string
+}
+
+// lifecycleRule is an expiration rule of a bucket lifecycle configuration
+type lifecycleRule struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Prefix         string `json:"prefix"`
+	Days           int    `json:"days,omitempty"`
+	Date           string `json:"date,omitempty"`
+	NoncurrentDays int    `json:"noncurrentDays,omitempty"`
+
+	// rule is the complete rule, written back unchanged so that settings
+	// this command does not know about (transitions, tags) are kept
+	rule lifecycle.Rule
+}
+
+func NewCmdMinIOLifecycle(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lifecycle",
+		Short: "Manage expiration rules of a bucket",
+		Long: `Manage the lifecycle expiration rules of a bucket.
+
+Objects matching a rule are deleted by the server once they are older than
+the rule's number of days. On versioned buckets --noncurrent-days also expires
+old versions of objects.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newCmdLifecycleList(f))
+	cmd.AddCommand(newCmdLifecycleAdd(f))
+	cmd.AddCommand(newCmdLifecycleRemove(f))
+
+	return cmd
+}
+
+func newCmdLifecycleList(f *cmdutil.Factory) *cobra.Command {
+	opts := &LifecycleOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "ls [flags] [bucket]",
+		Aliases: []string{"list"},
+		Short:   "List the lifecycle rules of a bucket",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runLifecycleList(cmd.Context(), f, opts, args)
+		},
+	}
+
+	addLifecycleFlags(cmd, opts)
+
+	return cmd
+}
+
+func newCmdLifecycleAdd(f *cmdutil.Factory) *cobra.Command {
+	opts := &LifecycleOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "add [flags] [bucket]",
+		Short: "Add or replace an expiration rule",
+		Long:  `Add an expiration rule to a bucket. A rule with the same --id is replaced.`,
+		Example: `  # Delete temporary uploads after a week
+  gogobox minio lifecycle add --prefix tmp/ --days 7
+
+  # Keep old versions of objects for 30 days
+  gogobox minio lifecycle add --id old-versions --noncurrent-days 30 mybucket`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runLifecycleAdd(cmd.Context(), f, opts, args)
+		},
+	}
+
+	addLifecycleFlags(cmd, opts)
+	cmd.Flags().StringVar(&opts.ID, "id", "", "ID of the rule (generated if empty)")
+	cmd.Flags().StringVar(&opts.Prefix, "prefix", "", "Only expire objects below this prefix")
+	cmd.Flags().IntVar(&opts.Days, "days", 0, "Delete objects this many days after their creation")
+	cmd.Flags().IntVar(&opts.NoncurrentDays, "noncurrent-days", 0, "Delete old versions this many days after they were replaced")
+
+	return cmd
+}
+
+func newCmdLifecycleRemove(f *cmdutil.Factory) *cobra.Command {
+	opts := &LifecycleOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rm [flags] <id> [bucket]",
+		Short: "Remove an expiration rule",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			opts.ID = args[0]
+			return runLifecycleRemove(cmd.Context(), f, opts, args[1:])
+		},
+	}
+
+	addLifecycleFlags(cmd, opts)
+
+	return cmd
+}
+
+func addLifecycleFlags(cmd *cobra.Command, opts *LifecycleOptions) {
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputText, "Output format: text or json")
+}
+
+func runLifecycleList(ctx context.Context, f *cmdutil.Factory, opts *LifecycleOptions, args []string) error {
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+	rules, err := getLifecycleRules(ctx, client, opts.Config.BucketName)
+	if err != nil {
+		return err
+	}
+	return writeLifecycleRules(f, opts.Output, rules)
+}
+
+func runLifecycleAdd(ctx context.Context, f *cmdutil.Factory, opts *LifecycleOptions, args []string) error {
+	if opts.Days < 0 || opts.NoncurrentDays < 0 || opts.Days+opts.NoncurrentDays == 0 {
+		return fmt.Errorf("configuration error: --days or --noncurrent-days must be a positive number of days")
+	}
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+
+	bucketName := opts.Config.BucketName
+	rules, err := getLifecycleRules(ctx, client, bucketName)
+	if err != nil {
+		return err
+	}
+
+	rule := newLifecycleRule(opts.ID, opts.Prefix, opts.Days, opts.NoncurrentDays)
+	replaced := false
+	for i := range rules {
+		if rules[i].ID == rule.ID {
+			rules[i] = rule
+			replaced = true
+		}
+	}
+	if !replaced {
+		rules = append(rules, rule)
+	}
+	if err := setLifecycleRules(ctx, client, bucketName, rules); err != nil {
+		return err
+	}
+	return writeLifecycleRules(f, opts.Output, []lifecycleRule{rule})
+}
+
+func runLifecycleRemove(ctx context.Context, f *cmdutil.Factory, opts *LifecycleOptions, args []string) error {
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+
+	bucketName := opts.Config.BucketName
+	rules, err := getLifecycleRules(ctx, client, bucketName)
+	if err != nil {
+		return err
+	}
+
+	var kept, removed []lifecycleRule
+	for _, rule := range rules {
+		if rule.ID == opts.ID {
+			removed = append(removed, rule)
+		} else {
+			kept = append(kept, rule)
+		}
+	}
+	if len(removed) == 0 {
+		return fmt.Errorf("bucket %s has no lifecycle rule %s", bucketName, opts.ID)
+	}
+	if err := setLifecycleRules(ctx, client, bucketName, kept); err != nil {
+		return err
+	}
+	return writeLifecycleRules(f, opts.Output, removed)
+}
+
+// newLifecycleRule creates an enabled expiration rule
+func newLifecycleRule(id, prefix string, days, noncurrentDays int) lifecycleRule {
+	if id == "" {
+		id = uuid.NewV4().String()
+	}
+	return lifecycleRule{
+		ID:             id,
+		Status:         "Enabled",
+		Prefix:         prefix,
+		Days:           days,
+		NoncurrentDays: noncurrentDays,
+		rule: lifecycle.Rule{
+			ID:                          id,
+			Status:                      "Enabled",
+			RuleFilter:                  lifecycle.Filter{Prefix: prefix},
+			Expiration:                  lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+			NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{NoncurrentDays: lifecycle.ExpirationDays(noncurrentDays)},
+		},
+	}
+}
+
+// getLifecycleRules returns the lifecycle rules of a bucket
+func getLifecycleRules(ctx context.Context, client *minio.Client, bucketName string) ([]lifecycleRule, error) {
+	config, err := client.GetBucketLifecycle(ctx, bucketName)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get lifecycle of bucket %s: %w", bucketName, err)
+	}
+
+	rules := make([]lifecycleRule, 0, len(config.Rules))
+	for _, r := range config.Rules {
+		// The prefix is either given directly (old format) or in the filter
+		prefix := r.Prefix
+		if r.RuleFilter.Prefix != "" {
+			prefix = r.RuleFilter.Prefix
+		} else if r.RuleFilter.And.Prefix != "" {
+			prefix = r.RuleFilter.And.Prefix
+		}
+		rule := lifecycleRule{
+			ID:             r.ID,
+			Status:         r.Status,
+			Prefix:         prefix,
+			Days:           int(r.Expiration.Days),
+			NoncurrentDays: int(r.NoncurrentVersionExpiration.NoncurrentDays),
+			rule:           r,
+		}
+		if !r.Expiration.Date.IsZero() {
+			rule.Date = r.Expiration.Date.Format(time.RFC3339)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// setLifecycleRules replaces the lifecycle configuration of a bucket, an
+// empty list of rules removes it
+func setLifecycleRules(ctx context.Context, client *minio.Client, bucketName string, rules []lifecycleRule) error {
+	config := lifecycle.NewConfiguration()
+	for _, rule := range rules {
+		config.Rules = append(config.Rules, rule.rule)
+	}
+	if err := client.SetBucketLifecycle(ctx, bucketName, config); err != nil {
+		return fmt.Errorf("failed to set lifecycle of bucket %s: %w", bucketName, err)
+	}
+	return nil
+}
+
+func writeLifecycleRules(f *cmdutil.Factory, output string, rules []lifecycleRule) error {
+	if output == OutputJSON {
+		if rules == nil {
+			rules = []lifecycleRule{}
+		}
+		return writeJSON(f.IOStreams.Out, rules)
+	}
+
+	w := tabwriter.NewWriter(f.IOStreams.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tPREFIX\tEXPIRATION")
+	for _, rule := range rules {
+		var expiration []string
+		if rule.Days > 0 {
+			expiration = append(expiration, fmt.Sprintf("%d days", rule.Days))
+		}
+		if rule.Date != "" {
+			expiration = append(expiration, rule.Date)
+		}
+		if rule.NoncurrentDays > 0 {
+			expiration = append(expiration, fmt.Sprintf("noncurrent after %d days", rule.NoncurrentDays))
+		}
+		if len(expiration) == 0 {
+			expiration = append(expiration, "-")
+		}
+		prefix := rule.Prefix
+		if prefix == "" {
+			prefix = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.ID, rule.Status, prefix, strings.Join(expiration, ", "))
+	}
+	return w.Flush()
+}
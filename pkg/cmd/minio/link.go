@@ -0,0 +1,51 @@
+package minio
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Link formats for printed URLs
+const (
+	LinkRaw      = "raw"
+	LinkMarkdown = "markdown"
+	LinkHTML     = "html"
+	LinkBBCode   = "bbcode"
+)
+
+// validateLinkFormat checks a --format value
+func validateLinkFormat(format string) error {
+	switch format {
+	case "", LinkRaw, LinkMarkdown, LinkHTML, LinkBBCode:
+		return nil
+	default:
+		return fmt.Errorf("unsupported link format: %s (use raw, markdown, html or bbcode)", format)
+	}
+}
+
+// formatLink renders url as a snippet for pasting into documents. Images are
+// embedded, other files are linked with name as text.
+func formatLink(format, url, name string, image bool) string {
+	switch format {
+	case LinkMarkdown:
+		// Brackets would end the link text early
+		text := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(name)
+		if image {
+			return fmt.Sprintf("![%s](%s)", text, url)
+		}
+		return fmt.Sprintf("[%s](%s)", text, url)
+	case LinkHTML:
+		if image {
+			return fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(url), html.EscapeString(name))
+		}
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(name))
+	case LinkBBCode:
+		if image {
+			return fmt.Sprintf("[img]%s[/img]", url)
+		}
+		return fmt.Sprintf("[url=%s]%s[/url]", url, name)
+	default:
+		return url
+	}
+}
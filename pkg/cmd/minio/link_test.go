@@ -0,0 +1,51 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestFormatLink(t *testing.T) {
+	const url = "https://cdn.example.com/a.png?x=1&y=2"
+
+	tests := []struct {
+		format string
+		name   string
+		image  bool
+		want   string
+	}{
+		{LinkRaw, "a.png", true, url},
+		{"", "a.png", true, url},
+		{LinkMarkdown, "a.png", true, "![a.png](" + url + ")"},
+		{LinkMarkdown, "notes [draft].pdf", false, `[notes \[draft\].pdf](` + url + ")"},
+		{LinkHTML, "a.png", true, `<img src="https://cdn.example.com/a.png?x=1&amp;y=2" alt="a.png">`},
+		{LinkHTML, "<b>.pdf", false, `<a href="https://cdn.example.com/a.png?x=1&amp;y=2">&lt;b&gt;.pdf</a>`},
+		{LinkBBCode, "a.png", true, "[img]" + url + "[/img]"},
+		{LinkBBCode, "report.pdf", false, "[url=" + url + "]report.pdf[/url]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.name, func(t *testing.T) {
+			if got := formatLink(tt.format, url, tt.name, tt.image); got != tt.want {
+				t.Errorf("formatLink() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunUploadInvalidFormat(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{Config: cfg, Format: "rst"}
+
+	if err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1)); err == nil || !strings.Contains(err.Error(), "unsupported link format") {
+		t.Errorf("runUpload() error = %v, want link format error", err)
+	}
+	if keys := fake.keys(); len(keys) != 0 {
+		t.Errorf("objects were uploaded despite invalid --format: %v", keys)
+	}
+}
@@ -0,0 +1,210 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/minio/minio-go/v7"
+)
+
+// localTempPrefix starts the names of files that are still being written
+const localTempPrefix = ".gogobox-upload-"
+
+// localStore keeps objects as files below a directory: the bucket is a
+// directory below Endpoint and keys are paths in it
+type localStore struct {
+	root string
+	cfg  *MinIOConfig
+}
+
+// newLocalStore opens the bucket directory of cfg
+func newLocalStore(cfg *MinIOConfig) (*localStore, error) {
+	root := filepath.Join(cfg.Endpoint, cfg.BucketName)
+	if stat, err := os.Stat(root); err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf("bucket '%s' does not exist (create the directory %s)", cfg.BucketName, root)
+	}
+	return &localStore{root: root, cfg: cfg}, nil
+}
+
+// path returns the file of key, keys that are no clean relative paths are
+// rejected so nothing is written outside of the bucket
+func (s *localStore) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", fmt.Errorf("object key %s cannot be stored as a file", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader, size int64, opts minio.PutObjectOptions) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	// Readers never see partially written objects
+	tmp, err := os.CreateTemp(filepath.Dir(name), localTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	written, err := io.Copy(tmp, &storeReader{ctx: ctx, r: r, progress: opts.Progress})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("read %d bytes of %d", written, size)
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *localStore) Get(ctx context.Context, key string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	info, err := s.Stat(ctx, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	header := opts.Header()
+	if etag := strings.Trim(header.Get("If-Match"), `"`); etag != "" && etag != info.ETag {
+		return nil, fmt.Errorf("object %s changed (ETag %s, expected %s)", key, info.ETag, etag)
+	}
+
+	name, _ := s.path(key)
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if r := header.Get("Range"); r != "" {
+		// Downloads only resume from an offset
+		spec, _ := strings.CutSuffix(strings.TrimPrefix(r, "bytes="), "-")
+		offset, err := strconv.ParseInt(spec, 10, 64)
+		if err != nil || !strings.HasPrefix(r, "bytes=") {
+			file.Close()
+			return nil, fmt.Errorf("unsupported range %s", r)
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (s *localStore) Stat(ctx context.Context, key string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	stat, err := os.Stat(name)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	if !stat.Mode().IsRegular() {
+		return minio.ObjectInfo{}, fmt.Errorf("object %s: %w", key, fs.ErrNotExist)
+	}
+	return localObjectInfo(key, stat), nil
+}
+
+func (s *localStore) List(ctx context.Context, prefix string, recursive bool) ([]minio.ObjectInfo, error) {
+	// Only the directory of the prefix is walked
+	dir := s.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = filepath.Join(s.root, filepath.FromSlash(prefix[:i]))
+	}
+
+	var objects []minio.ObjectInfo
+	prefixes := map[string]bool{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), localTempPrefix) {
+			return nil
+		}
+		relPath, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relPath)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		if !recursive {
+			if common := commonPrefix(prefix, key); common != "" {
+				if !prefixes[common] {
+					prefixes[common] = true
+					objects = append(objects, minio.ObjectInfo{Key: common})
+				}
+				return nil
+			}
+		}
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, localObjectInfo(key, stat))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	// Directories exist as long as objects below them, like prefixes
+	for dir := path.Dir(key); dir != "."; dir = path.Dir(dir) {
+		if os.Remove(filepath.Join(s.root, filepath.FromSlash(dir))) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *localStore) URL(key string) string {
+	return s.cfg.GetObjectURL(key)
+}
+
+// localObjectInfo describes the file of key. The ETag is derived from the
+// size and modification time, it is no MD5.
+func localObjectInfo(key string, stat fs.FileInfo) minio.ObjectInfo {
+	contentType := util.ContentTypeByExtension(key)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return minio.ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		LastModified: stat.ModTime(),
+		ETag:         fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), stat.Size()),
+		ContentType:  contentType,
+	}
+}
@@ -0,0 +1,258 @@
+package minio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmd/timefmt"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+)
+
+const (
+	OutputTable  = "table"
+	OutputJSON   = "json"
+	OutputNDJSON = "ndjson"
+	OutputText   = "text"
+)
+
+type ListOptions struct {
+	Config    *MinIOConfig
+	Recursive bool
+	OlderThan string
+	NewerThan string
+	Output    string
+	// ContentTypes looks up the content types the listing does not carry
+	ContentTypes bool
+}
+
+func NewCmdMinIOList(f *cmdutil.Factory) *cobra.Command {
+	opts := &ListOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "ls [flags] [prefix]",
+		Aliases: []string{"list"},
+		Short:   "List objects in a MinIO bucket",
+		Long: `List objects in a MinIO bucket.
+
+Without --recursive only the objects and "directories" directly below the
+prefix are shown. Time filters accept any input understood by the timefmt
+command, e.g. "2024-01-01", "2024-01-01 12:00:00" or a unix timestamp.
+
+Content types are shown if the listing includes them, which only MinIO
+listings do. --content-type looks up the others with one request per object.
+
+Output formats:
+- table:  human readable columns (default)
+- json:   a single JSON array
+- ndjson: one JSON object per line, suitable for streaming into scripts`,
+		Example: `  # List the top level of a bucket
+  gogobox minio ls -e localhost:9000 -a mykey -s mysecret -b mybucket
+
+  # List everything under 202401/ uploaded before February as NDJSON
+  gogobox minio ls -e localhost:9000 -a mykey -s mysecret -b mybucket -r --older-than 2024-02-01 -o ndjson 202401/`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prefix := ""
+			if len(args) > 0 {
+				prefix = args[0]
+			}
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runList(cmd.Context(), f, opts, prefix)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	// List options flags
+	cmd.Flags().BoolVarP(&opts.Recursive, "recursive", "r", false, "List objects recursively")
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "Only list objects last modified before this time")
+	cmd.Flags().StringVar(&opts.NewerThan, "newer-than", "", "Only list objects last modified after this time")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputTable, "Output format: table, json or ndjson")
+	cmd.Flags().BoolVar(&opts.ContentTypes, "content-type", false, "Look up content types the listing does not include")
+
+	return cmd
+}
+
+// objectEntry is the listing representation of an object or common prefix
+type objectEntry struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag"`
+	ContentType  string    `json:"contentType"`
+	IsDir        bool      `json:"isDir,omitempty"`
+}
+
+// timeFilter keeps objects whose modification time lies in (after, before)
+type timeFilter struct {
+	before time.Time
+	after  time.Time
+}
+
+func newTimeFilter(olderThan, newerThan string) (*timeFilter, error) {
+	formatter := &timefmt.TimeFormatter{}
+	filter := &timeFilter{}
+
+	if olderThan != "" {
+		t, err := formatter.ParseInput(olderThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --older-than: %w", err)
+		}
+		filter.before = t
+	}
+	if newerThan != "" {
+		t, err := formatter.ParseInput(newerThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --newer-than: %w", err)
+		}
+		filter.after = t
+	}
+	return filter, nil
+}
+
+func (tf *timeFilter) active() bool {
+	return !tf.before.IsZero() || !tf.after.IsZero()
+}
+
+func (tf *timeFilter) match(t time.Time) bool {
+	if !tf.before.IsZero() && !t.Before(tf.before) {
+		return false
+	}
+	if !tf.after.IsZero() && !t.After(tf.after) {
+		return false
+	}
+	return true
+}
+
+func runList(ctx context.Context, f *cmdutil.Factory, opts *ListOptions, prefix string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	switch opts.Output {
+	case OutputTable, OutputJSON, OutputNDJSON:
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.Output)
+	}
+
+	filter, err := newTimeFilter(opts.OlderThan, opts.NewerThan)
+	if err != nil {
+		return err
+	}
+
+	store, err := newStore(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+
+	entries, err := listObjects(ctx, store, prefix, opts.Recursive, filter, opts.ContentTypes)
+	if err != nil {
+		return err
+	}
+
+	return writeEntries(f.IOStreams.Out, opts.Output, entries)
+}
+
+// listObjects lists the objects below prefix. With lookupContentTypes the
+// content types that the listing does not carry are filled in with a stat
+// request per object.
+func listObjects(ctx context.Context, store ObjectStore, prefix string, recursive bool, filter *timeFilter, lookupContentTypes bool) ([]objectEntry, error) {
+	objects, err := store.List(ctx, prefix, recursive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	var entries []objectEntry
+	for _, object := range objects {
+		// Common prefixes are reported as directories
+		if strings.HasSuffix(object.Key, "/") && object.Size == 0 {
+			if filter.active() {
+				continue
+			}
+			entries = append(entries, objectEntry{Key: object.Key, IsDir: true})
+			continue
+		}
+
+		if !filter.match(object.LastModified) {
+			continue
+		}
+
+		contentType := object.ContentType
+		if contentType == "" {
+			contentType = object.UserMetadata["content-type"]
+		}
+		if contentType == "" && lookupContentTypes {
+			info, err := store.Stat(ctx, object.Key, minio.StatObjectOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat object %s: %w", object.Key, err)
+			}
+			contentType = info.ContentType
+		}
+
+		entries = append(entries, objectEntry{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+			ETag:         object.ETag,
+			ContentType:  contentType,
+		})
+	}
+
+	return entries, nil
+}
+
+// writeEntries prints the entries in the requested output format
+func writeEntries(out io.Writer, format string, entries []objectEntry) error {
+	switch format {
+	case OutputJSON:
+		if entries == nil {
+			entries = []objectEntry{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case OutputNDJSON:
+		encoder := json.NewEncoder(out)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LAST MODIFIED\tSIZE\tETAG\tCONTENT TYPE\tKEY")
+		for _, entry := range entries {
+			if entry.IsDir {
+				fmt.Fprintf(w, "-\tDIR\t-\t-\t%s\n", entry.Key)
+				continue
+			}
+			contentType := entry.ContentType
+			if contentType == "" {
+				contentType = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				entry.LastModified.Local().Format("2006-01-02 15:04:05"),
+				util.HumanSize(entry.Size),
+				entry.ETag,
+				contentType,
+				entry.Key)
+		}
+		return w.Flush()
+	}
+}
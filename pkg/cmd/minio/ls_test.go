@@ -0,0 +1,180 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+)
+
+func newListFixture(t *testing.T) *MinIOConfig {
+	fake, cfg := newFakeS3(t, map[string][]byte{
+		"202401/old.png": []byte("old"),
+		"202401/new.png": []byte("new"),
+		"top.txt":        []byte("top"),
+	})
+	modTimes := map[string]time.Time{
+		"202401/old.png": time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		"202401/new.png": time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		"top.txt":        time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC),
+	}
+	for key, modTime := range modTimes {
+		fake.SetModTime(fake.bucket, key, modTime)
+	}
+	return cfg
+}
+
+func TestRunList(t *testing.T) {
+	cfg := newListFixture(t)
+
+	tests := []struct {
+		name     string
+		opts     ListOptions
+		prefix   string
+		wantKeys []string
+	}{
+		{
+			name:     "Top level",
+			opts:     ListOptions{},
+			wantKeys: []string{"top.txt", "202401/"},
+		},
+		{
+			name:     "Recursive",
+			opts:     ListOptions{Recursive: true},
+			wantKeys: []string{"202401/new.png", "202401/old.png", "top.txt"},
+		},
+		{
+			name:     "Content types",
+			opts:     ListOptions{Recursive: true, ContentTypes: true},
+			wantKeys: []string{"202401/new.png", "202401/old.png", "top.txt"},
+		},
+		{
+			name:     "Prefix",
+			opts:     ListOptions{Recursive: true},
+			prefix:   "202401/",
+			wantKeys: []string{"202401/new.png", "202401/old.png"},
+		},
+		{
+			name:     "Older than",
+			opts:     ListOptions{Recursive: true, OlderThan: "2024-02-01"},
+			wantKeys: []string{"202401/old.png"},
+		},
+		{
+			name:     "Time window",
+			opts:     ListOptions{Recursive: true, NewerThan: "2024-01-10", OlderThan: "2024-03-01"},
+			wantKeys: []string{"top.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+			opts := tt.opts
+			opts.Config = cfg
+			opts.Output = OutputNDJSON
+
+			if err := runList(context.Background(), f, &opts, tt.prefix); err != nil {
+				t.Fatalf("runList() unexpected error: %v", err)
+			}
+
+			var keys []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				var entry objectEntry
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("invalid NDJSON line %q: %v", line, err)
+				}
+				if opts.ContentTypes && !entry.IsDir && entry.ContentType == "" {
+					t.Errorf("entry %s has no content type", entry.Key)
+				}
+				keys = append(keys, entry.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("runList() keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+// statCounter counts the stat requests of a store
+type statCounter struct {
+	ObjectStore
+	stats int
+}
+
+func (s *statCounter) Stat(ctx context.Context, key string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	s.stats++
+	return s.ObjectStore.Stat(ctx, key, opts)
+}
+
+func TestListObjectsContentTypes(t *testing.T) {
+	store := &statCounter{ObjectStore: newTestStore(t, newListFixture(t))}
+
+	// Listings of S3 providers other than MinIO carry no content types
+	entries, err := listObjects(context.Background(), store, "", true, &timeFilter{}, false)
+	if err != nil || len(entries) != 3 || store.stats != 0 {
+		t.Errorf("listObjects() = %d entries, %v with %d stats, want 3 without stats", len(entries), err, store.stats)
+	}
+
+	entries, err = listObjects(context.Background(), store, "", true, &timeFilter{}, true)
+	if err != nil || len(entries) != 3 || store.stats != 3 {
+		t.Fatalf("listObjects() = %d entries, %v with %d stats, want 3 with stats", len(entries), err, store.stats)
+	}
+	for _, entry := range entries {
+		if entry.ContentType == "" {
+			t.Errorf("entry %s has no content type", entry.Key)
+		}
+	}
+}
+
+func TestRunListInvalidInput(t *testing.T) {
+	cfg := newListFixture(t)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+
+	if err := runList(context.Background(), f, &ListOptions{Config: cfg, Output: "xml"}, ""); err == nil {
+		t.Errorf("runList() expected error for unsupported output format")
+	}
+	if err := runList(context.Background(), f, &ListOptions{Config: cfg, Output: OutputTable, OlderThan: "not a time"}, ""); err == nil {
+		t.Errorf("runList() expected error for invalid time filter")
+	}
+}
+
+func TestWriteEntries(t *testing.T) {
+	entries := []objectEntry{
+		{Key: "dir/", IsDir: true},
+		{Key: "dir/a.png", Size: 2048, ETag: "abc", ContentType: "image/png", LastModified: time.Unix(0, 0)},
+	}
+
+	out := &bytes.Buffer{}
+	if err := writeEntries(out, OutputJSON, entries); err != nil {
+		t.Fatalf("writeEntries() unexpected error: %v", err)
+	}
+	var decoded []objectEntry
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeEntries() produced invalid JSON: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].Size != 2048 {
+		t.Errorf("writeEntries() JSON round trip mismatch: %+v", decoded)
+	}
+
+	out.Reset()
+	if err := writeEntries(out, OutputTable, entries); err != nil {
+		t.Fatalf("writeEntries() unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "2.0KiB") || !strings.Contains(out.String(), "DIR") {
+		t.Errorf("writeEntries() table output unexpected:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeEntries(out, OutputJSON, nil); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("writeEntries() with no entries = %q, want []", out.String())
+	}
+}
@@ -0,0 +1,241 @@
+package minio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gogodjzhu/gogobox/internal/config"
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/minio/minio-go/v7"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRulesFile is read from the gogobox config directory when --rules is
+// not given
+const DefaultRulesFile = "upload-rules.yaml"
+
+// objectMetadata holds the headers, user metadata and tags an object is
+// uploaded with
+type objectMetadata struct {
+	ContentType        string            `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+	CacheControl       string            `json:"cacheControl,omitempty" yaml:"cacheControl,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty" yaml:"contentDisposition,omitempty"`
+	ContentEncoding    string            `json:"contentEncoding,omitempty" yaml:"contentEncoding,omitempty"`
+	StorageClass       string            `json:"storageClass,omitempty" yaml:"storageClass,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// metadataRule applies its metadata to files with one of the extensions or
+// content types. Content types may end with "/*" to match a whole family.
+type metadataRule struct {
+	Extensions     []string `yaml:"extensions"`
+	ContentTypes   []string `yaml:"contentTypes"`
+	objectMetadata `yaml:",inline"`
+}
+
+// rulesFile is the layout of the upload rules file
+type rulesFile struct {
+	Rules []metadataRule `yaml:"rules"`
+}
+
+// merge overrides the fields of m that are set in other
+func (m *objectMetadata) merge(other objectMetadata) {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&m.ContentType, other.ContentType},
+		{&m.CacheControl, other.CacheControl},
+		{&m.ContentDisposition, other.ContentDisposition},
+		{&m.ContentEncoding, other.ContentEncoding},
+		{&m.StorageClass, other.StorageClass},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	m.Metadata = mergeMaps(m.Metadata, other.Metadata)
+	m.Tags = mergeMaps(m.Tags, other.Tags)
+}
+
+func mergeMaps(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	merged := make(map[string]string, len(dst)+len(src))
+	for k, v := range dst {
+		merged[k] = v
+	}
+	for k, v := range src {
+		merged[k] = v
+	}
+	return merged
+}
+
+// putOptions returns the options for uploading an object with the metadata
+func (m objectMetadata) putOptions() minio.PutObjectOptions {
+	return minio.PutObjectOptions{
+		ContentType:        m.ContentType,
+		CacheControl:       m.CacheControl,
+		ContentDisposition: m.ContentDisposition,
+		ContentEncoding:    m.ContentEncoding,
+		StorageClass:       m.StorageClass,
+		UserMetadata:       m.Metadata,
+		UserTags:           m.Tags,
+	}
+}
+
+// matches reports whether the rule applies to a file with the content type
+func (r *metadataRule) matches(filename, contentType string) bool {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	for _, want := range r.Extensions {
+		if ext != "" && strings.EqualFold(strings.TrimPrefix(want, "."), ext) {
+			return true
+		}
+	}
+	// Parameters such as "; charset=utf-8" are ignored
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	for _, want := range r.ContentTypes {
+		if strings.HasSuffix(want, "/*") {
+			if strings.HasPrefix(mediaType, strings.TrimSuffix(want, "*")) {
+				return true
+			}
+		} else if strings.EqualFold(want, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadMetadataRules reads the rules file at path. Without a path the default
+// rules file of the config directory is read if it exists.
+func loadMetadataRules(path string) ([]metadataRule, error) {
+	if path == "" {
+		dir, err := config.Dir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(dir, DefaultRulesFile)
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	var file rulesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", path, err)
+	}
+	for i, rule := range file.Rules {
+		if len(rule.Extensions) == 0 && len(rule.ContentTypes) == 0 {
+			return nil, fmt.Errorf("rule %d of %s matches no files, set extensions or contentTypes", i+1, path)
+		}
+		if err := validateMetadata(rule.objectMetadata); err != nil {
+			return nil, fmt.Errorf("rule %d of %s: %w", i+1, path, err)
+		}
+	}
+	return file.Rules, nil
+}
+
+// validateMetadata checks user metadata keys and tags for characters that
+// cannot be sent as headers
+func validateMetadata(m objectMetadata) error {
+	for key := range m.Metadata {
+		if key == "" || strings.ContainsAny(key, " :\t\r\n") {
+			return fmt.Errorf("invalid metadata key %q", key)
+		}
+	}
+	if len(m.Tags) > 10 {
+		return errors.New("objects can have at most 10 tags")
+	}
+	for key := range m.Tags {
+		if key == "" {
+			return errors.New("tag keys must not be empty")
+		}
+	}
+	return nil
+}
+
+// parseKeyValues parses repeated key=value flag values
+func parseKeyValues(flag string, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --%s %q, expected key=value", flag, value)
+		}
+		parsed[key] = val
+	}
+	return parsed, nil
+}
+
+// uploadMetadata resolves the metadata of every uploaded file: the detected
+// content type, then the matching rules in order, then the flags
+type uploadMetadata struct {
+	rules []metadataRule
+	flags objectMetadata
+}
+
+func newUploadMetadata(opts *UploadOptions) (*uploadMetadata, error) {
+	meta, err := parseKeyValues("meta", opts.Meta)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := parseKeyValues("tag", opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	flags := objectMetadata{
+		ContentType:        opts.ContentType,
+		CacheControl:       opts.CacheControl,
+		ContentDisposition: opts.ContentDisposition,
+		ContentEncoding:    opts.ContentEncoding,
+		StorageClass:       opts.StorageClass,
+		Metadata:           meta,
+		Tags:               tags,
+	}
+	if err := validateMetadata(flags); err != nil {
+		return nil, err
+	}
+
+	rules, err := loadMetadataRules(opts.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+	return &uploadMetadata{rules: rules, flags: flags}, nil
+}
+
+// For returns the metadata of the named file, a nil uploadMetadata only
+// detects the content type
+func (u *uploadMetadata) For(filename string) objectMetadata {
+	return u.forContentType(filename, getContentType(filename))
+}
+
+// ForStream returns the metadata of a stream named name starting with header
+func (u *uploadMetadata) ForStream(name string, header []byte) objectMetadata {
+	return u.forContentType(name, util.DetectContentTypeOf(name, header))
+}
+
+func (u *uploadMetadata) forContentType(filename, contentType string) objectMetadata {
+	m := objectMetadata{ContentType: contentType}
+	if u == nil {
+		return m
+	}
+	for i := range u.rules {
+		if u.rules[i].matches(filename, m.ContentType) {
+			m.merge(u.rules[i].objectMetadata)
+		}
+	}
+	m.merge(u.flags)
+	return m
+}
@@ -0,0 +1,162 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+const testRules = `rules:
+  - extensions: [png, .JPG]
+    cacheControl: public, max-age=31536000, immutable
+    tags:
+      kind: image
+  - contentTypes: [text/*]
+    cacheControl: no-cache
+    contentDisposition: inline
+  - extensions: [txt]
+    metadata:
+      source: rules
+`
+
+func writeRulesFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create rules file: %v", err)
+	}
+	return path
+}
+
+func TestUploadMetadataFor(t *testing.T) {
+	opts := &UploadOptions{
+		RulesFile: writeRulesFile(t, testRules),
+		Meta:      []string{"author=alice"},
+		Tags:      []string{"kind=screenshot", "team=web"},
+	}
+	metadata, err := newUploadMetadata(opts)
+	if err != nil {
+		t.Fatalf("newUploadMetadata() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		flags    objectMetadata
+		want     objectMetadata
+	}{
+		{
+			name:     "image rule",
+			filename: "photo.PNG",
+			want: objectMetadata{
+				ContentType:  "image/png",
+				CacheControl: "public, max-age=31536000, immutable",
+				Metadata:     map[string]string{"author": "alice"},
+				Tags:         map[string]string{"kind": "screenshot", "team": "web"},
+			},
+		},
+		{
+			name:     "later rules add to earlier ones",
+			filename: "notes.txt",
+			want: objectMetadata{
+				ContentType:        "text/plain",
+				CacheControl:       "no-cache",
+				ContentDisposition: "inline",
+				Metadata:           map[string]string{"source": "rules", "author": "alice"},
+				Tags:               map[string]string{"kind": "screenshot", "team": "web"},
+			},
+		},
+		{
+			name:     "no rule",
+			filename: "archive.zip",
+			want: objectMetadata{
+				ContentType: "application/zip",
+				Metadata:    map[string]string{"author": "alice"},
+				Tags:        map[string]string{"kind": "screenshot", "team": "web"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metadata.For(tt.filename); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("For(%s) = %+v, want %+v", tt.filename, got, tt.want)
+			}
+		})
+	}
+
+	// Flags override rules
+	opts.CacheControl = "max-age=60"
+	metadata, err = newUploadMetadata(opts)
+	if err != nil {
+		t.Fatalf("newUploadMetadata() unexpected error: %v", err)
+	}
+	if got := metadata.For("photo.png").CacheControl; got != "max-age=60" {
+		t.Errorf("Cache-Control = %q, want the flag value", got)
+	}
+}
+
+func TestNewUploadMetadataErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    UploadOptions
+		rules   string
+		wantErr string
+	}{
+		{name: "meta without value", opts: UploadOptions{Meta: []string{"author"}}, wantErr: "expected key=value"},
+		{name: "tag without key", opts: UploadOptions{Tags: []string{"=x"}}, wantErr: "invalid --tag"},
+		{name: "invalid meta key", opts: UploadOptions{Meta: []string{"my key=x"}}, wantErr: "invalid metadata key"},
+		{name: "missing rules file", opts: UploadOptions{RulesFile: "/nonexistent/rules.yaml"}, wantErr: "failed to read rules file"},
+		{name: "rule without match", rules: "rules:\n  - cacheControl: x\n", wantErr: "matches no files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if tt.rules != "" {
+				opts.RulesFile = writeRulesFile(t, tt.rules)
+			}
+			if _, err := newUploadMetadata(&opts); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newUploadMetadata() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunUploadMetadata(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{
+		Config:             cfg,
+		KeyTemplate:        "{basename}.{ext}",
+		CacheControl:       "max-age=3600",
+		ContentDisposition: "attachment",
+		ContentEncoding:    "identity",
+		StorageClass:       "REDUCED_REDUNDANCY",
+		Meta:               []string{"author=alice"},
+		Tags:               []string{"project=apollo"},
+		RulesFile:          writeRulesFile(t, "rules: []\n"),
+	}
+
+	if err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1)); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+	header := fake.header("file0.txt")
+	for name, want := range map[string]string{
+		"Cache-Control":       "max-age=3600",
+		"Content-Disposition": "attachment",
+		"Content-Encoding":    "identity",
+		"X-Amz-Storage-Class": "REDUCED_REDUNDANCY",
+		"X-Amz-Meta-Author":   "alice",
+		"X-Amz-Tagging":       "project=apollo",
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf("stored %s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package minio
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/gogodjzhu/gogobox/pkg/cmd/secrets"
 	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
 	"github.com/spf13/cobra"
 )
 
@@ -24,52 +32,186 @@ download, and bucket management. Use subcommands to perform specific operations.
 
 	// Add subcommands
 	cmd.AddCommand(NewCmdMinIOUpload(f))
+	cmd.AddCommand(NewCmdMinIODownload(f))
+	cmd.AddCommand(NewCmdMinIOList(f))
+	cmd.AddCommand(NewCmdMinIOCopy(f))
+	cmd.AddCommand(NewCmdMinIOMove(f))
+	cmd.AddCommand(NewCmdMinIORemove(f))
+	cmd.AddCommand(NewCmdMinIOStat(f))
+	cmd.AddCommand(NewCmdMinIOCat(f))
+	cmd.AddCommand(NewCmdMinIOSync(f))
+	cmd.AddCommand(NewCmdMinIOShare(f))
+	cmd.AddCommand(NewCmdMinIOMakeBucket(f))
+	cmd.AddCommand(NewCmdMinIORemoveBucket(f))
+	cmd.AddCommand(NewCmdMinIOPolicy(f))
+	cmd.AddCommand(NewCmdMinIOLifecycle(f))
+	cmd.AddCommand(NewCmdMinIOVersioning(f))
+	cmd.AddCommand(NewCmdMinIOProfile(f))
+	cmd.AddCommand(NewCmdMinIOServeFake(f))
 
 	return cmd
 }
 
 // MinIOConfig represents the configuration for MinIO operations
 type MinIOConfig struct {
-	// Endpoint is the MinIO server endpoint (e.g., "localhost:9000" or "s3.amazonaws.com")
+	// Backend selects the storage: "s3" (the default), "local" or "webdav".
+	// Only upload, download, ls and sync support the local and WebDAV
+	// backends.
+	Backend string `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	// Endpoint is the MinIO server endpoint (e.g., "localhost:9000" or
+	// "s3.amazonaws.com"), the directory holding the buckets of the local
+	// backend or the URL of the WebDAV backend (e.g.,
+	// "https://dav.example.com/files")
 	Endpoint string `json:"endpoint" yaml:"endpoint"`
 
-	// AccessKeyID is the access key for MinIO authentication
+	// AccessKeyID is the access key for MinIO authentication. Without access
+	// key the credentials are looked up from the credential chain.
 	AccessKeyID string `json:"accessKeyID" yaml:"accessKeyID"`
 
 	// SecretAccessKey is the secret key for MinIO authentication
-	SecretAccessKey string `json:"secretAccessKey" yaml:"secretAccessKey"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty" yaml:"secretAccessKey,omitempty"`
+
+	// SecretRef names the entry of the gogobox secrets store holding the
+	// secret key, used when SecretAccessKey is empty
+	SecretRef string `json:"secretRef,omitempty" yaml:"secretRef,omitempty"`
 
 	// BucketName is the name of the bucket to upload files to
 	BucketName string `json:"bucketName" yaml:"bucketName"`
 
 	// UseSSL indicates whether to use HTTPS for connection
 	UseSSL bool `json:"useSSL" yaml:"useSSL"`
+
+	// Provider selects the preset of a storage provider (aws, r2,
+	// aliyun-oss, tencent-cos or minio), which fills the settings below that
+	// are not configured
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+
+	// Region is the region requests are signed for and new buckets are
+	// created in (looked up from the server if empty)
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+
+	// BucketLookup selects how requests address the bucket: "auto" (the
+	// default), "path" or "virtual-host"
+	BucketLookup string `json:"bucketLookup,omitempty" yaml:"bucketLookup,omitempty"`
+
+	// SignatureVersion is the AWS signature version of requests: "v4" (the
+	// default) or "v2"
+	SignatureVersion string `json:"signatureVersion,omitempty" yaml:"signatureVersion,omitempty"`
+
+	// URLStyle selects how public object URLs address the bucket: "path"
+	// (endpoint/bucket/key, the default) or "virtual-host" (bucket.endpoint/key)
+	URLStyle string `json:"urlStyle,omitempty" yaml:"urlStyle,omitempty"`
+
+	// BaseURL overrides URLStyle with a template for public URLs, e.g.
+	// "https://cdn.example.com/{key}". {bucket} is replaced by the bucket name
+	// and the key is appended if {key} is missing.
+	BaseURL string `json:"baseURL,omitempty" yaml:"baseURL,omitempty"`
 }
 
+// Public URL styles
+const (
+	URLStylePath        = "path"
+	URLStyleVirtualHost = "virtual-host"
+)
+
 // Validate checks if the MinIO configuration is valid
 func (c *MinIOConfig) Validate() error {
 	if c.Endpoint == "" {
 		return errors.New("endpoint must not be empty")
 	}
-	if c.AccessKeyID == "" {
-		return errors.New("accessKeyID must not be empty")
+	if c.AccessKeyID == "" && c.SecretAccessKey != "" {
+		return errors.New("accessKeyID must not be empty when secretAccessKey is set")
 	}
-	if c.SecretAccessKey == "" {
-		return errors.New("secretAccessKey must not be empty")
+	if c.AccessKeyID != "" && c.SecretAccessKey == "" {
+		return errors.New("secretAccessKey must not be empty (set it or reference a secret with secretRef)")
 	}
 	if c.BucketName == "" {
 		return errors.New("bucketName must not be empty")
 	}
+	switch c.Backend {
+	case "", BackendS3:
+	case BackendLocal, BackendWebDAV:
+		if c.Provider != "" {
+			return fmt.Errorf("provider only applies to the %s backend", BackendS3)
+		}
+		if strings.ContainsAny(c.BucketName, `/\`) {
+			return fmt.Errorf("bucketName must be a single path segment, got %s", c.BucketName)
+		}
+	default:
+		return fmt.Errorf("backend must be %s, %s or %s, got %s", BackendS3, BackendLocal, BackendWebDAV, c.Backend)
+	}
+	if c.Backend == BackendWebDAV {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("endpoint of the %s backend must be an http or https URL, got %s", BackendWebDAV, c.Endpoint)
+		}
+	}
+	if _, ok := providerPresets[c.Provider]; c.Provider != "" && !ok {
+		return fmt.Errorf("provider must be one of %s, got %s", strings.Join(providerNames(), ", "), c.Provider)
+	}
+	switch c.URLStyle {
+	case "", URLStylePath, URLStyleVirtualHost:
+	default:
+		return fmt.Errorf("urlStyle must be %s or %s, got %s", URLStylePath, URLStyleVirtualHost, c.URLStyle)
+	}
+	switch c.BucketLookup {
+	case "", BucketLookupAuto, URLStylePath, URLStyleVirtualHost:
+	default:
+		return fmt.Errorf("bucketLookup must be %s, %s or %s, got %s", BucketLookupAuto, URLStylePath, URLStyleVirtualHost, c.BucketLookup)
+	}
+	switch c.SignatureVersion {
+	case "", SignatureV4, SignatureV2:
+	default:
+		return fmt.Errorf("signatureVersion must be %s or %s, got %s", SignatureV4, SignatureV2, c.SignatureVersion)
+	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(strings.NewReplacer("{key}", "", "{bucket}", c.BucketName).Replace(c.BaseURL)); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("baseURL must be an absolute URL template like https://cdn.example.com/{key}, got %s", c.BaseURL)
+		}
+	}
 	return nil
 }
 
-// GetObjectURL returns the public URL for an object in the bucket
+// GetObjectURL returns the public URL for an object in the bucket, following
+// BaseURL or URLStyle
 func (c *MinIOConfig) GetObjectURL(objectName string) string {
+	key := escapeObjectName(objectName)
+	if c.BaseURL != "" {
+		base := strings.ReplaceAll(c.BaseURL, "{bucket}", c.BucketName)
+		if strings.Contains(base, "{key}") {
+			return strings.ReplaceAll(base, "{key}", key)
+		}
+		return strings.TrimSuffix(base, "/") + "/" + key
+	}
+
+	switch c.Backend {
+	case BackendLocal:
+		name, err := filepath.Abs(filepath.Join(c.Endpoint, c.BucketName, filepath.FromSlash(objectName)))
+		if err != nil {
+			name = filepath.Join(c.Endpoint, c.BucketName, filepath.FromSlash(objectName))
+		}
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(name)}).String()
+	case BackendWebDAV:
+		return strings.TrimSuffix(c.Endpoint, "/") + "/" + url.PathEscape(c.BucketName) + "/" + key
+	}
+
 	protocol := "http"
 	if c.UseSSL {
 		protocol = "https"
 	}
-	return fmt.Sprintf("%s://%s/%s/%s", protocol, c.Endpoint, c.BucketName, objectName)
+	if c.URLStyle == URLStyleVirtualHost {
+		return fmt.Sprintf("%s://%s.%s/%s", protocol, c.BucketName, c.Endpoint, key)
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", protocol, c.Endpoint, c.BucketName, key)
+}
+
+// escapeObjectName escapes the segments of an object key for use in a URL path
+func escapeObjectName(objectName string) string {
+	segments := strings.Split(objectName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
 }
 
 // NewDefaultConfig returns a new MinIOConfig with default values
@@ -78,3 +220,215 @@ func NewDefaultConfig() *MinIOConfig {
 		UseSSL: false,
 	}
 }
+
+// Environment variables overriding the profile settings
+const (
+	EnvProfile      = "GOGOBOX_MINIO_PROFILE"
+	EnvBackend      = "GOGOBOX_MINIO_BACKEND"
+	EnvEndpoint     = "GOGOBOX_MINIO_ENDPOINT"
+	EnvAccessKey    = "GOGOBOX_MINIO_ACCESS_KEY"
+	EnvSecretKey    = "GOGOBOX_MINIO_SECRET_KEY"
+	EnvSecretRef    = "GOGOBOX_MINIO_SECRET_REF"
+	EnvBucket       = "GOGOBOX_MINIO_BUCKET"
+	EnvSSL          = "GOGOBOX_MINIO_SSL"
+	EnvProvider     = "GOGOBOX_MINIO_PROVIDER"
+	EnvRegion       = "GOGOBOX_MINIO_REGION"
+	EnvBucketLookup = "GOGOBOX_MINIO_BUCKET_LOOKUP"
+	EnvSignature    = "GOGOBOX_MINIO_SIGNATURE"
+	EnvURLStyle     = "GOGOBOX_MINIO_URL_STYLE"
+	EnvBaseURL      = "GOGOBOX_MINIO_BASE_URL"
+)
+
+// addConnectionFlags registers the MinIO connection flags shared by all subcommands
+func addConnectionFlags(cmd *cobra.Command, cfg *MinIOConfig) {
+	addConfigFlags(cmd, cfg)
+	cmd.Flags().String("profile", "", "Connection profile to use (see 'gogobox minio profile')")
+}
+
+// addConfigFlags registers one flag per MinIOConfig setting
+func addConfigFlags(cmd *cobra.Command, cfg *MinIOConfig) {
+	cmd.Flags().StringVar(&cfg.Backend, "backend", "", "Storage backend: s3, local or webdav (default s3)")
+	cmd.Flags().StringVarP(&cfg.Endpoint, "endpoint", "e", "", "MinIO server endpoint")
+	cmd.Flags().StringVarP(&cfg.AccessKeyID, "access-key", "a", "", "MinIO access key ID (credentials come from the environment, ~/.aws or IAM if unset)")
+	cmd.Flags().StringVarP(&cfg.SecretAccessKey, "secret-key", "s", "", "MinIO secret access key")
+	cmd.Flags().StringVar(&cfg.SecretRef, "secret-ref", "", "Name of the secret holding the secret access key (see 'gogobox secrets')")
+	cmd.Flags().StringVarP(&cfg.BucketName, "bucket", "b", "", "MinIO bucket name")
+	cmd.Flags().BoolVar(&cfg.UseSSL, "ssl", false, "Use SSL/TLS connection")
+	cmd.Flags().StringVar(&cfg.Provider, "provider", "", "Storage provider preset: aws, r2, aliyun-oss, tencent-cos or minio")
+	cmd.Flags().StringVar(&cfg.Region, "region", "", "Region of the bucket (looked up from the server by default)")
+	cmd.Flags().StringVar(&cfg.BucketLookup, "bucket-lookup", "", "How requests address the bucket: auto, path or virtual-host (default auto)")
+	cmd.Flags().StringVar(&cfg.SignatureVersion, "signature", "", "Signature version of requests: v4 or v2 (default v4)")
+	cmd.Flags().StringVar(&cfg.URLStyle, "url-style", "", "Style of public URLs: path or virtual-host (default path)")
+	cmd.Flags().StringVar(&cfg.BaseURL, "base-url", "", "Template for public URLs, e.g. https://cdn.example.com/{key}")
+}
+
+// resolveConfig replaces cfg, which holds the flag values of cmd, with the
+// effective configuration merged with the precedence flag > env > profile,
+// completed by the provider preset.
+// The profile is taken from --profile, $GOGOBOX_MINIO_PROFILE or the current
+// profile, in that order. A secret key referenced by SecretRef is read from
+// the secrets store, which may prompt for its passphrase.
+func resolveConfig(f *cmdutil.Factory, cmd *cobra.Command, cfg *MinIOConfig) error {
+	merged := NewDefaultConfig()
+
+	profileName, _ := cmd.Flags().GetString("profile")
+	if profileName == "" {
+		profileName = os.Getenv(EnvProfile)
+	}
+
+	pc, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	if profileName == "" {
+		profileName = pc.CurrentProfile
+	}
+	if profileName != "" {
+		profile, err := pc.Get(profileName)
+		if err != nil {
+			return err
+		}
+		*merged = *profile
+	}
+
+	if err := applyEnvConfig(merged); err != nil {
+		return err
+	}
+	applyFlagConfig(cmd, cfg, merged)
+	if err := merged.ApplyProvider(); err != nil {
+		return err
+	}
+
+	if merged.SecretAccessKey == "" && merged.SecretRef != "" {
+		secret, err := secrets.Lookup(f.IOStreams, merged.SecretRef)
+		if err != nil {
+			return err
+		}
+		merged.SecretAccessKey = secret
+	}
+
+	*cfg = *merged
+	return nil
+}
+
+// applyEnvConfig overrides the settings of cfg from the environment
+func applyEnvConfig(cfg *MinIOConfig) error {
+	if v, ok := os.LookupEnv(EnvBackend); ok {
+		cfg.Backend = v
+	}
+	if v, ok := os.LookupEnv(EnvEndpoint); ok {
+		cfg.Endpoint = v
+	}
+	if v, ok := os.LookupEnv(EnvAccessKey); ok {
+		cfg.AccessKeyID = v
+	}
+	if v, ok := os.LookupEnv(EnvSecretKey); ok {
+		cfg.SecretAccessKey = v
+	}
+	if v, ok := os.LookupEnv(EnvSecretRef); ok {
+		cfg.SecretRef = v
+	}
+	if v, ok := os.LookupEnv(EnvBucket); ok {
+		cfg.BucketName = v
+	}
+	if v, ok := os.LookupEnv(EnvSSL); ok {
+		useSSL, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvSSL, err)
+		}
+		cfg.UseSSL = useSSL
+	}
+	if v, ok := os.LookupEnv(EnvProvider); ok {
+		cfg.Provider = v
+	}
+	if v, ok := os.LookupEnv(EnvRegion); ok {
+		cfg.Region = v
+	}
+	if v, ok := os.LookupEnv(EnvBucketLookup); ok {
+		cfg.BucketLookup = v
+	}
+	if v, ok := os.LookupEnv(EnvSignature); ok {
+		cfg.SignatureVersion = v
+	}
+	if v, ok := os.LookupEnv(EnvURLStyle); ok {
+		cfg.URLStyle = v
+	}
+	if v, ok := os.LookupEnv(EnvBaseURL); ok {
+		cfg.BaseURL = v
+	}
+	return nil
+}
+
+// applyFlagConfig copies the settings explicitly given on the command line
+// from flagCfg to cfg
+func applyFlagConfig(cmd *cobra.Command, flagCfg, cfg *MinIOConfig) {
+	flags := cmd.Flags()
+	if flags.Changed("backend") {
+		cfg.Backend = flagCfg.Backend
+	}
+	if flags.Changed("endpoint") {
+		cfg.Endpoint = flagCfg.Endpoint
+	}
+	if flags.Changed("access-key") {
+		cfg.AccessKeyID = flagCfg.AccessKeyID
+	}
+	if flags.Changed("secret-key") {
+		cfg.SecretAccessKey = flagCfg.SecretAccessKey
+	}
+	if flags.Changed("secret-ref") {
+		cfg.SecretRef = flagCfg.SecretRef
+	}
+	if flags.Changed("bucket") {
+		cfg.BucketName = flagCfg.BucketName
+	}
+	if flags.Changed("ssl") {
+		cfg.UseSSL = flagCfg.UseSSL
+	}
+	if flags.Changed("provider") {
+		cfg.Provider = flagCfg.Provider
+	}
+	if flags.Changed("region") {
+		cfg.Region = flagCfg.Region
+	}
+	if flags.Changed("bucket-lookup") {
+		cfg.BucketLookup = flagCfg.BucketLookup
+	}
+	if flags.Changed("signature") {
+		cfg.SignatureVersion = flagCfg.SignatureVersion
+	}
+	if flags.Changed("url-style") {
+		cfg.URLStyle = flagCfg.URLStyle
+	}
+	if flags.Changed("base-url") {
+		cfg.BaseURL = flagCfg.BaseURL
+	}
+}
+
+// newClient creates a MinIO client and makes sure the configured bucket exists
+func newClient(ctx context.Context, cfg *MinIOConfig) (*minio.Client, error) {
+	client, err := newS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket '%s' does not exist (create it with 'gogobox minio mb %s')", cfg.BucketName, cfg.BucketName)
+	}
+	return client, nil
+}
+
+// newS3Client creates a MinIO client without checking the bucket
+func newS3Client(cfg *MinIOConfig) (*minio.Client, error) {
+	if err := requireS3Backend(cfg, "this command"); err != nil {
+		return nil, err
+	}
+	client, err := minio.New(cfg.Endpoint, cfg.clientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,313 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestGetObjectURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    MinIOConfig
+		object string
+		want   string
+	}{
+		{
+			name:   "path style",
+			cfg:    MinIOConfig{Endpoint: "localhost:9000", BucketName: "images"},
+			object: "202401/a.png",
+			want:   "http://localhost:9000/images/202401/a.png",
+		},
+		{
+			name:   "virtual host style",
+			cfg:    MinIOConfig{Endpoint: "s3.amazonaws.com", BucketName: "images", UseSSL: true, URLStyle: URLStyleVirtualHost},
+			object: "a.png",
+			want:   "https://images.s3.amazonaws.com/a.png",
+		},
+		{
+			name:   "base URL with key placeholder",
+			cfg:    MinIOConfig{Endpoint: "localhost:9000", BucketName: "images", BaseURL: "https://cdn.example.com/{bucket}/{key}?v=1"},
+			object: "a.png",
+			want:   "https://cdn.example.com/images/a.png?v=1",
+		},
+		{
+			name:   "base URL without placeholder",
+			cfg:    MinIOConfig{Endpoint: "localhost:9000", BucketName: "images", BaseURL: "https://cdn.example.com/static/", URLStyle: URLStyleVirtualHost},
+			object: "a.png",
+			want:   "https://cdn.example.com/static/a.png",
+		},
+		{
+			name:   "escaped key",
+			cfg:    MinIOConfig{Endpoint: "localhost:9000", BucketName: "images"},
+			object: "my photos/a#1?.png",
+			want:   "http://localhost:9000/images/my%20photos/a%231%3F.png",
+		},
+		{
+			name:   "local backend",
+			cfg:    MinIOConfig{Backend: BackendLocal, Endpoint: "/srv/objects", BucketName: "images"},
+			object: "my photos/a.png",
+			want:   "file:///srv/objects/images/my%20photos/a.png",
+		},
+		{
+			name:   "webdav backend",
+			cfg:    MinIOConfig{Backend: BackendWebDAV, Endpoint: "https://dav.example.com/files/", BucketName: "images"},
+			object: "my photos/a.png",
+			want:   "https://dav.example.com/files/images/my%20photos/a.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.GetObjectURL(tt.object); got != tt.want {
+				t.Errorf("GetObjectURL(%q) = %s, want %s", tt.object, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinIOConfigValidate(t *testing.T) {
+	valid := MinIOConfig{Endpoint: "localhost:9000", AccessKeyID: "key", SecretAccessKey: "secret", BucketName: "bucket"}
+
+	tests := []struct {
+		name    string
+		modify  func(c *MinIOConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(c *MinIOConfig) {}},
+		{name: "virtual host", modify: func(c *MinIOConfig) { c.URLStyle = URLStyleVirtualHost }},
+		{name: "base URL", modify: func(c *MinIOConfig) { c.BaseURL = "https://cdn.example.com/{key}" }},
+		{name: "missing endpoint", modify: func(c *MinIOConfig) { c.Endpoint = "" }, wantErr: "endpoint"},
+		{name: "unknown URL style", modify: func(c *MinIOConfig) { c.URLStyle = "subdomain" }, wantErr: "urlStyle"},
+		{name: "relative base URL", modify: func(c *MinIOConfig) { c.BaseURL = "cdn.example.com/{key}" }, wantErr: "baseURL"},
+		{name: "provider", modify: func(c *MinIOConfig) {
+			c.Provider = ProviderR2
+			c.BucketLookup = URLStylePath
+			c.SignatureVersion = SignatureV4
+		}},
+		{name: "credential chain", modify: func(c *MinIOConfig) { c.AccessKeyID, c.SecretAccessKey = "", "" }},
+		{name: "missing secret key", modify: func(c *MinIOConfig) { c.SecretAccessKey = "" }, wantErr: "secretAccessKey"},
+		{name: "missing access key", modify: func(c *MinIOConfig) { c.AccessKeyID = "" }, wantErr: "accessKeyID"},
+		{name: "unknown provider", modify: func(c *MinIOConfig) { c.Provider = "gcs" }, wantErr: "provider"},
+		{name: "unknown bucket lookup", modify: func(c *MinIOConfig) { c.BucketLookup = "dns" }, wantErr: "bucketLookup"},
+		{name: "unknown signature", modify: func(c *MinIOConfig) { c.SignatureVersion = "v3" }, wantErr: "signatureVersion"},
+		{name: "local backend", modify: func(c *MinIOConfig) { c.Backend, c.Endpoint = BackendLocal, "/srv/objects" }},
+		{name: "webdav backend", modify: func(c *MinIOConfig) { c.Backend, c.Endpoint = BackendWebDAV, "https://dav.example.com/files" }},
+		{name: "unknown backend", modify: func(c *MinIOConfig) { c.Backend = "sftp" }, wantErr: "backend"},
+		{name: "webdav endpoint without scheme", modify: func(c *MinIOConfig) { c.Backend = BackendWebDAV }, wantErr: "http or https URL"},
+		{name: "provider of local backend", modify: func(c *MinIOConfig) { c.Backend, c.Provider = BackendLocal, ProviderAWS }, wantErr: "provider"},
+		{name: "nested local bucket", modify: func(c *MinIOConfig) { c.Backend, c.BucketName = BackendLocal, "a/b" }, wantErr: "single path segment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     MinIOConfig
+		want    MinIOConfig
+		wantErr string
+	}{
+		{
+			name: "no provider",
+			cfg:  MinIOConfig{Endpoint: "localhost:9000"},
+			want: MinIOConfig{Endpoint: "localhost:9000"},
+		},
+		{
+			name: "aws default region",
+			cfg:  MinIOConfig{Provider: ProviderAWS},
+			want: MinIOConfig{Provider: ProviderAWS, Endpoint: "s3.amazonaws.com", Region: "us-east-1", UseSSL: true,
+				BucketLookup: URLStyleVirtualHost, URLStyle: URLStyleVirtualHost, SignatureVersion: SignatureV4},
+		},
+		{
+			name: "aws region",
+			cfg:  MinIOConfig{Provider: ProviderAWS, Region: "eu-west-1", URLStyle: URLStylePath},
+			want: MinIOConfig{Provider: ProviderAWS, Endpoint: "s3.eu-west-1.amazonaws.com", Region: "eu-west-1", UseSSL: true,
+				BucketLookup: URLStyleVirtualHost, URLStyle: URLStylePath, SignatureVersion: SignatureV4},
+		},
+		{
+			name: "r2",
+			cfg:  MinIOConfig{Provider: ProviderR2, Endpoint: "acc.r2.cloudflarestorage.com"},
+			want: MinIOConfig{Provider: ProviderR2, Endpoint: "acc.r2.cloudflarestorage.com", Region: "auto", UseSSL: true,
+				BucketLookup: URLStylePath, URLStyle: URLStylePath, SignatureVersion: SignatureV4},
+		},
+		{
+			name: "aliyun oss",
+			cfg:  MinIOConfig{Provider: ProviderAliyunOSS, Region: "cn-hangzhou"},
+			want: MinIOConfig{Provider: ProviderAliyunOSS, Endpoint: "oss-cn-hangzhou.aliyuncs.com", Region: "cn-hangzhou", UseSSL: true,
+				BucketLookup: URLStyleVirtualHost, URLStyle: URLStyleVirtualHost, SignatureVersion: SignatureV2},
+		},
+		{
+			name: "tencent cos",
+			cfg:  MinIOConfig{Provider: ProviderTencentCOS, Region: "ap-guangzhou", SignatureVersion: SignatureV2},
+			want: MinIOConfig{Provider: ProviderTencentCOS, Endpoint: "cos.ap-guangzhou.myqcloud.com", Region: "ap-guangzhou", UseSSL: true,
+				BucketLookup: URLStyleVirtualHost, URLStyle: URLStyleVirtualHost, SignatureVersion: SignatureV2},
+		},
+		{
+			name: "minio",
+			cfg:  MinIOConfig{Provider: ProviderMinIO, Endpoint: "localhost:9000"},
+			want: MinIOConfig{Provider: ProviderMinIO, Endpoint: "localhost:9000",
+				BucketLookup: URLStylePath, URLStyle: URLStylePath, SignatureVersion: SignatureV4},
+		},
+		{name: "missing region", cfg: MinIOConfig{Provider: ProviderAliyunOSS}, wantErr: "region"},
+		{name: "unknown provider", cfg: MinIOConfig{Provider: "gcs"}, wantErr: "unknown provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			err := cfg.ApplyProvider()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ApplyProvider() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyProvider() unexpected error: %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("ApplyProvider() = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewS3ClientSignature(t *testing.T) {
+	tests := []struct {
+		signature string
+		want      string
+	}{
+		{"", "AWS4-HMAC-SHA256 "},
+		{SignatureV4, "AWS4-HMAC-SHA256 "},
+		{SignatureV2, "AWS "},
+	}
+	for _, tt := range tests {
+		var auth, path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth, path = r.Header.Get("Authorization"), r.URL.Path
+		}))
+		cfg := MinIOConfig{
+			Endpoint:         strings.TrimPrefix(server.URL, "http://"),
+			AccessKeyID:      "key",
+			SecretAccessKey:  "secret",
+			Region:           "us-east-1",
+			BucketLookup:     URLStylePath,
+			SignatureVersion: tt.signature,
+		}
+		client, err := newS3Client(&cfg)
+		if err != nil {
+			t.Fatalf("newS3Client() unexpected error: %v", err)
+		}
+		client.BucketExists(context.Background(), "images")
+		server.Close()
+
+		if !strings.HasPrefix(auth, tt.want) {
+			t.Errorf("signature %q: Authorization = %q, want prefix %q", tt.signature, auth, tt.want)
+		}
+		if path != "/images/" {
+			t.Errorf("signature %q: path = %q, want /images/", tt.signature, path)
+		}
+	}
+}
+
+func TestNewS3ClientCredentialChain(t *testing.T) {
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", "envkey")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+
+	tests := []struct {
+		signature string
+		want      string
+	}{
+		{SignatureV4, "AWS4-HMAC-SHA256 Credential=envkey/"},
+		{SignatureV2, "AWS envkey:"},
+	}
+	for _, tt := range tests {
+		var auth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+		}))
+		cfg := MinIOConfig{
+			Endpoint:         strings.TrimPrefix(server.URL, "http://"),
+			Region:           "us-east-1",
+			SignatureVersion: tt.signature,
+		}
+		client, err := newS3Client(&cfg)
+		if err != nil {
+			t.Fatalf("newS3Client() unexpected error: %v", err)
+		}
+		client.BucketExists(context.Background(), "images")
+		server.Close()
+
+		if !strings.HasPrefix(auth, tt.want) {
+			t.Errorf("signature %s: Authorization = %q, want prefix %q", tt.signature, auth, tt.want)
+		}
+	}
+}
+
+// countingProvider counts how often the credentials are retrieved
+type countingProvider struct {
+	value     credentials.Value
+	retrieved int
+}
+
+func (p *countingProvider) Retrieve() (credentials.Value, error) {
+	p.retrieved++
+	if p.value.AccessKeyID == "" {
+		return p.value, errors.New("no credentials")
+	}
+	return p.value, nil
+}
+
+func (p *countingProvider) IsExpired() bool { return true }
+
+func TestAnonymousOnceChain(t *testing.T) {
+	// Chains without credentials are asked once
+	empty := &countingProvider{}
+	creds := credentials.New(&anonymousOnceChain{Chain: credentials.Chain{Providers: []credentials.Provider{empty}}})
+	for i := 0; i < 3; i++ {
+		value, err := creds.Get()
+		if err != nil || !value.SignerType.IsAnonymous() {
+			t.Fatalf("Get() = %+v, %v, want anonymous credentials", value, err)
+		}
+	}
+	if empty.retrieved != 1 {
+		t.Errorf("providers without credentials were asked %d times, want once", empty.retrieved)
+	}
+
+	// Expiring credentials are still refreshed
+	expiring := &countingProvider{value: credentials.Value{AccessKeyID: "key", SecretAccessKey: "secret"}}
+	creds = credentials.New(&anonymousOnceChain{Chain: credentials.Chain{Providers: []credentials.Provider{expiring}}})
+	for i := 0; i < 3; i++ {
+		if value, err := creds.Get(); err != nil || value.AccessKeyID != "key" {
+			t.Fatalf("Get() = %+v, %v, want the provider's credentials", value, err)
+		}
+	}
+	if expiring.retrieved != 3 {
+		t.Errorf("expiring credentials were retrieved %d times, want 3", expiring.retrieved)
+	}
+}
@@ -0,0 +1,303 @@
+package minio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// DefaultKeyTemplate reproduces the historical YYYYMM/YYYYMMDD_HHMMSS_<uuid>.<ext> layout
+	DefaultKeyTemplate = "{date:200601}/{date:20060102_150405}_{uuid}.{ext}"
+
+	// KeepPathKeyTemplate keeps the source path relative to the uploaded directory
+	KeepPathKeyTemplate = "{path}"
+)
+
+// Policies for object keys that are already taken
+const (
+	IfExistsError     = "error"
+	IfExistsRename    = "rename"
+	IfExistsOverwrite = "overwrite"
+)
+
+// keyPlaceholders lists the supported template placeholders and whether they
+// take an argument ("{date:<layout>}")
+var keyPlaceholders = map[string]bool{
+	"basename": false,
+	"ext":      false,
+	"path":     false,
+	"dir":      false,
+	"sha256":   false,
+	"uuid":     false,
+	"index":    false,
+	"date":     true,
+}
+
+// keyTemplate is a parsed --key-template
+type keyTemplate struct {
+	raw      string
+	prefix   string
+	segments []keySegment
+}
+
+// keySegment is either a literal or a placeholder with an optional argument
+type keySegment struct {
+	literal     string
+	placeholder string
+	arg         string
+}
+
+// uploadSource is a file to upload together with the path its key is derived
+// from. For files of an uploaded directory RelPath keeps the directory name,
+// e.g. uploading "photos" stores "photos/2024/a.png".
+type uploadSource struct {
+	Path    string
+	RelPath string
+}
+
+// parseKeyTemplate parses and validates a key template, so that mistakes are
+// reported before anything is uploaded. prefix is prepended to every key.
+func parseKeyTemplate(tmpl, prefix string) (*keyTemplate, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return nil, fmt.Errorf("key template must not be empty")
+	}
+
+	t := &keyTemplate{raw: tmpl, prefix: strings.Trim(prefix, "/")}
+	rest := tmpl
+	for rest != "" {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			t.segments = append(t.segments, keySegment{literal: rest})
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("invalid key template %q: unexpected '}'", tmpl)
+		}
+		if start > 0 {
+			t.segments = append(t.segments, keySegment{literal: rest[:start]})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid key template %q: unclosed '{'", tmpl)
+		}
+		name, arg, hasArg := strings.Cut(rest[start+1:start+end], ":")
+		takesArg, ok := keyPlaceholders[name]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("invalid key template %q: unknown placeholder {%s}", tmpl, name)
+		case takesArg && (!hasArg || arg == ""):
+			return nil, fmt.Errorf("invalid key template %q: {%s} needs a layout, e.g. {%s:2006/01/02}", tmpl, name, name)
+		case !takesArg && hasArg:
+			return nil, fmt.Errorf("invalid key template %q: {%s} takes no argument", tmpl, name)
+		}
+		t.segments = append(t.segments, keySegment{placeholder: name, arg: arg})
+		rest = rest[start+end+1:]
+	}
+	return t, nil
+}
+
+// Has reports whether the template uses the placeholder
+func (t *keyTemplate) Has(placeholder string) bool {
+	for _, segment := range t.segments {
+		if segment.placeholder == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// Render returns the object key for the source. uploadPath is the file that
+// is actually uploaded, which differs from source.Path for resized images.
+func (t *keyTemplate) Render(source uploadSource, uploadPath string, index int, now time.Time) (string, error) {
+	base := path.Base(source.RelPath)
+	ext := strings.TrimPrefix(filepath.Ext(uploadPath), ".")
+	if ext == "" {
+		ext = "bin"
+	}
+
+	var key strings.Builder
+	for _, segment := range t.segments {
+		switch segment.placeholder {
+		case "":
+			key.WriteString(segment.literal)
+		case "basename":
+			key.WriteString(strings.TrimSuffix(base, path.Ext(base)))
+		case "ext":
+			key.WriteString(ext)
+		case "path":
+			key.WriteString(source.RelPath)
+		case "dir":
+			if dir := path.Dir(source.RelPath); dir != "." {
+				key.WriteString(dir)
+			}
+		case "sha256":
+			sum, err := fileSHA256(uploadPath)
+			if err != nil {
+				return "", err
+			}
+			key.WriteString(sum)
+		case "uuid":
+			key.WriteString(uuid.NewV4().String())
+		case "index":
+			key.WriteString(strconv.Itoa(index + 1))
+		case "date":
+			key.WriteString(now.Format(segment.arg))
+		}
+	}
+
+	// Empty placeholders ({dir} of a top level file) must not leave empty
+	// path segments behind
+	var parts []string
+	for _, part := range strings.Split(t.prefix+"/"+key.String(), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	objectName := strings.Join(parts, "/")
+	if objectName == "" {
+		return "", fmt.Errorf("key template %q renders an empty key for %s", t.raw, source.Path)
+	}
+	return objectName, nil
+}
+
+// fileSHA256 returns the hex SHA-256 digest of the file's content
+func fileSHA256(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash file %s: %w", filename, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// collectUploadSources expands directory arguments into the regular files
+// below them. Other arguments (files and URLs) are kept as they are.
+func collectUploadSources(args []string) ([]uploadSource, error) {
+	var sources []uploadSource
+	for _, arg := range args {
+		if isRemoteSource(arg) {
+			relPath := "download"
+			if u, err := url.Parse(arg); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+				relPath = path.Base(u.Path)
+			}
+			sources = append(sources, uploadSource{Path: arg, RelPath: relPath})
+			continue
+		}
+
+		stat, err := os.Stat(arg)
+		if err != nil || !stat.IsDir() {
+			// Missing files are reported when they are opened
+			sources = append(sources, uploadSource{Path: arg, RelPath: filepath.Base(arg)})
+			continue
+		}
+
+		root := filepath.Clean(arg)
+		parent := filepath.Dir(root)
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			relPath, err := filepath.Rel(parent, p)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, uploadSource{Path: p, RelPath: filepath.ToSlash(relPath)})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", arg, err)
+		}
+	}
+	return sources, nil
+}
+
+// renderObjectNames renders the key of every source, uploadPaths are the
+// (processed) files that are uploaded for them
+func renderObjectNames(tmpl *keyTemplate, sources []uploadSource, uploadPaths []string) ([]string, error) {
+	now := time.Now()
+	objectNames := make([]string, len(sources))
+	for i, source := range sources {
+		objectName, err := tmpl.Render(source, uploadPaths[i], i, now)
+		if err != nil {
+			return nil, err
+		}
+		objectNames[i] = objectName
+	}
+	return objectNames, nil
+}
+
+// resolveKeyConflicts makes sure no two files of the run get the same key and
+// applies the ifExists policy to keys that are already taken in the bucket.
+// Checking the bucket is skipped for templates containing {uuid}.
+func resolveKeyConflicts(ctx context.Context, store ObjectStore, tmpl *keyTemplate, sources []uploadSource, objectNames []string, ifExists string) error {
+	checkBucket := !tmpl.Has("uuid") && ifExists != IfExistsOverwrite
+	taken := map[string]int{}
+
+	exists := func(objectName string) (bool, error) {
+		if _, ok := taken[objectName]; ok {
+			return true, nil
+		}
+		if !checkBucket {
+			return false, nil
+		}
+		_, err := store.Stat(ctx, objectName, minio.StatObjectOptions{})
+		if err == nil {
+			return true, nil
+		}
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check object %s: %w", objectName, err)
+	}
+
+	for i, objectName := range objectNames {
+		found, err := exists(objectName)
+		if err != nil {
+			return err
+		}
+		if found {
+			if other, ok := taken[objectName]; ok && ifExists != IfExistsRename {
+				return fmt.Errorf("%s and %s would both be uploaded as %s", sources[other].Path, sources[i].Path, objectName)
+			}
+			if ifExists == IfExistsError {
+				return fmt.Errorf("object %s already exists (use --if-exists rename or overwrite)", objectName)
+			}
+		}
+
+		if found && ifExists == IfExistsRename {
+			// photo.png -> photo-1.png, photo-2.png, ...
+			ext := path.Ext(path.Base(objectName))
+			stem := strings.TrimSuffix(objectName, ext)
+			for n := 1; found; n++ {
+				objectNames[i] = fmt.Sprintf("%s-%d%s", stem, n, ext)
+				if found, err = exists(objectNames[i]); err != nil {
+					return err
+				}
+			}
+		}
+		taken[objectNames[i]] = i
+	}
+	return nil
+}
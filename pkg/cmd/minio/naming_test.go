@@ -0,0 +1,211 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestParseKeyTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr string
+	}{
+		{name: "empty", tmpl: " ", wantErr: "must not be empty"},
+		{name: "unknown placeholder", tmpl: "{name}.{ext}", wantErr: "unknown placeholder {name}"},
+		{name: "unclosed", tmpl: "{basename", wantErr: "unclosed '{'"},
+		{name: "stray brace", tmpl: "basename}", wantErr: "unexpected '}'"},
+		{name: "date without layout", tmpl: "{date}/{uuid}", wantErr: "needs a layout"},
+		{name: "argument not allowed", tmpl: "{uuid:short}", wantErr: "takes no argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseKeyTemplate(tt.tmpl, ""); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseKeyTemplate(%q) error = %v, want %q", tt.tmpl, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyTemplateRender(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "photo.png")
+	if err := os.WriteFile(file, []byte("image data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("image data"))
+	now := time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		tmpl       string
+		prefix     string
+		relPath    string
+		uploadPath string
+		want       string
+	}{
+		{name: "basename and ext", tmpl: "{basename}.{ext}", relPath: "photo.png", want: "photo.png"},
+		{name: "date layout", tmpl: "{date:2006/01/02}/{basename}.{ext}", relPath: "photo.png", want: "2024/03/05/photo.png"},
+		{name: "sha256", tmpl: "{sha256}.{ext}", relPath: "photo.png", want: hex.EncodeToString(sum[:]) + ".png"},
+		{name: "index", tmpl: "{index}-{basename}.{ext}", relPath: "photo.png", want: "3-photo.png"},
+		{name: "path of directory upload", tmpl: "{path}", relPath: "album/2024/photo.png", want: "album/2024/photo.png"},
+		{name: "dir of directory upload", tmpl: "{dir}/{sha256}", relPath: "album/photo.png", want: "album/" + hex.EncodeToString(sum[:])},
+		{name: "empty dir is dropped", tmpl: "{dir}/{basename}.{ext}", relPath: "photo.png", want: "photo.png"},
+		{name: "prefix", tmpl: "{path}", prefix: "/backups/2024/", relPath: "album/photo.png", want: "backups/2024/album/photo.png"},
+		{name: "ext of processed file", tmpl: "{basename}.{ext}", relPath: "photo.png", uploadPath: "/tmp/resized_123.jpeg", want: "photo.jpeg"},
+		{name: "missing ext", tmpl: "{basename}.{ext}", relPath: "README", uploadPath: "README", want: "README.bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseKeyTemplate(tt.tmpl, tt.prefix)
+			if err != nil {
+				t.Fatalf("parseKeyTemplate() unexpected error: %v", err)
+			}
+			uploadPath := tt.uploadPath
+			if uploadPath == "" {
+				uploadPath = file
+			}
+			got, err := tmpl.Render(uploadSource{Path: file, RelPath: tt.relPath}, uploadPath, 2, now)
+			if err != nil {
+				t.Fatalf("Render() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectUploadSources(t *testing.T) {
+	dir := t.TempDir()
+	album := filepath.Join(dir, "album")
+	for _, name := range []string{"a.png", "2024/b.png"} {
+		path := filepath.Join(album, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	single := filepath.Join(dir, "single.txt")
+	if err := os.WriteFile(single, []byte("single"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := collectUploadSources([]string{album + "/", single, "https://example.com/img/logo.png?x=1"})
+	if err != nil {
+		t.Fatalf("collectUploadSources() unexpected error: %v", err)
+	}
+
+	want := []uploadSource{
+		{Path: filepath.Join(album, "2024", "b.png"), RelPath: "album/2024/b.png"},
+		{Path: filepath.Join(album, "a.png"), RelPath: "album/a.png"},
+		{Path: single, RelPath: "single.txt"},
+		{Path: "https://example.com/img/logo.png?x=1", RelPath: "logo.png"},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("collectUploadSources() = %+v, want %+v", sources, want)
+	}
+}
+
+func TestResolveKeyConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmpl     string
+		ifExists string
+		keys     []string
+		want     []string
+		wantErr  string
+	}{
+		{name: "no conflicts", tmpl: "{path}", ifExists: IfExistsError, keys: []string{"a.png", "b.png"}, want: []string{"a.png", "b.png"}},
+		{name: "existing object", tmpl: "{path}", ifExists: IfExistsError, keys: []string{"taken.png"}, wantErr: "already exists"},
+		{name: "duplicate in run", tmpl: "{path}", ifExists: IfExistsOverwrite, keys: []string{"a.png", "a.png"}, wantErr: "would both be uploaded as a.png"},
+		{name: "overwrite", tmpl: "{path}", ifExists: IfExistsOverwrite, keys: []string{"taken.png"}, want: []string{"taken.png"}},
+		{name: "rename", tmpl: "{path}", ifExists: IfExistsRename, keys: []string{"taken.png", "a.png", "a.png", "taken.png"}, want: []string{"taken-2.png", "a.png", "a-1.png", "taken-3.png"}},
+		{name: "uuid skips bucket", tmpl: "{uuid}", ifExists: IfExistsError, keys: []string{"taken.png"}, want: []string{"taken.png"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cfg := newFakeS3(t, map[string][]byte{"taken.png": []byte("x"), "taken-1.png": []byte("y")})
+			tmpl, err := parseKeyTemplate(tt.tmpl, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sources := make([]uploadSource, len(tt.keys))
+			for i, key := range tt.keys {
+				sources[i] = uploadSource{Path: key}
+			}
+			keys := append([]string(nil), tt.keys...)
+
+			err = resolveKeyConflicts(context.Background(), newTestStore(t, cfg), tmpl, sources, keys, tt.ifExists)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveKeyConflicts() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveKeyConflicts() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("resolveKeyConflicts() keys = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunUploadKeepPath(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	album := filepath.Join(t.TempDir(), "album")
+	if err := os.MkdirAll(filepath.Join(album, "2024"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "2024/b.txt"} {
+		if err := os.WriteFile(filepath.Join(album, filepath.FromSlash(name)), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{Config: cfg, KeepPath: true, Prefix: "backup", Concurrency: 2}
+	if err := runUpload(context.Background(), f, opts, []string{album}); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+
+	want := []string{"backup/album/2024/b.txt", "backup/album/a.txt"}
+	if keys := fake.keys(); !reflect.DeepEqual(keys, want) {
+		t.Errorf("uploaded keys = %v, want %v", keys, want)
+	}
+
+	// Uploading again must not silently overwrite
+	if err := runUpload(context.Background(), f, opts, []string{album}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("runUpload() second run error = %v, want already exists", err)
+	}
+}
+
+func TestRunUploadInvalidTemplate(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{Config: cfg, KeyTemplate: "{date}/{uuid}"}
+
+	if err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1)); err == nil || !strings.Contains(err.Error(), "needs a layout") {
+		t.Errorf("runUpload() error = %v, want template error", err)
+	}
+	if keys := fake.keys(); len(keys) != 0 {
+		t.Errorf("objects were uploaded with an invalid template: %v", keys)
+	}
+}
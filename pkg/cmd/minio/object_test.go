@@ -0,0 +1,214 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestRunCopy(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     CopyOptions
+		source   string
+		target   string
+		wantKeys []string
+		wantErr  string
+	}{
+		{
+			name:     "single object",
+			source:   "docs/a.txt",
+			target:   "archive/a-2024.txt",
+			wantKeys: []string{"archive/a-2024.txt", "docs/a.txt", "docs/sub/b.txt", "docsx/c.txt"},
+		},
+		{
+			name:     "into directory",
+			source:   "docs/a.txt",
+			target:   "archive/",
+			wantKeys: []string{"archive/a.txt", "docs/a.txt", "docs/sub/b.txt", "docsx/c.txt"},
+		},
+		{
+			name:     "recursive",
+			opts:     CopyOptions{Recursive: true},
+			source:   "docs",
+			target:   "backup",
+			wantKeys: []string{"backup/a.txt", "backup/sub/b.txt", "docs/a.txt", "docs/sub/b.txt", "docsx/c.txt"},
+		},
+		{
+			name:     "move",
+			opts:     CopyOptions{Move: true},
+			source:   "docs/a.txt",
+			target:   "moved.txt",
+			wantKeys: []string{"docs/sub/b.txt", "docsx/c.txt", "moved.txt"},
+		},
+		{
+			name:     "recursive move",
+			opts:     CopyOptions{Move: true, Recursive: true},
+			source:   "docs/",
+			target:   "",
+			wantKeys: []string{"a.txt", "docsx/c.txt", "sub/b.txt"},
+		},
+		{name: "missing source", source: "missing.txt", target: "x.txt", wantErr: "failed to stat object missing.txt"},
+		{name: "empty prefix", opts: CopyOptions{Recursive: true}, source: "none/", target: "x/", wantErr: "no objects below none/"},
+		{name: "onto itself", source: "docs/a.txt", target: "docs/", wantErr: "onto itself"},
+		{name: "missing target bucket", opts: CopyOptions{ToBucket: "other"}, source: "docs/a.txt", target: "a.txt", wantErr: "'other' does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, cfg := newFakeS3(t, map[string][]byte{
+				"docs/a.txt":     []byte("a"),
+				"docs/sub/b.txt": []byte("b"),
+				"docsx/c.txt":    []byte("c"),
+			})
+			fake.PutObject(fake.bucket, "docs/a.txt", []byte("a"), http.Header{"X-Amz-Meta-Author": {"alice"}})
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+			opts := tt.opts
+			opts.Config = cfg
+
+			err := runCopy(context.Background(), f, &opts, tt.source, tt.target)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("runCopy() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runCopy() unexpected error: %v", err)
+			}
+			if keys := fake.keys(); !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("bucket keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if fake.Stats().Puts != 0 {
+				t.Errorf("objects were uploaded instead of copied on the server")
+			}
+		})
+	}
+}
+
+func TestRunRemove(t *testing.T) {
+	objects := func() map[string][]byte {
+		return map[string][]byte{"tmp/a": []byte("a"), "tmp/sub/b": []byte("b"), "tmp-old/c": []byte("c"), "tmpX": []byte("x"), "keep.txt": []byte("k")}
+	}
+
+	t.Run("keys", func(t *testing.T) {
+		fake, cfg := newFakeS3(t, objects())
+		f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+		if err := runRemove(context.Background(), f, &RemoveOptions{Config: cfg}, []string{"tmp/a", "keep.txt"}); err != nil {
+			t.Fatalf("runRemove() unexpected error: %v", err)
+		}
+		if keys := fake.keys(); !reflect.DeepEqual(keys, []string{"tmp-old/c", "tmp/sub/b", "tmpX"}) {
+			t.Errorf("bucket keys = %v", keys)
+		}
+		if err := runRemove(context.Background(), f, &RemoveOptions{Config: cfg}, []string{"tmp/missing"}); err == nil {
+			t.Errorf("runRemove() of a missing key succeeded")
+		}
+	})
+
+	t.Run("prefix needs confirmation", func(t *testing.T) {
+		fake, cfg := newFakeS3(t, objects())
+		f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{In: strings.NewReader(""), Out: &bytes.Buffer{}}}
+		err := runRemove(context.Background(), f, &RemoveOptions{Config: cfg, Recursive: true}, []string{"tmp/"})
+		if err == nil || !strings.Contains(err.Error(), "--yes") {
+			t.Errorf("runRemove() error = %v, want confirmation error", err)
+		}
+		if len(fake.keys()) != 5 {
+			t.Errorf("objects were deleted without confirmation: %v", fake.keys())
+		}
+	})
+
+	t.Run("whole bucket needs yes", func(t *testing.T) {
+		fake, cfg := newFakeS3(t, objects())
+		f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+		err := runRemove(context.Background(), f, &RemoveOptions{Config: cfg, Recursive: true}, []string{""})
+		if err == nil || !strings.Contains(err.Error(), "every object") {
+			t.Errorf("runRemove() error = %v, want --yes error", err)
+		}
+		if len(fake.keys()) != 5 {
+			t.Errorf("objects were deleted without --yes: %v", fake.keys())
+		}
+	})
+
+	t.Run("prefix with yes", func(t *testing.T) {
+		fake, cfg := newFakeS3(t, objects())
+		out := &bytes.Buffer{}
+		f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+		if err := runRemove(context.Background(), f, &RemoveOptions{Config: cfg, Recursive: true, Yes: true}, []string{"tmp", "tmp/sub/"}); err != nil {
+			t.Fatalf("runRemove() unexpected error: %v", err)
+		}
+		if keys := fake.keys(); !reflect.DeepEqual(keys, []string{"keep.txt", "tmp-old/c", "tmpX"}) {
+			t.Errorf("bucket keys = %v", keys)
+		}
+		if !strings.Contains(out.String(), "Deleted 2 objects") {
+			t.Errorf("output = %q, want 2 deleted objects", out.String())
+		}
+	})
+}
+
+func TestRunStat(t *testing.T) {
+	fake, cfg := newFakeS3(t, map[string][]byte{"report.pdf": []byte("%PDF-1.4")})
+	fake.PutObject(fake.bucket, "report.pdf", []byte("%PDF-1.4"), http.Header{
+		"Content-Type":      {"application/pdf"},
+		"Cache-Control":     {"max-age=3600"},
+		"X-Amz-Meta-Author": {"alice"},
+	})
+	fake.SetObjectTagging(fake.bucket, "report.pdf", `<Tagging><TagSet><Tag><Key>project</Key><Value>apollo</Value></Tag></TagSet></Tagging>`)
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+
+	if err := runStat(context.Background(), f, &StatOptions{Config: cfg, Output: OutputJSON}, []string{"report.pdf"}); err != nil {
+		t.Fatalf("runStat() unexpected error: %v", err)
+	}
+	var stat objectStat
+	if err := json.Unmarshal(out.Bytes(), &stat); err != nil {
+		t.Fatalf("runStat() printed invalid JSON %s: %v", out.String(), err)
+	}
+	if stat.Key != "report.pdf" || stat.Size != 8 || stat.ContentType != "application/pdf" {
+		t.Errorf("stat = %+v", stat)
+	}
+	if stat.Metadata["Cache-Control"] != "max-age=3600" {
+		t.Errorf("metadata = %v, want Cache-Control", stat.Metadata)
+	}
+	if !reflect.DeepEqual(stat.UserMetadata, map[string]string{"author": "alice"}) {
+		t.Errorf("user metadata = %v", stat.UserMetadata)
+	}
+	if !reflect.DeepEqual(stat.Tags, map[string]string{"project": "apollo"}) {
+		t.Errorf("tags = %v", stat.Tags)
+	}
+
+	out.Reset()
+	if err := runStat(context.Background(), f, &StatOptions{Config: cfg, Output: OutputText}, []string{"report.pdf"}); err != nil {
+		t.Fatalf("runStat() unexpected error: %v", err)
+	}
+	for _, want := range []string{"Content type:", "application/pdf", "author:", "project:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := runStat(context.Background(), f, &StatOptions{Config: cfg, Output: OutputText}, []string{"missing"}); err == nil {
+		t.Errorf("runStat() of a missing object succeeded")
+	}
+}
+
+func TestRunCat(t *testing.T) {
+	_, cfg := newFakeS3(t, map[string][]byte{"a.txt": []byte("first\n"), "b.txt": []byte("second\n")})
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+
+	if err := runCat(context.Background(), f, &CatOptions{Config: cfg}, []string{"a.txt", "b.txt"}); err != nil {
+		t.Fatalf("runCat() unexpected error: %v", err)
+	}
+	if out.String() != "first\nsecond\n" {
+		t.Errorf("runCat() printed %q", out.String())
+	}
+	if err := runCat(context.Background(), f, &CatOptions{Config: cfg}, []string{"missing.txt"}); err == nil || !strings.Contains(err.Error(), "missing.txt") {
+		t.Errorf("runCat() error = %v, want missing object error", err)
+	}
+}
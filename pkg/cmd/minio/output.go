@@ -0,0 +1,199 @@
+package minio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+)
+
+// OutputTemplate renders a Go template for every item
+const OutputTemplate = "template"
+
+// reasonPresent is the reason of files skipped because their object exists
+const reasonPresent = "already present"
+
+// uploadRecord describes the outcome of one file in the structured upload output
+type uploadRecord struct {
+	Source          string  `json:"source"`
+	Key             string  `json:"key"`
+	URL             string  `json:"url,omitempty"`
+	Status          string  `json:"status"`
+	Reason          string  `json:"reason,omitempty"`
+	OriginalSize    int64   `json:"originalSize"`
+	Size            int64   `json:"size"`
+	ContentType     string  `json:"contentType,omitempty"`
+	ETag            string  `json:"etag,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds"`
+
+	// name is the link text of sources without a file name, such as stdin
+	name string
+}
+
+// validateUploadOutput checks the output format and parses its template
+func validateUploadOutput(opts *UploadOptions) error {
+	switch opts.Output {
+	case "":
+		opts.Output = OutputText
+	case OutputText, OutputJSON, OutputNDJSON, OutputTable:
+	case OutputTemplate:
+		if opts.Template == "" {
+			return errors.New("--output template needs --template")
+		}
+		if _, err := template.New("upload").Parse(opts.Template); err != nil {
+			return fmt.Errorf("invalid --template: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.Output)
+	}
+	if opts.Template != "" {
+		return errors.New("--template requires --output template")
+	}
+	return nil
+}
+
+// uploadRecords describes every object of the run in the order of the
+// sources. Objects missing from results were already present. Uploaded
+// objects are stat'ed for their ETag and stored size, unless only the plain
+// URLs are printed.
+func uploadRecords(ctx context.Context, store ObjectStore, opts *UploadOptions, sources []string, originalSizes []int64, objectNames []string, results []uploadResult) ([]uploadRecord, error) {
+	resultByKey := make(map[string]uploadResult, len(results))
+	for _, result := range results {
+		resultByKey[result.Key] = result
+	}
+
+	records := make([]uploadRecord, len(objectNames))
+	for i, objectName := range objectNames {
+		record := uploadRecord{
+			Source:       sources[i],
+			Key:          objectName,
+			Status:       uploadSkipped,
+			Reason:       reasonPresent,
+			OriginalSize: originalSizes[i],
+		}
+		if result, ok := resultByKey[objectName]; ok {
+			record.Status, record.Reason = result.Status, ""
+			if result.Err != nil {
+				record.Reason = result.Err.Error()
+			}
+			record.DurationSeconds = result.Duration.Seconds()
+		}
+		if record.Status != uploadSucceeded && record.Reason != reasonPresent {
+			records[i] = record
+			continue
+		}
+
+		if opts.PrintURLs {
+			urls, err := objectURLs(ctx, store, opts, []string{objectName})
+			if err != nil {
+				return nil, err
+			}
+			record.URL = urls[0]
+		}
+		if opts.Output != OutputText {
+			info, err := store.Stat(ctx, objectName, opts.encryption.statOptions())
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+			}
+			record.Size = info.Size
+			record.ContentType = info.ContentType
+			record.ETag = info.ETag
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// writeUploadRecords prints the records in opts.Output. The text format
+// prints the URLs (or a summary) of the available objects only, the others
+// describe every file.
+func writeUploadRecords(out io.Writer, opts *UploadOptions, records []uploadRecord) error {
+	switch opts.Output {
+	case OutputJSON:
+		if records == nil {
+			records = []uploadRecord{}
+		}
+		return writeJSON(out, records)
+	case OutputNDJSON:
+		encoder := json.NewEncoder(out)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputTemplate:
+		tmpl, err := template.New("upload").Parse(opts.Template)
+		if err != nil {
+			return fmt.Errorf("invalid --template: %w", err)
+		}
+		for _, record := range records {
+			if err := tmpl.Execute(out, record); err != nil {
+				return fmt.Errorf("failed to render --template: %w", err)
+			}
+			fmt.Fprintln(out)
+		}
+		return nil
+	case OutputTable:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tSOURCE\tKEY\tSIZE\tCONTENT TYPE\tETAG\tDURATION\tURL")
+		for _, record := range records {
+			size := "-"
+			if record.Size > 0 || record.ETag != "" {
+				size = util.HumanSize(record.Size)
+				if record.OriginalSize != record.Size {
+					size = util.HumanSize(record.OriginalSize) + " -> " + size
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%.2fs\t%s\n", record.Status, record.Source, record.Key,
+				size, valueOrDash(record.ContentType), valueOrDash(record.ETag), record.DurationSeconds, valueOrDash(record.URL))
+		}
+		return w.Flush()
+	}
+
+	var available []uploadRecord
+	uploaded := 0
+	for _, record := range records {
+		if record.Status == uploadSucceeded {
+			uploaded++
+		}
+		if record.Status == uploadSucceeded || record.Reason == reasonPresent {
+			available = append(available, record)
+		}
+	}
+	if opts.PrintURLs {
+		fmt.Fprintln(out, "Upload Success:")
+		for _, record := range available {
+			name, image := filepath.Base(record.Source), isImage(record.Source)
+			if record.name != "" {
+				name = record.name
+			}
+			if record.ContentType != "" {
+				image = util.IsImageContentType(record.ContentType)
+			}
+			fmt.Fprintln(out, formatLink(opts.Format, record.URL, name, image))
+		}
+		return nil
+	}
+	fmt.Fprintf(out, "Uploaded %d files successfully", uploaded)
+	if present := len(available) - uploaded; present > 0 {
+		fmt.Fprintf(out, ", %d already present", present)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// valueOrDash returns "-" for empty table cells
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
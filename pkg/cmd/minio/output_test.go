@@ -0,0 +1,136 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestRunUploadOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		template string
+		check    func(t *testing.T, out string)
+	}{
+		{
+			name:   "text",
+			output: OutputText,
+			check: func(t *testing.T, out string) {
+				if !strings.HasPrefix(out, "Upload Success:\n") || !strings.Contains(out, "/test-bucket/file0.txt\n") {
+					t.Errorf("text output = %q", out)
+				}
+			},
+		},
+		{
+			name:   "json",
+			output: OutputJSON,
+			check: func(t *testing.T, out string) {
+				var records []uploadRecord
+				if err := json.Unmarshal([]byte(out), &records); err != nil {
+					t.Fatalf("invalid JSON %s: %v", out, err)
+				}
+				if len(records) != 2 {
+					t.Fatalf("got %d records, want 2", len(records))
+				}
+				record := records[1]
+				if !strings.HasSuffix(record.Source, "file1.txt") || record.Key != "file1.txt" || record.Status != uploadSucceeded {
+					t.Errorf("record = %+v", record)
+				}
+				if record.Size != 9 || record.OriginalSize != 9 || record.ContentType != "text/plain" || record.ETag == "" {
+					t.Errorf("record metadata = %+v", record)
+				}
+				if !strings.HasSuffix(record.URL, "/test-bucket/file1.txt") {
+					t.Errorf("record URL = %s", record.URL)
+				}
+			},
+		},
+		{
+			name:   "ndjson",
+			output: OutputNDJSON,
+			check: func(t *testing.T, out string) {
+				lines := strings.Split(strings.TrimSpace(out), "\n")
+				if len(lines) != 2 {
+					t.Fatalf("got %d lines, want 2:\n%s", len(lines), out)
+				}
+				var record uploadRecord
+				if err := json.Unmarshal([]byte(lines[0]), &record); err != nil || record.Key != "file0.txt" {
+					t.Errorf("first line = %s (%v)", lines[0], err)
+				}
+			},
+		},
+		{
+			name:   "table",
+			output: OutputTable,
+			check: func(t *testing.T, out string) {
+				for _, want := range []string{"STATUS", "ETAG", "success", "file1.txt", "text/plain", "9B"} {
+					if !strings.Contains(out, want) {
+						t.Errorf("table output missing %q:\n%s", want, out)
+					}
+				}
+			},
+		},
+		{
+			name:     "template",
+			output:   OutputTemplate,
+			template: "{{.Key}} {{.Size}} {{.Status}}",
+			check: func(t *testing.T, out string) {
+				if out != "file0.txt 9 success\nfile1.txt 9 success\n" {
+					t.Errorf("template output = %q", out)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cfg := newFakeS3(t, nil)
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+			opts := &UploadOptions{
+				Config:      cfg,
+				PrintURLs:   true,
+				Concurrency: 1,
+				KeyTemplate: "{basename}.{ext}",
+				Output:      tt.output,
+				Template:    tt.template,
+			}
+
+			if err := runUpload(context.Background(), f, opts, writeTestFiles(t, 2)); err != nil {
+				t.Fatalf("runUpload() unexpected error: %v", err)
+			}
+			tt.check(t, out.String())
+		})
+	}
+}
+
+func TestRunUploadOutputErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		template string
+		wantErr  string
+	}{
+		{name: "unknown format", output: "yaml", wantErr: "unsupported output format: yaml"},
+		{name: "missing template", output: OutputTemplate, wantErr: "needs --template"},
+		{name: "invalid template", output: OutputTemplate, template: "{{.Key", wantErr: "invalid --template"},
+		{name: "template without format", output: OutputJSON, template: "{{.Key}}", wantErr: "requires --output template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cfg := newFakeS3(t, nil)
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+			opts := &UploadOptions{Config: cfg, Output: tt.output, Template: tt.template}
+
+			err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runUpload() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
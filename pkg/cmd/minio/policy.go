@@ -0,0 +1,219 @@
+package minio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+// Anonymous access levels of buckets
+const (
+	PolicyPrivate  = "private"
+	PolicyDownload = "download"
+	PolicyUpload   = "upload"
+	PolicyPublic   = "public"
+
+	// PolicyCustom is reported for bucket policies that grant none of the
+	// levels above
+	PolicyCustom = "custom"
+)
+
+// bucketPolicies maps access levels to the canned policies of minio-go
+var bucketPolicies = map[string]policy.BucketPolicy{
+	PolicyPrivate:  policy.BucketPolicyNone,
+	PolicyDownload: policy.BucketPolicyReadOnly,
+	PolicyUpload:   policy.BucketPolicyWriteOnly,
+	PolicyPublic:   policy.BucketPolicyReadWrite,
+}
+
+type PolicyOptions struct {
+	Config *MinIOConfig
+	Prefix string
+	Output string
+}
+
+// policyResult is the JSON representation of a bucket's anonymous access
+type policyResult struct {
+	Bucket   string          `json:"bucket"`
+	Prefix   string          `json:"prefix,omitempty"`
+	Policy   string          `json:"policy"`
+	Document json.RawMessage `json:"document,omitempty"`
+}
+
+func NewCmdMinIOPolicy(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Get or set the anonymous access policy of a bucket",
+		Long: `Get or set the anonymous access policy of a bucket.
+
+Access levels:
+- private:  no anonymous access (default)
+- download: anyone can download objects, e.g. for public URLs
+- upload:   anyone can upload objects
+- public:   anyone can list, download and upload objects
+
+With --prefix the policy only applies to the objects below the prefix.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newCmdPolicyGet(f))
+	cmd.AddCommand(newCmdPolicySet(f))
+
+	return cmd
+}
+
+func newCmdPolicyGet(f *cmdutil.Factory) *cobra.Command {
+	opts := &PolicyOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "get [flags] [bucket]",
+		Short: "Show the anonymous access policy of a bucket",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runPolicyGet(cmd.Context(), f, opts, args)
+		},
+	}
+
+	addPolicyFlags(cmd, opts)
+
+	return cmd
+}
+
+func newCmdPolicySet(f *cmdutil.Factory) *cobra.Command {
+	opts := &PolicyOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "set [flags] <private|download|upload|public> [bucket]",
+		Short: "Set the anonymous access policy of a bucket",
+		Example: `  # Make uploaded files reachable through their public URLs
+  gogobox minio policy set download
+
+  # Only share the objects below public/
+  gogobox minio policy set download --prefix public/ mybucket`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runPolicySet(cmd.Context(), f, opts, args[0], args[1:])
+		},
+	}
+
+	addPolicyFlags(cmd, opts)
+
+	return cmd
+}
+
+func addPolicyFlags(cmd *cobra.Command, opts *PolicyOptions) {
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().StringVar(&opts.Prefix, "prefix", "", "Only apply to objects below this prefix")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputText, "Output format: text or json")
+}
+
+func runPolicyGet(ctx context.Context, f *cmdutil.Factory, opts *PolicyOptions, args []string) error {
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+
+	bucketName := opts.Config.BucketName
+	document, statements, err := getBucketPolicy(ctx, client, bucketName)
+	if err != nil {
+		return err
+	}
+
+	result := policyResult{Bucket: bucketName, Prefix: opts.Prefix, Policy: PolicyCustom}
+	if document != "" {
+		result.Document = json.RawMessage(document)
+	}
+	canned := policy.GetPolicy(statements, bucketName, opts.Prefix)
+	for name, p := range bucketPolicies {
+		if p == canned && (p != policy.BucketPolicyNone || len(statements) == 0) {
+			result.Policy = name
+		}
+	}
+	return writePolicyResult(f, opts, result)
+}
+
+func runPolicySet(ctx context.Context, f *cmdutil.Factory, opts *PolicyOptions, level string, args []string) error {
+	canned, ok := bucketPolicies[level]
+	if !ok {
+		return fmt.Errorf("unsupported policy: %s (use private, download, upload or public)", level)
+	}
+	client, err := newBucketClient(opts.Config, opts.Output, args)
+	if err != nil {
+		return err
+	}
+
+	// Statements of other prefixes are kept
+	bucketName := opts.Config.BucketName
+	_, statements, err := getBucketPolicy(ctx, client, bucketName)
+	if err != nil {
+		return err
+	}
+	statements = policy.SetPolicy(statements, canned, bucketName, opts.Prefix)
+
+	result := policyResult{Bucket: bucketName, Prefix: opts.Prefix, Policy: level}
+	document := ""
+	if len(statements) > 0 {
+		data, err := json.Marshal(policy.BucketAccessPolicy{Version: "2012-10-17", Statements: statements})
+		if err != nil {
+			return fmt.Errorf("failed to encode bucket policy: %w", err)
+		}
+		document = string(data)
+		result.Document = data
+	}
+	// An empty document removes the bucket policy
+	if err := client.SetBucketPolicy(ctx, bucketName, document); err != nil {
+		return fmt.Errorf("failed to set policy of bucket %s: %w", bucketName, err)
+	}
+	return writePolicyResult(f, opts, result)
+}
+
+// getBucketPolicy returns the policy document of a bucket and its statements,
+// both empty if the bucket has no policy
+func getBucketPolicy(ctx context.Context, client *minio.Client, bucketName string) (string, []policy.Statement, error) {
+	document, err := client.GetBucketPolicy(ctx, bucketName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get policy of bucket %s: %w", bucketName, err)
+	}
+	if document == "" {
+		return "", nil, nil
+	}
+
+	var accessPolicy policy.BucketAccessPolicy
+	if err := json.Unmarshal([]byte(document), &accessPolicy); err != nil {
+		return "", nil, fmt.Errorf("failed to parse policy of bucket %s: %w", bucketName, err)
+	}
+	return document, accessPolicy.Statements, nil
+}
+
+func writePolicyResult(f *cmdutil.Factory, opts *PolicyOptions, result policyResult) error {
+	if opts.Output == OutputJSON {
+		return writeJSON(f.IOStreams.Out, result)
+	}
+	target := result.Bucket
+	if result.Prefix != "" {
+		target += "/" + result.Prefix
+	}
+	fmt.Fprintf(f.IOStreams.Out, "%s: %s\n", target, result.Policy)
+	return nil
+}
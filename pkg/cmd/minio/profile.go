@@ -0,0 +1,205 @@
+package minio
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/gogodjzhu/gogobox/internal/config"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// profileSection is the config file section holding the MinIO profiles
+const profileSection = "minio"
+
+// ProfileConfig holds the named MinIO connection profiles
+type ProfileConfig struct {
+	// CurrentProfile is used when no profile is selected explicitly
+	CurrentProfile string `json:"currentProfile" yaml:"currentProfile"`
+
+	// Profiles maps profile names to their connection settings
+	Profiles map[string]*MinIOConfig `json:"profiles" yaml:"profiles"`
+}
+
+// LoadProfiles reads the profiles from the gogobox config file
+func LoadProfiles() (*ProfileConfig, error) {
+	pc := &ProfileConfig{}
+	if err := config.LoadSection(profileSection, pc); err != nil {
+		return nil, err
+	}
+	if pc.Profiles == nil {
+		pc.Profiles = map[string]*MinIOConfig{}
+	}
+	return pc, nil
+}
+
+// Save writes the profiles back to the gogobox config file
+func (pc *ProfileConfig) Save() error {
+	return config.SaveSection(profileSection, pc)
+}
+
+// Get returns the named profile
+func (pc *ProfileConfig) Get(name string) (*MinIOConfig, error) {
+	profile, ok := pc.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' does not exist", name)
+	}
+	return profile, nil
+}
+
+func NewCmdMinIOProfile(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage named MinIO connection profiles",
+		Long: `Manage named MinIO connection profiles.
+
+Profiles are stored in ~/.config/gogobox/config.yaml (or the file named by
+$GOGOBOX_CONFIG) and selected with --profile on any minio subcommand. When no
+profile is given the current profile (see 'profile use') is used.
+
+Settings are merged with the precedence flag > environment > profile, where
+the environment variables are:
+  GOGOBOX_MINIO_PROFILE, GOGOBOX_MINIO_BACKEND, GOGOBOX_MINIO_ENDPOINT,
+  GOGOBOX_MINIO_ACCESS_KEY, GOGOBOX_MINIO_SECRET_KEY, GOGOBOX_MINIO_SECRET_REF,
+  GOGOBOX_MINIO_BUCKET, GOGOBOX_MINIO_SSL, GOGOBOX_MINIO_PROVIDER,
+  GOGOBOX_MINIO_REGION, GOGOBOX_MINIO_BUCKET_LOOKUP, GOGOBOX_MINIO_SIGNATURE,
+  GOGOBOX_MINIO_URL_STYLE, GOGOBOX_MINIO_BASE_URL`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newCmdProfileAdd(f))
+	cmd.AddCommand(newCmdProfileUse(f))
+	cmd.AddCommand(newCmdProfileList(f))
+	cmd.AddCommand(newCmdProfileRemove(f))
+
+	return cmd
+}
+
+func newCmdProfileAdd(f *cmdutil.Factory) *cobra.Command {
+	cfg := NewDefaultConfig()
+
+	cmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add or update a connection profile",
+		Example: `  # Add a profile and make it the current one
+  gogobox minio profile add local -e localhost:9000 -a mykey -s mysecret -b mybucket
+
+  # Keep objects in the directory /srv/objects/mybucket
+  gogobox minio profile add offline --backend local -e /srv/objects -b mybucket`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := LoadProfiles()
+			if err != nil {
+				return err
+			}
+
+			// Only overwrite the settings given on the command line
+			profile, ok := pc.Profiles[args[0]]
+			if !ok {
+				profile = NewDefaultConfig()
+			}
+			applyFlagConfig(cmd, cfg, profile)
+			pc.Profiles[args[0]] = profile
+
+			if pc.CurrentProfile == "" {
+				pc.CurrentProfile = args[0]
+			}
+			if err := pc.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(f.IOStreams.Out, "Saved profile %s\n", args[0])
+			return nil
+		},
+	}
+
+	addConfigFlags(cmd, cfg)
+
+	return cmd
+}
+
+func newCmdProfileUse(f *cmdutil.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the current connection profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := LoadProfiles()
+			if err != nil {
+				return err
+			}
+			if _, err := pc.Get(args[0]); err != nil {
+				return err
+			}
+			pc.CurrentProfile = args[0]
+			if err := pc.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(f.IOStreams.Out, "Switched to profile %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newCmdProfileList(f *cmdutil.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List connection profiles",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := LoadProfiles()
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(pc.Profiles))
+			for name := range pc.Profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			// Secrets are never printed
+			w := tabwriter.NewWriter(f.IOStreams.Out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CURRENT\tNAME\tBACKEND\tENDPOINT\tBUCKET\tSSL")
+			for _, name := range names {
+				current := ""
+				if name == pc.CurrentProfile {
+					current = "*"
+				}
+				profile := pc.Profiles[name]
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", current, name, profile.backendName(), profile.Endpoint, profile.BucketName, profile.UseSSL)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newCmdProfileRemove(f *cmdutil.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a connection profile",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pc, err := LoadProfiles()
+			if err != nil {
+				return err
+			}
+			if _, err := pc.Get(args[0]); err != nil {
+				return err
+			}
+			delete(pc.Profiles, args[0])
+			if pc.CurrentProfile == args[0] {
+				pc.CurrentProfile = ""
+			}
+			if err := pc.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(f.IOStreams.Out, "Removed profile %s\n", args[0])
+			return nil
+		},
+	}
+}
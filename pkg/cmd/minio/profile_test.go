@@ -0,0 +1,238 @@
+package minio
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/internal/config"
+	"github.com/gogodjzhu/gogobox/internal/secretstore"
+	"github.com/gogodjzhu/gogobox/pkg/cmd/secrets"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// setupProfiles points the config file to a temp dir and clears the MinIO
+// environment overrides
+func setupProfiles(t *testing.T, pc *ProfileConfig) {
+	t.Setenv(config.EnvConfigFile, filepath.Join(t.TempDir(), "config.yaml"))
+	for _, env := range []string{EnvProfile, EnvBackend, EnvEndpoint, EnvAccessKey, EnvSecretKey, EnvSecretRef, EnvBucket, EnvSSL, EnvProvider, EnvRegion, EnvBucketLookup, EnvSignature, EnvURLStyle, EnvBaseURL} {
+		// t.Setenv restores the variable after the test, Unsetenv makes
+		// LookupEnv report it as missing
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+	if pc != nil {
+		if err := pc.Save(); err != nil {
+			t.Fatalf("Failed to save profiles: %v", err)
+		}
+	}
+}
+
+func testFactory() *cmdutil.Factory {
+	return &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{In: strings.NewReader(""), Out: &bytes.Buffer{}}}
+}
+
+func newResolveCommand(cfg *MinIOConfig, args ...string) (*cobra.Command, error) {
+	cmd := &cobra.Command{Use: "test", RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+	addConnectionFlags(cmd, cfg)
+	cmd.SetArgs(args)
+	return cmd, cmd.Execute()
+}
+
+func TestResolveConfigPrecedence(t *testing.T) {
+	setupProfiles(t, &ProfileConfig{
+		CurrentProfile: "default",
+		Profiles: map[string]*MinIOConfig{
+			"default": {Endpoint: "default:9000", AccessKeyID: "dkey", SecretAccessKey: "dsecret", BucketName: "dbucket"},
+			"other":   {Endpoint: "other:9000", AccessKeyID: "okey", SecretAccessKey: "osecret", BucketName: "obucket", UseSSL: true},
+		},
+	})
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want MinIOConfig
+	}{
+		{
+			name: "Current profile",
+			want: MinIOConfig{Endpoint: "default:9000", AccessKeyID: "dkey", SecretAccessKey: "dsecret", BucketName: "dbucket"},
+		},
+		{
+			name: "Profile flag",
+			args: []string{"--profile", "other"},
+			want: MinIOConfig{Endpoint: "other:9000", AccessKeyID: "okey", SecretAccessKey: "osecret", BucketName: "obucket", UseSSL: true},
+		},
+		{
+			name: "Profile env",
+			env:  map[string]string{EnvProfile: "other"},
+			want: MinIOConfig{Endpoint: "other:9000", AccessKeyID: "okey", SecretAccessKey: "osecret", BucketName: "obucket", UseSSL: true},
+		},
+		{
+			name: "Env overrides profile",
+			env:  map[string]string{EnvBucket: "envbucket", EnvSSL: "true"},
+			want: MinIOConfig{Endpoint: "default:9000", AccessKeyID: "dkey", SecretAccessKey: "dsecret", BucketName: "envbucket", UseSSL: true},
+		},
+		{
+			name: "Flag overrides env",
+			env:  map[string]string{EnvBucket: "envbucket", EnvSSL: "true"},
+			args: []string{"-b", "flagbucket", "--ssl=false"},
+			want: MinIOConfig{Endpoint: "default:9000", AccessKeyID: "dkey", SecretAccessKey: "dsecret", BucketName: "flagbucket"},
+		},
+		{
+			name: "URL settings",
+			env:  map[string]string{EnvURLStyle: URLStyleVirtualHost, EnvBaseURL: "https://env.example.com"},
+			args: []string{"--base-url", "https://cdn.example.com/{key}"},
+			want: MinIOConfig{Endpoint: "default:9000", AccessKeyID: "dkey", SecretAccessKey: "dsecret", BucketName: "dbucket",
+				URLStyle: URLStyleVirtualHost, BaseURL: "https://cdn.example.com/{key}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg := NewDefaultConfig()
+			cmd, err := newResolveCommand(cfg, tt.args...)
+			if err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+			if err := resolveConfig(testFactory(), cmd, cfg); err != nil {
+				t.Fatalf("resolveConfig() unexpected error: %v", err)
+			}
+			if *cfg != tt.want {
+				t.Errorf("resolveConfig() = %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveConfigSecretRef(t *testing.T) {
+	setupProfiles(t, &ProfileConfig{
+		CurrentProfile: "default",
+		Profiles: map[string]*MinIOConfig{
+			"default": {Endpoint: "default:9000", AccessKeyID: "dkey", SecretRef: "minio-default", BucketName: "dbucket"},
+		},
+	})
+	t.Setenv(secretstore.EnvSecretsFile, filepath.Join(t.TempDir(), "secrets.enc"))
+	t.Setenv(secrets.EnvPassphrase, "passphrase")
+
+	store, err := secrets.Unlock(testFactory().IOStreams)
+	if err != nil {
+		t.Fatalf("Unlock() unexpected error: %v", err)
+	}
+	store.Set("minio-default", "stored-secret")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	cfg := NewDefaultConfig()
+	cmd, _ := newResolveCommand(cfg)
+	if err := resolveConfig(testFactory(), cmd, cfg); err != nil {
+		t.Fatalf("resolveConfig() unexpected error: %v", err)
+	}
+	if cfg.SecretAccessKey != "stored-secret" {
+		t.Errorf("resolveConfig() secret = %q, want value from secrets store", cfg.SecretAccessKey)
+	}
+
+	// An explicit secret key wins over the reference
+	cfg = NewDefaultConfig()
+	cmd, _ = newResolveCommand(cfg, "-s", "flag-secret")
+	if err := resolveConfig(testFactory(), cmd, cfg); err != nil {
+		t.Fatalf("resolveConfig() unexpected error: %v", err)
+	}
+	if cfg.SecretAccessKey != "flag-secret" {
+		t.Errorf("resolveConfig() secret = %q, want flag value", cfg.SecretAccessKey)
+	}
+
+	// A missing secret is reported
+	cfg = NewDefaultConfig()
+	cmd, _ = newResolveCommand(cfg, "--secret-ref", "missing")
+	if err := resolveConfig(testFactory(), cmd, cfg); err == nil {
+		t.Errorf("resolveConfig() expected error for missing secret")
+	}
+}
+
+func TestResolveConfigErrors(t *testing.T) {
+	setupProfiles(t, nil)
+
+	cfg := NewDefaultConfig()
+	cmd, err := newResolveCommand(cfg, "--profile", "missing")
+	if err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if err := resolveConfig(testFactory(), cmd, cfg); err == nil {
+		t.Errorf("resolveConfig() expected error for missing profile")
+	}
+
+	t.Setenv(EnvSSL, "maybe")
+	cmd, _ = newResolveCommand(cfg)
+	if err := resolveConfig(testFactory(), cmd, cfg); err == nil {
+		t.Errorf("resolveConfig() expected error for invalid %s", EnvSSL)
+	}
+}
+
+func TestProfileCommands(t *testing.T) {
+	setupProfiles(t, nil)
+
+	run := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewCmdMinIOProfile(&cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}})
+		cmd.SetArgs(args)
+		cmd.SetOut(out)
+		cmd.SetErr(out)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("add", "local", "-e", "localhost:9000", "-a", "key", "-s", "secret", "-b", "bucket"); err != nil {
+		t.Fatalf("profile add failed: %v", err)
+	}
+	if _, err := run("add", "remote", "-e", "s3.example.com", "-a", "key2", "-s", "secret2", "-b", "bucket2", "--ssl"); err != nil {
+		t.Fatalf("profile add failed: %v", err)
+	}
+	// Updating only changes the given settings
+	if _, err := run("add", "local", "-b", "newbucket"); err != nil {
+		t.Fatalf("profile update failed: %v", err)
+	}
+
+	pc, err := LoadProfiles()
+	if err != nil {
+		t.Fatalf("LoadProfiles() unexpected error: %v", err)
+	}
+	if pc.CurrentProfile != "local" {
+		t.Errorf("first added profile should become current, got %q", pc.CurrentProfile)
+	}
+	local := pc.Profiles["local"]
+	if local == nil || local.Endpoint != "localhost:9000" || local.BucketName != "newbucket" {
+		t.Errorf("unexpected local profile: %+v", local)
+	}
+
+	if _, err := run("use", "remote"); err != nil {
+		t.Fatalf("profile use failed: %v", err)
+	}
+	if _, err := run("use", "missing"); err == nil {
+		t.Errorf("profile use expected error for missing profile")
+	}
+
+	out, err := run("list")
+	if err != nil {
+		t.Fatalf("profile list failed: %v", err)
+	}
+	if !regexp.MustCompile(`(?m)^\*\s+remote\s`).MatchString(out) || strings.Contains(out, "secret") {
+		t.Errorf("unexpected profile list output:\n%s", out)
+	}
+
+	if _, err := run("remove", "remote"); err != nil {
+		t.Fatalf("profile remove failed: %v", err)
+	}
+	pc, _ = LoadProfiles()
+	if _, ok := pc.Profiles["remote"]; ok || pc.CurrentProfile != "" {
+		t.Errorf("profile remove left %+v", pc)
+	}
+}
@@ -0,0 +1,158 @@
+package minio
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil/tui/tui_progress"
+)
+
+// transferProgress tracks concurrent transfers. When stdout is a terminal it
+// is rendered as a Bubble Tea view, otherwise as plain lines on stderr.
+type transferProgress struct {
+	mu      sync.Mutex
+	files   []tui_progress.FileProgress
+	started time.Time
+
+	// plain line output, nil when rendering with Bubble Tea or disabled
+	out      io.Writer
+	quarters []int
+
+	program     *tea.Program
+	programDone chan struct{}
+}
+
+// newTransferProgress creates the tracker for the named files. Nothing is
+// rendered if enabled is false.
+func newTransferProgress(streams *cmdutil.IOStreams, title string, names []string, enabled bool) *transferProgress {
+	p := &transferProgress{
+		files:    make([]tui_progress.FileProgress, len(names)),
+		quarters: make([]int, len(names)),
+		started:  time.Now(),
+	}
+	for i, name := range names {
+		p.files[i].Name = name
+	}
+
+	switch {
+	case !enabled:
+	case streams.IsStdoutTTY():
+		p.program = tea.NewProgram(tui_progress.NewModel(title, p.Snapshot),
+			tea.WithOutput(streams.Out), tea.WithInput(nil))
+		p.programDone = make(chan struct{})
+		go func() {
+			defer close(p.programDone)
+			p.program.Run()
+		}()
+	default:
+		p.out = streams.ErrOut
+		if p.out == nil {
+			p.out = io.Discard
+		}
+	}
+	return p
+}
+
+// Start marks the transfer of file id with total bytes as started
+func (p *transferProgress) Start(id int, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[id].Started = true
+	p.files[id].Total = total
+	if p.out != nil {
+		fmt.Fprintf(p.out, "%s: started (%s)\n", p.files[id].Name, util.HumanSize(total))
+	}
+}
+
+// Add records n more transferred bytes of file id
+func (p *transferProgress) Add(id int, n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file := &p.files[id]
+	// Retried requests report their bytes again
+	file.Current = util.MinInt64(file.Current+n, file.Total)
+
+	if p.out != nil && file.Total > 0 {
+		if quarter := int(file.Current * 4 / file.Total); quarter > p.quarters[id] && quarter < 4 {
+			p.quarters[id] = quarter
+			fmt.Fprintf(p.out, "%s: %d%% (%s/%s)\n", file.Name, quarter*25,
+				util.HumanSize(file.Current), util.HumanSize(file.Total))
+		}
+	}
+}
+
+// Done marks the transfer of file id as finished, failed if err is not nil
+func (p *transferProgress) Done(id int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file := &p.files[id]
+	file.Done = true
+	file.Err = err
+	if err == nil {
+		file.Current = file.Total
+	}
+	if p.out != nil {
+		if err != nil {
+			fmt.Fprintf(p.out, "%s: failed: %v\n", file.Name, err)
+		} else {
+			fmt.Fprintf(p.out, "%s: done\n", file.Name)
+		}
+	}
+}
+
+// Reader returns an io.Reader for minio.PutObjectOptions.Progress, which
+// reads as many bytes as were uploaded
+func (p *transferProgress) Reader(id int) io.Reader {
+	return progressReader{progress: p, id: id}
+}
+
+// Writer returns an io.Writer that records every written byte as
+// transferred, for readers wrapped with io.TeeReader
+func (p *transferProgress) Writer(id int) io.Writer {
+	return progressReader{progress: p, id: id}
+}
+
+// Snapshot returns a copy of the current state
+func (p *transferProgress) Snapshot() tui_progress.Snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	files := make([]tui_progress.FileProgress, len(p.files))
+	copy(files, p.files)
+	return tui_progress.Snapshot{Files: files, Started: p.started}
+}
+
+// Close renders the final state and waits for the view to exit
+func (p *transferProgress) Close() {
+	if p.program != nil {
+		p.program.Send(tui_progress.DoneMsg{})
+		<-p.programDone
+		return
+	}
+	if p.out != nil {
+		snapshot := p.Snapshot()
+		current, _ := snapshot.Totals()
+		rate, _ := snapshot.Throughput(time.Now())
+		fmt.Fprintf(p.out, "Transferred %s in %s (%s/s)\n",
+			util.HumanSize(current), time.Since(p.started).Round(time.Millisecond), util.HumanSize(int64(rate)))
+	}
+}
+
+type progressReader struct {
+	progress *transferProgress
+	id       int
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	r.progress.Add(r.id, int64(len(b)))
+	return len(b), nil
+}
+
+func (r progressReader) Write(b []byte) (int, error) {
+	r.progress.Add(r.id, int64(len(b)))
+	return len(b), nil
+}
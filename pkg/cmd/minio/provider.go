@@ -0,0 +1,164 @@
+package minio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Storage providers with presets
+const (
+	ProviderMinIO      = "minio"
+	ProviderAWS        = "aws"
+	ProviderR2         = "r2"
+	ProviderAliyunOSS  = "aliyun-oss"
+	ProviderTencentCOS = "tencent-cos"
+)
+
+// BucketLookupAuto addresses buckets the way the client library detects for
+// the endpoint, the other lookups are URLStylePath and URLStyleVirtualHost
+const BucketLookupAuto = "auto"
+
+// Signature versions of requests
+const (
+	SignatureV4 = "v4"
+	SignatureV2 = "v2"
+)
+
+// providerPreset holds the settings of a provider. Presets only fill the
+// settings that are not configured, except for TLS which the cloud providers
+// require.
+type providerPreset struct {
+	// endpoint returns the endpoint of a region, nil if it must be configured
+	endpoint      func(region string) string
+	defaultRegion string
+	bucketLookup  string
+	urlStyle      string
+	signature     string
+	ssl           bool
+}
+
+var providerPresets = map[string]providerPreset{
+	ProviderMinIO: {
+		bucketLookup: URLStylePath,
+		urlStyle:     URLStylePath,
+		signature:    SignatureV4,
+	},
+	ProviderAWS: {
+		endpoint: func(region string) string {
+			if region == "us-east-1" {
+				return "s3.amazonaws.com"
+			}
+			return "s3." + region + ".amazonaws.com"
+		},
+		defaultRegion: "us-east-1",
+		bucketLookup:  URLStyleVirtualHost,
+		urlStyle:      URLStyleVirtualHost,
+		signature:     SignatureV4,
+		ssl:           true,
+	},
+	// R2 endpoints contain the account ID: <account>.r2.cloudflarestorage.com.
+	// Buckets are not public on them, public URLs need --base-url.
+	ProviderR2: {
+		defaultRegion: "auto",
+		bucketLookup:  URLStylePath,
+		urlStyle:      URLStylePath,
+		signature:     SignatureV4,
+		ssl:           true,
+	},
+	// OSS only serves virtual-hosted requests. Regions are given without the
+	// "oss-" prefix of the endpoint, e.g. cn-hangzhou.
+	ProviderAliyunOSS: {
+		endpoint: func(region string) string {
+			return "oss-" + strings.TrimPrefix(region, "oss-") + ".aliyuncs.com"
+		},
+		bucketLookup: URLStyleVirtualHost,
+		urlStyle:     URLStyleVirtualHost,
+		signature:    SignatureV2,
+		ssl:          true,
+	},
+	// COS bucket names end with the APPID, e.g. images-1250000000
+	ProviderTencentCOS: {
+		endpoint: func(region string) string {
+			return "cos." + region + ".myqcloud.com"
+		},
+		bucketLookup: URLStyleVirtualHost,
+		urlStyle:     URLStyleVirtualHost,
+		signature:    SignatureV4,
+		ssl:          true,
+	},
+}
+
+// providerNames returns the names of the providers with presets
+func providerNames() []string {
+	names := make([]string, 0, len(providerPresets))
+	for name := range providerPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProvider fills the settings that are not configured from the preset
+// of Provider. Configurations without provider, or of other backends than S3,
+// are left as they are.
+func (c *MinIOConfig) ApplyProvider() error {
+	if c.Provider == "" || c.backendName() != BackendS3 {
+		return nil
+	}
+	preset, ok := providerPresets[c.Provider]
+	if !ok {
+		return fmt.Errorf("unknown provider %s, supported are %s", c.Provider, strings.Join(providerNames(), ", "))
+	}
+
+	if c.Region == "" {
+		c.Region = preset.defaultRegion
+	}
+	if c.Endpoint == "" && preset.endpoint != nil {
+		if c.Region == "" {
+			return fmt.Errorf("provider %s needs a region or an endpoint", c.Provider)
+		}
+		c.Endpoint = preset.endpoint(c.Region)
+	}
+	if preset.ssl {
+		c.UseSSL = true
+	}
+	if c.BucketLookup == "" {
+		c.BucketLookup = preset.bucketLookup
+	}
+	if c.URLStyle == "" {
+		c.URLStyle = preset.urlStyle
+	}
+	if c.SignatureVersion == "" {
+		c.SignatureVersion = preset.signature
+	}
+	return nil
+}
+
+// clientOptions returns the options of clients for the configuration
+func (c *MinIOConfig) clientOptions() *minio.Options {
+	signer := credentials.SignatureV4
+	if c.SignatureVersion == SignatureV2 {
+		signer = credentials.SignatureV2
+	}
+	creds := credentials.NewStatic(c.AccessKeyID, c.SecretAccessKey, "", signer)
+	if c.AccessKeyID == "" {
+		creds = newCredentialChain(signer)
+	}
+	lookup := minio.BucketLookupAuto
+	switch c.BucketLookup {
+	case URLStylePath:
+		lookup = minio.BucketLookupPath
+	case URLStyleVirtualHost:
+		lookup = minio.BucketLookupDNS
+	}
+	return &minio.Options{
+		Creds:        creds,
+		Secure:       c.UseSSL,
+		Region:       c.Region,
+		BucketLookup: lookup,
+	}
+}
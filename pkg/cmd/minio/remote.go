@@ -0,0 +1,216 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+)
+
+// Limits of URL sources
+const (
+	DefaultURLMaxSize      = 1024 * 1024 * 1024
+	DefaultURLTimeout      = 30 * time.Second
+	DefaultURLMaxRedirects = 5
+)
+
+// remoteSource is an http(s) URL given as upload source
+type remoteSource struct {
+	URL string
+	// Name is the file name from the response, keys are derived from it
+	Name        string
+	ContentType string
+	// Size is -1 if the response had no Content-Length
+	Size int64
+	// File is the temporary copy of sources that cannot be streamed, "" if
+	// the URL is streamed into its object
+	File string
+
+	// response is the response the source was named after until its body
+	// is consumed, so the content matches the name and the URL is only
+	// requested once
+	mu       sync.Mutex
+	response *util.RemoteFile
+}
+
+// fetch returns the response the source was named after, or requests the
+// URL again once it has been consumed
+func (r *remoteSource) fetch(ctx context.Context, opts *UploadOptions) (*util.RemoteFile, error) {
+	r.mu.Lock()
+	response := r.response
+	r.response = nil
+	r.mu.Unlock()
+	if response != nil {
+		return response, nil
+	}
+	return util.FetchRemote(ctx, r.URL, remoteFetchOptions(opts))
+}
+
+// close releases the response if it was not consumed
+func (r *remoteSource) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.response != nil {
+		r.response.Body.Close()
+		r.response = nil
+	}
+}
+
+// closeRemoteSources releases the responses that were not uploaded
+func closeRemoteSources(remotes map[string]*remoteSource) {
+	for _, remote := range remotes {
+		remote.close()
+	}
+}
+
+// isRemoteSource reports whether the upload source is a URL
+func isRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+func remoteFetchOptions(opts *UploadOptions) util.FetchOptions {
+	return util.FetchOptions{MaxSize: opts.URLMaxSize, Timeout: opts.URLTimeout, MaxRedirects: opts.URLMaxRedirects}
+}
+
+// resolveRemoteSources requests every URL source and names it after the
+// response. Sources that are needed as files, images that may be resized and
+// sources of content hashed keys or journaled runs, are downloaded to
+// temporary files. The others are streamed into their objects by uploadFile,
+// the responses of those must be released with closeRemoteSources.
+func resolveRemoteSources(ctx context.Context, sources []uploadSource, opts *UploadOptions, tmpl *keyTemplate, temporary map[string]bool) (map[string]*remoteSource, error) {
+	remotes := map[string]*remoteSource{}
+	for _, source := range sources {
+		if !isRemoteSource(source.Path) || remotes[source.Path] != nil {
+			continue
+		}
+
+		remote, err := openRemoteSource(ctx, source.Path, opts)
+		if err != nil {
+			closeRemoteSources(remotes)
+			return nil, err
+		}
+		resizable := opts.AutoResize && util.IsResizableContentType(remote.ContentType) &&
+			(remote.Size < 0 || remote.Size > opts.MaxSize)
+		if resizable || opts.Journal != "" || opts.Dedupe || tmpl.Has("sha256") {
+			remote.File, err = downloadRemoteSource(ctx, remote, opts)
+			if err != nil {
+				closeRemoteSources(remotes)
+				return nil, err
+			}
+			temporary[remote.File] = true
+		}
+		remotes[source.Path] = remote
+	}
+	for i, source := range sources {
+		if remote := remotes[source.Path]; remote != nil {
+			sources[i].RelPath = remote.Name
+		}
+	}
+	return remotes, nil
+}
+
+// openRemoteSource requests the URL and describes the response. The body
+// is kept open for the upload or download of the source.
+func openRemoteSource(ctx context.Context, url string, opts *UploadOptions) (*remoteSource, error) {
+	file, err := util.FetchRemote(ctx, url, remoteFetchOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	remote := newRemoteSource(url, file)
+	remote.response = file
+	return remote, nil
+}
+
+// newRemoteSource names the response: after its Content-Disposition or URL,
+// with an extension matching its Content-Type if the name has none. Missing
+// and generic content types are detected from the name.
+func newRemoteSource(url string, file *util.RemoteFile) *remoteSource {
+	contentType := file.ContentType
+	if contentType == util.DefaultContentType {
+		contentType = ""
+	}
+	name := file.Name
+	if name == "" {
+		name = "download"
+	}
+	if path.Ext(name) == "" {
+		if ext := util.ExtensionByContentType(contentType); ext != "" {
+			name += ext
+		}
+	}
+	if contentType == "" {
+		contentType = util.ContentTypeByExtension(name)
+	}
+	if contentType == "" {
+		contentType = util.DefaultContentType
+	}
+	return &remoteSource{URL: url, Name: name, ContentType: contentType, Size: file.Size}
+}
+
+// downloadRemoteSource copies the URL to a temporary file with the extension
+// of its name
+func downloadRemoteSource(ctx context.Context, remote *remoteSource, opts *UploadOptions) (string, error) {
+	file, err := remote.fetch(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	defer file.Body.Close()
+
+	tmp, err := os.CreateTemp("", "gogobox-url-*"+path.Ext(remote.Name))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer tmp.Close()
+	if _, err := io.Copy(tmp, file.Body); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to download %s: %w", remote.URL, err)
+	}
+	return tmp.Name(), nil
+}
+
+// uploadRemote streams the URL into the object. Responses without a
+// Content-Length are uploaded in parts of streamPartSize unless --part-size
+// is given.
+func uploadRemote(ctx context.Context, store ObjectStore, remote *remoteSource, objectName string, opts *UploadOptions, progress *transferProgress, id int) error {
+	file, err := remote.fetch(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer file.Body.Close()
+
+	putOpts := opts.metadata.forContentType(remote.Name, remote.ContentType).putOptions()
+	putOpts.PartSize = uint64(opts.PartSize)
+	if file.Size < 0 && putOpts.PartSize == 0 {
+		putOpts.PartSize = streamPartSize
+	}
+	putOpts.Progress = progress.Reader(id)
+	reader, size, err := opts.encryption.seal(file.Body, file.Size, &putOpts)
+	if err != nil {
+		return err
+	}
+
+	progress.Start(id, util.MaxInt64(size, 0))
+	if err := store.Put(ctx, objectName, reader, size, putOpts); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remote.URL, err)
+	}
+	return nil
+}
+
+// remoteUploadPaths returns the files uploaded for naming, streamed URLs are
+// replaced by their names so {ext} matches the response
+func remoteUploadPaths(files []string, remotes map[string]*remoteSource) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file
+		if remote := remotes[file]; remote != nil && remote.File == "" {
+			paths[i] = remote.Name
+		}
+	}
+	return paths
+}
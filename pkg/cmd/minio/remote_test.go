@@ -0,0 +1,151 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestRunUploadURL(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	report := []byte("%PDF-1.7 quarterly report")
+	streamed := bytes.Repeat([]byte("line of a log\n"), 1000)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/photo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	})
+	mux.HandleFunc("/dl", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="report.pdf"`)
+		w.Write(report)
+	})
+	mux.HandleFunc("/logs/app.log", func(w http.ResponseWriter, r *http.Request) {
+		// Flushing before the end sends the body chunked, without a length
+		for i := 0; i < len(streamed); i += 1400 {
+			w.Write(streamed[i:min(i+1400, len(streamed))])
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/dl", http.StatusFound)
+	})
+	// URLs are requested once, signed URLs may not be valid twice
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mux.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	sum := sha256.Sum256(report)
+
+	tests := []struct {
+		name            string
+		url             string
+		opts            UploadOptions
+		wantKey         string
+		wantContent     []byte
+		wantContentType string
+		wantOut         string
+		wantErr         string
+	}{
+		{
+			name:            "content type names the extension",
+			url:             "/images/photo",
+			opts:            UploadOptions{PrintURLs: true, Format: LinkMarkdown},
+			wantKey:         "photo.png",
+			wantContent:     png,
+			wantContentType: "image/png",
+			wantOut:         "![photo.png](",
+		},
+		{
+			name:            "resize of small images streams them",
+			url:             "/images/photo",
+			opts:            UploadOptions{AutoResize: true, MaxSize: 1024},
+			wantKey:         "photo.png",
+			wantContent:     png,
+			wantContentType: "image/png",
+		},
+		{
+			name:            "content disposition",
+			url:             "/dl",
+			wantKey:         "report.pdf",
+			wantContent:     report,
+			wantContentType: "application/pdf",
+		},
+		{
+			name:            "unknown length",
+			url:             "/logs/app.log",
+			wantKey:         "app.log",
+			wantContent:     streamed,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:        "content hash via temporary file",
+			url:         "/dl",
+			opts:        UploadOptions{Dedupe: true},
+			wantKey:     hex.EncodeToString(sum[:]) + ".pdf",
+			wantContent: report,
+		},
+		{
+			name:    "size limit",
+			url:     "/logs/app.log",
+			opts:    UploadOptions{URLMaxSize: 1000},
+			wantErr: "exceeds the size limit",
+		},
+		{
+			name:    "redirect limit",
+			url:     "/moved",
+			opts:    UploadOptions{URLMaxRedirects: 0},
+			wantErr: "stopped after 0 redirects",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, cfg := newFakeS3(t, nil)
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+			opts := tt.opts
+			opts.Config = cfg
+			if opts.KeyTemplate == "" {
+				opts.KeyTemplate = "{basename}.{ext}"
+			}
+
+			requests.Store(0)
+			err := runUpload(context.Background(), f, &opts, []string{ts.URL + tt.url})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("runUpload() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runUpload() unexpected error: %v", err)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("URL was requested %d times, want once", got)
+			}
+			if got := fake.object(tt.wantKey); !bytes.Equal(got, tt.wantContent) {
+				t.Fatalf("object %s has %d bytes, want %d (objects: %d)", tt.wantKey, len(got), len(tt.wantContent), len(fake.keys()))
+			}
+			if tt.wantContentType != "" {
+				if got := fake.header(tt.wantKey).Get("Content-Type"); got != tt.wantContentType {
+					t.Errorf("stored Content-Type = %q, want %q", got, tt.wantContentType)
+				}
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil/tui/tui_result"
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+)
+
+// maxConfirmKeys is the number of keys listed in the deletion confirmation
+const maxConfirmKeys = 10
+
+type RemoveOptions struct {
+	Config    *MinIOConfig
+	Recursive bool
+	Yes       bool
+}
+
+func NewCmdMinIORemove(f *cmdutil.Factory) *cobra.Command {
+	opts := &RemoveOptions{
+		Config: NewDefaultConfig(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rm [flags] <object1> [object2] ...",
+		Short: "Delete objects",
+		Long: `Delete objects from a MinIO bucket.
+
+With --recursive the arguments are prefixes and every object below them is
+deleted. Prefixes are directories like for cp and mv: "photos" deletes
+"photos/a.png" but not "photos-old/a.png". The objects are listed for
+confirmation before they are deleted unless --yes is given. Without a
+terminal, and to delete the whole bucket with "", --yes is required.`,
+		Example: `  # Delete two objects
+  gogobox minio rm 202401/a.png 202401/b.png
+
+  # Delete everything below tmp/ without asking
+  gogobox minio rm -r --yes tmp/`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runRemove(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().BoolVarP(&opts.Recursive, "recursive", "r", false, "Delete every object below the given prefixes")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Delete without asking for confirmation")
+
+	return cmd
+}
+
+func runRemove(ctx context.Context, f *cmdutil.Factory, opts *RemoveOptions, args []string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	minioClient, err := newClient(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+	bucketName := opts.Config.BucketName
+
+	var keys []string
+	if opts.Recursive {
+		// Prefixes may overlap
+		seen := map[string]bool{}
+		prefixes := make([]string, len(args))
+		for i, prefix := range args {
+			if prefix == "" && !opts.Yes {
+				return fmt.Errorf("refusing to delete every object of bucket %s without --yes", bucketName)
+			}
+			// Prefixes are treated as directories, "a" must not match "ab/c"
+			if prefix != "" && !strings.HasSuffix(prefix, "/") {
+				prefix += "/"
+			}
+			prefixes[i] = prefix
+			objects, err := listAllObjects(ctx, minioClient, bucketName, prefix)
+			if err != nil {
+				return err
+			}
+			for _, object := range objects {
+				if !seen[object.Key] {
+					seen[object.Key] = true
+					keys = append(keys, object.Key)
+				}
+			}
+		}
+		if len(keys) == 0 {
+			fmt.Fprintf(f.IOStreams.Out, "No objects below %s\n", strings.Join(prefixes, ", "))
+			return nil
+		}
+	} else {
+		// Deleting a missing key succeeds silently, report typos instead
+		for _, key := range args {
+			if _, err := minioClient.StatObject(ctx, bucketName, key, minio.StatObjectOptions{}); err != nil {
+				return fmt.Errorf("failed to stat object %s: %w", key, err)
+			}
+		}
+		keys = args
+	}
+
+	// Deleting by prefix may match more than expected
+	if opts.Recursive && !opts.Yes {
+		confirmed, err := confirmRemove(f.IOStreams, bucketName, keys)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return errors.New("deletion cancelled")
+		}
+	}
+
+	if err := removeObjects(ctx, minioClient, bucketName, keys); err != nil {
+		return err
+	}
+	fmt.Fprintf(f.IOStreams.Out, "Deleted %d objects\n", len(keys))
+	return nil
+}
+
+// confirmRemove lists the keys about to be deleted and asks for confirmation
+func confirmRemove(streams *cmdutil.IOStreams, bucketName string, keys []string) (bool, error) {
+	if !streams.IsStdinTTY() {
+		return false, fmt.Errorf("refusing to delete %d objects without confirmation, use --yes", len(keys))
+	}
+
+	title := strings.Builder{}
+	fmt.Fprintf(&title, "Delete %d objects from bucket %s?\n", len(keys), bucketName)
+	for i, key := range keys {
+		if i == maxConfirmKeys {
+			fmt.Fprintf(&title, "  ... and %d more\n", len(keys)-maxConfirmKeys)
+			break
+		}
+		fmt.Fprintf(&title, "  %s\n", key)
+	}
+
+	yes := fmt.Sprintf("Yes, delete %d objects", len(keys))
+	var choice string
+	p := tea.NewProgram(tui_result.NewModel([]string{"No", yes}, title.String(), func(s string) {
+		choice = s
+	}), tea.WithInput(streams.In), tea.WithOutput(streams.ErrOut))
+	if _, err := p.Run(); err != nil {
+		return false, err
+	}
+	return choice == yes, nil
+}
@@ -0,0 +1,80 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// DefaultRetries is the number of times a file is retried after a transient error
+const DefaultRetries = 3
+
+var (
+	// retryBaseDelay is the wait before the first retry, it doubles with
+	// every further attempt up to retryMaxDelay
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// transientS3Codes are S3 error codes worth retrying
+var transientS3Codes = map[string]bool{
+	"InternalError":        true,
+	"RequestTimeout":       true,
+	"ServiceUnavailable":   true,
+	"SlowDown":             true,
+	"Throttling":           true,
+	"RequestLimitExceeded": true,
+}
+
+// retryTransient calls fn until it succeeds, fails with an error that is not
+// transient, or failed retries+1 times. The wait between attempts grows
+// exponentially and ends early when ctx is cancelled.
+func retryTransient(ctx context.Context, retries int, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !isTransientError(err) {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
+
+// isTransientError reports whether err is a network error or a server error
+// that may go away when the request is repeated
+func isTransientError(err error) bool {
+	// Interrupted and timed out operations are not repeated, the context
+	// errors would pass as net.Error below
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) && (resp.Code != "" || resp.StatusCode != 0) {
+		return transientS3Codes[resp.Code] || resp.StatusCode == http.StatusTooManyRequests ||
+			resp.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
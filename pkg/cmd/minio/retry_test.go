@@ -0,0 +1,65 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "slow down", err: minio.ErrorResponse{Code: "SlowDown", StatusCode: 503}, want: true},
+		{name: "server error", err: minio.ErrorResponse{Code: "Unknown", StatusCode: 502}, want: true},
+		{name: "too many requests", err: minio.ErrorResponse{StatusCode: 429}, want: true},
+		{name: "access denied", err: minio.ErrorResponse{Code: "AccessDenied", StatusCode: 403}, want: false},
+		{name: "wrapped network error", err: fmt.Errorf("failed to upload: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), want: true},
+		{name: "unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "missing file", err: errors.New("failed to open file a.txt: no such file"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Errorf("isTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	defer func(delay time.Duration) { retryBaseDelay = delay }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+	transient := minio.ErrorResponse{Code: "SlowDown", StatusCode: 503}
+
+	calls := 0
+	err := retryTransient(context.Background(), 3, func() error {
+		if calls++; calls < 3 {
+			return transient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("retryTransient() = %v after %d calls, want success after 3", err, calls)
+	}
+
+	calls = 0
+	if err := retryTransient(context.Background(), 2, func() error { calls++; return transient }); err == nil || calls != 3 {
+		t.Errorf("retryTransient() = %v after %d calls, want error after 3", err, calls)
+	}
+
+	calls = 0
+	if err := retryTransient(context.Background(), 5, func() error { calls++; return errors.New("permanent") }); err == nil || calls != 1 {
+		t.Errorf("retryTransient() = %v after %d calls, want no retry of permanent errors", err, calls)
+	}
+}
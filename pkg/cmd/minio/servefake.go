@@ -0,0 +1,97 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/gogodjzhu/gogobox/pkg/s3fake"
+	"github.com/spf13/cobra"
+)
+
+type ServeFakeOptions struct {
+	Addr    string
+	Buckets []string
+}
+
+func NewCmdMinIOServeFake(f *cmdutil.Factory) *cobra.Command {
+	opts := &ServeFakeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve-fake [flags]",
+		Short: "Run an in-memory S3 server for testing",
+		Long: `Run an in-memory, S3 compatible server for testing.
+
+The server keeps objects in memory, they are lost when it stops. It supports
+the requests the minio commands send, addressed in path style; signatures are
+not checked, so any access key and secret key are accepted. It runs until it
+is interrupted.
+
+Go tests can run the same server in-process with the s3fake package.`,
+		Example: `  # Serve the bucket "test" on port 9000
+  gogobox minio serve-fake -b test
+
+  # Use it from another terminal
+  gogobox minio upload -e 127.0.0.1:9000 -a fake -s fake -b test photo.png`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServeFake(cmd.Context(), f, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", "127.0.0.1:9000", "Address to listen on")
+	cmd.Flags().StringSliceVarP(&opts.Buckets, "bucket", "b", []string{"gogobox"}, "Buckets to create, more can be created with 'gogobox minio mb'")
+
+	return cmd
+}
+
+func runServeFake(ctx context.Context, f *cmdutil.Factory, opts *ServeFakeOptions) error {
+	for _, bucket := range opts.Buckets {
+		if bucket == "" || strings.ContainsAny(bucket, `/\`) {
+			return fmt.Errorf("invalid bucket name '%s'", bucket)
+		}
+	}
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+	}
+	return serveFake(ctx, f, opts, listener)
+}
+
+// serveFake serves a fake S3 server on listener until ctx is cancelled
+func serveFake(ctx context.Context, f *cmdutil.Factory, opts *ServeFakeOptions, listener net.Listener) error {
+	server := &http.Server{Handler: s3fake.New(opts.Buckets...), ReadHeaderTimeout: 10 * time.Second}
+
+	endpoint := listener.Addr().String()
+	fmt.Fprintf(f.IOStreams.Out, "Fake S3 server listening on http://%s\n", endpoint)
+	if len(opts.Buckets) > 0 {
+		fmt.Fprintf(f.IOStreams.Out, "Buckets: %s\n", strings.Join(opts.Buckets, ", "))
+		fmt.Fprintf(f.IOStreams.Out, "Try: gogobox minio ls -e %s -a fake -s fake -b %s\n", endpoint, opts.Buckets[0])
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Serve(listener)
+	}()
+	select {
+	case err := <-errc:
+		return fmt.Errorf("fake S3 server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	fmt.Fprintln(f.IOStreams.Out, "Fake S3 server stopped")
+	return nil
+}
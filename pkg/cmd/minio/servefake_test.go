@@ -0,0 +1,56 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestServeFake(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverOut := &bytes.Buffer{}
+	done := make(chan error, 1)
+	go func() {
+		opts := &ServeFakeOptions{Buckets: []string{"photos"}}
+		done <- serveFake(ctx, &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: serverOut}}, opts, listener)
+	}()
+
+	cfg := &MinIOConfig{Endpoint: listener.Addr().String(), AccessKeyID: "fake", SecretAccessKey: "fake", BucketName: "photos"}
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	if err := runUpload(ctx, f, &UploadOptions{Config: cfg, KeyTemplate: "{basename}.{ext}"}, writeTestFiles(t, 2)); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+	out.Reset()
+	if err := runList(ctx, f, &ListOptions{Config: cfg, Output: OutputTable}, ""); err != nil {
+		t.Fatalf("runList() unexpected error: %v", err)
+	}
+	for _, want := range []string{"file0.txt", "file1.txt"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("runList() output missing %s:\n%s", want, out.String())
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serveFake() unexpected error: %v", err)
+	}
+	for _, want := range []string{"listening on http://" + cfg.Endpoint, "Buckets: photos", "Fake S3 server stopped"} {
+		if !strings.Contains(serverOut.String(), want) {
+			t.Errorf("serveFake() output missing %q:\n%s", want, serverOut.String())
+		}
+	}
+
+	if err := runServeFake(context.Background(), f, &ServeFakeOptions{Addr: "127.0.0.1:0", Buckets: []string{"a/b"}}); err == nil {
+		t.Errorf("runServeFake() expected error for an invalid bucket name")
+	}
+}
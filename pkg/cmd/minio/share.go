@@ -0,0 +1,130 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmd/timefmt"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultExpires is the default lifetime of presigned URLs
+	DefaultExpires = "24h"
+
+	// maxPresignExpiry is the longest lifetime S3 accepts for presigned URLs
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+type ShareOptions struct {
+	Config  *MinIOConfig
+	Expires string
+	Put     bool
+}
+
+func NewCmdMinIOShare(f *cmdutil.Factory) *cobra.Command {
+	opts := &ShareOptions{
+		Config:  NewDefaultConfig(),
+		Expires: DefaultExpires,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "share [flags] <object1> [object2] ...",
+		Short: "Create presigned URLs for objects in private buckets",
+		Long: `Create presigned URLs that grant temporary access to objects without
+making the bucket public.
+
+By default the URLs download the objects. With --put they upload to the given
+keys instead, so others can add objects to the bucket without credentials.
+
+--expires accepts durations like "90m", "24h", "7d" or "1w" (at most 7 days).`,
+		Example: `  # Share an object for one day
+  gogobox minio share -e localhost:9000 -a mykey -s mysecret -b mybucket 202401/report.pdf
+
+  # Let someone upload a file within the next two hours
+  gogobox minio share --put --expires 2h incoming/upload.zip`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runShare(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	// Share options flags
+	cmd.Flags().StringVar(&opts.Expires, "expires", DefaultExpires, "Lifetime of the URLs, e.g. 90m, 24h or 7d")
+	cmd.Flags().BoolVar(&opts.Put, "put", false, "Create URLs for uploading instead of downloading")
+
+	return cmd
+}
+
+func runShare(ctx context.Context, f *cmdutil.Factory, opts *ShareOptions, objectNames []string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	expires, err := parseExpiry(opts.Expires)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	minioClient, err := newClient(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+
+	for _, objectName := range objectNames {
+		var url string
+		if opts.Put {
+			url, err = presignedPutURL(ctx, minioClient, opts.Config.BucketName, objectName, expires)
+		} else {
+			// A URL for a missing object would only fail for the recipient
+			if _, err := minioClient.StatObject(ctx, opts.Config.BucketName, objectName, minio.StatObjectOptions{}); err != nil {
+				return fmt.Errorf("failed to stat object %s: %w", objectName, err)
+			}
+			url, err = presignedGetURL(ctx, minioClient, opts.Config.BucketName, objectName, expires)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(f.IOStreams.Out, url)
+	}
+	return nil
+}
+
+// parseExpiry parses the lifetime of presigned URLs
+func parseExpiry(expires string) (time.Duration, error) {
+	d, err := (&timefmt.TimeFormatter{}).ParseDuration(expires)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --expires: %w", err)
+	}
+	if d < time.Second || d > maxPresignExpiry {
+		return 0, fmt.Errorf("invalid --expires %s: must be between 1s and 7d", expires)
+	}
+	return d, nil
+}
+
+// presignedGetURL returns a URL downloading the object until it expires
+func presignedGetURL(ctx context.Context, client *minio.Client, bucketName, objectName string, expires time.Duration) (string, error) {
+	u, err := client.PresignedGetObject(ctx, bucketName, objectName, expires, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign %s: %w", objectName, err)
+	}
+	return u.String(), nil
+}
+
+// presignedPutURL returns a URL uploading to the object key until it expires
+func presignedPutURL(ctx context.Context, client *minio.Client, bucketName, objectName string, expires time.Duration) (string, error) {
+	u, err := client.PresignedPutObject(ctx, bucketName, objectName, expires)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign %s: %w", objectName, err)
+	}
+	return u.String(), nil
+}
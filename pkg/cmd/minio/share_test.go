@@ -0,0 +1,111 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestRunShare(t *testing.T) {
+	_, cfg := newFakeS3(t, map[string][]byte{"docs/report.pdf": []byte("report")})
+
+	tests := []struct {
+		name        string
+		opts        ShareOptions
+		object      string
+		wantExpires string
+		wantErr     string
+	}{
+		{name: "get", opts: ShareOptions{Expires: "24h"}, object: "docs/report.pdf", wantExpires: "86400"},
+		{name: "get in days", opts: ShareOptions{Expires: "7d"}, object: "docs/report.pdf", wantExpires: "604800"},
+		{name: "put of new key", opts: ShareOptions{Expires: "90m", Put: true}, object: "incoming/new.zip", wantExpires: "5400"},
+		{name: "get of missing object", opts: ShareOptions{Expires: "24h"}, object: "missing.txt", wantErr: "failed to stat object missing.txt"},
+		{name: "too long", opts: ShareOptions{Expires: "8d"}, object: "docs/report.pdf", wantErr: "between 1s and 7d"},
+		{name: "invalid", opts: ShareOptions{Expires: "tomorrow"}, object: "docs/report.pdf", wantErr: "invalid --expires"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+			opts := tt.opts
+			opts.Config = cfg
+
+			err := runShare(context.Background(), f, &opts, []string{tt.object})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("runShare() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runShare() unexpected error: %v", err)
+			}
+
+			u, err := url.Parse(strings.TrimSpace(out.String()))
+			if err != nil {
+				t.Fatalf("runShare() printed an invalid URL %q: %v", out.String(), err)
+			}
+			if u.Path != "/"+cfg.BucketName+"/"+tt.object {
+				t.Errorf("URL path = %s, want the object path", u.Path)
+			}
+			query := u.Query()
+			if query.Get("X-Amz-Expires") != tt.wantExpires || query.Get("X-Amz-Signature") == "" {
+				t.Errorf("URL query = %v, want signature expiring in %ss", query, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestObjectURLsPresign(t *testing.T) {
+	_, cfg := newFakeS3(t, map[string][]byte{"a.txt": []byte("shared content")})
+	store := newTestStore(t, cfg)
+
+	public, err := objectURLs(context.Background(), store, &UploadOptions{Config: cfg, PrintURLs: true}, []string{"a.txt"})
+	if err != nil {
+		t.Fatalf("objectURLs() unexpected error: %v", err)
+	}
+	if public[0] != cfg.GetObjectURL("a.txt") {
+		t.Errorf("objectURLs() = %v, want the public URL", public)
+	}
+
+	presigned, err := objectURLs(context.Background(), store, &UploadOptions{Config: cfg, PrintURLs: true, Presign: true, Expires: "1h"}, []string{"a.txt"})
+	if err != nil {
+		t.Fatalf("objectURLs() unexpected error: %v", err)
+	}
+	if !strings.Contains(presigned[0], "X-Amz-Expires=3600") {
+		t.Errorf("objectURLs() = %v, want a presigned URL", presigned)
+	}
+
+	// The URL addresses the object (the fake does not check signatures)
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	resp, err := httpClient.Get(presigned[0])
+	if err != nil {
+		t.Fatalf("GET presigned URL: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "shared content" {
+		t.Errorf("GET presigned URL = %q, want object content", body)
+	}
+}
+
+func TestRunUploadInvalidExpires(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{Config: cfg, Presign: true, Expires: "30d"}
+
+	if err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1)); err == nil || !strings.Contains(err.Error(), "--expires") {
+		t.Errorf("runUpload() error = %v, want expires error", err)
+	}
+	if keys := fake.keys(); len(keys) != 0 {
+		t.Errorf("objects were uploaded despite invalid --expires: %v", keys)
+	}
+}
@@ -0,0 +1,251 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"github.com/spf13/cobra"
+)
+
+// statHeaders are the standard object headers shown by stat
+var statHeaders = []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Expires"}
+
+type StatOptions struct {
+	Config *MinIOConfig
+	Output string
+}
+
+type CatOptions struct {
+	Config *MinIOConfig
+}
+
+// objectStat is the stat representation of an object
+type objectStat struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"lastModified"`
+	ETag         string            `json:"etag"`
+	ContentType  string            `json:"contentType"`
+	StorageClass string            `json:"storageClass,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	UserMetadata map[string]string `json:"userMetadata,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+func NewCmdMinIOStat(f *cmdutil.Factory) *cobra.Command {
+	opts := &StatOptions{
+		Config: NewDefaultConfig(),
+		Output: OutputText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "stat [flags] <object1> [object2] ...",
+		Short: "Show object metadata",
+		Long: `Show the metadata of objects: size, modification time, ETag, content
+type, headers such as Cache-Control, user metadata (x-amz-meta-*) and tags.`,
+		Example: `  # Show the metadata of an object
+  gogobox minio stat 202401/report.pdf
+
+  # Print the user metadata as JSON
+  gogobox minio stat -o json 202401/report.pdf | jq .userMetadata`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runStat(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputText, "Output format: text or json")
+
+	return cmd
+}
+
+func NewCmdMinIOCat(f *cmdutil.Factory) *cobra.Command {
+	opts := &CatOptions{
+		Config: NewDefaultConfig(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "cat [flags] <object1> [object2] ...",
+		Short: "Print objects to stdout",
+		Long:  `Print the content of objects to stdout, one after another, so they can be piped.`,
+		Example: `  # Search a log file without downloading it
+  gogobox minio cat logs/app.log | grep ERROR`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runCat(cmd.Context(), f, opts, args)
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	return cmd
+}
+
+func runStat(ctx context.Context, f *cmdutil.Factory, opts *StatOptions, objectNames []string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	switch opts.Output {
+	case OutputText, OutputJSON:
+	default:
+		return fmt.Errorf("unsupported output format: %s", opts.Output)
+	}
+
+	minioClient, err := newClient(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+
+	stats := make([]objectStat, 0, len(objectNames))
+	for _, objectName := range objectNames {
+		stat, err := statObject(ctx, minioClient, opts.Config.BucketName, objectName)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, stat)
+	}
+
+	if opts.Output == OutputJSON {
+		if len(stats) == 1 {
+			return writeJSON(f.IOStreams.Out, stats[0])
+		}
+		return writeJSON(f.IOStreams.Out, stats)
+	}
+	for i, stat := range stats {
+		if i > 0 {
+			fmt.Fprintln(f.IOStreams.Out)
+		}
+		if err := writeObjectStat(f.IOStreams.Out, stat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statObject collects the metadata and tags of an object
+func statObject(ctx context.Context, client *minio.Client, bucketName, objectName string) (objectStat, error) {
+	info, err := client.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return objectStat{}, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+
+	stat := objectStat{
+		Key:          info.Key,
+		Size:         info.Size,
+		LastModified: info.LastModified,
+		ETag:         info.ETag,
+		ContentType:  info.ContentType,
+		StorageClass: info.StorageClass,
+		Metadata:     map[string]string{},
+		UserMetadata: map[string]string{},
+	}
+	for _, name := range statHeaders {
+		if v := info.Metadata.Get(name); v != "" {
+			stat.Metadata[name] = v
+		}
+	}
+	for name, values := range info.Metadata {
+		if key := strings.TrimPrefix(name, "X-Amz-Meta-"); key != name && len(values) > 0 {
+			stat.UserMetadata[strings.ToLower(key)] = values[0]
+		}
+	}
+
+	stat.Tags, err = getObjectTags(ctx, client, bucketName, objectName)
+	if err != nil {
+		return objectStat{}, err
+	}
+	return stat, nil
+}
+
+// getObjectTags returns the tags of an object, servers without tagging
+// support report none
+func getObjectTags(ctx context.Context, client *minio.Client, bucketName, objectName string) (map[string]string, error) {
+	tags, err := client.GetObjectTagging(ctx, bucketName, objectName, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		resp := minio.ToErrorResponse(err)
+		if resp.Code == "NotImplemented" || resp.StatusCode == http.StatusNotImplemented {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get tags of object %s: %w", objectName, err)
+	}
+	return tags.ToMap(), nil
+}
+
+func writeObjectStat(out io.Writer, stat objectStat) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Key:\t%s\n", stat.Key)
+	fmt.Fprintf(w, "Size:\t%s (%d bytes)\n", util.HumanSize(stat.Size), stat.Size)
+	fmt.Fprintf(w, "Last modified:\t%s\n", stat.LastModified.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "ETag:\t%s\n", stat.ETag)
+	fmt.Fprintf(w, "Content type:\t%s\n", stat.ContentType)
+	if stat.StorageClass != "" {
+		fmt.Fprintf(w, "Storage class:\t%s\n", stat.StorageClass)
+	}
+	for _, section := range []struct {
+		title  string
+		values map[string]string
+	}{
+		{"Metadata", stat.Metadata},
+		{"User metadata", stat.UserMetadata},
+		{"Tags", stat.Tags},
+	} {
+		if len(section.values) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\t\n", section.title)
+		keys := make([]string, 0, len(section.values))
+		for key := range section.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s:\t%s\n", key, section.values[key])
+		}
+	}
+	return w.Flush()
+}
+
+func runCat(ctx context.Context, f *cmdutil.Factory, opts *CatOptions, objectNames []string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	minioClient, err := newClient(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+
+	for _, objectName := range objectNames {
+		object, err := minioClient.GetObject(ctx, opts.Config.BucketName, objectName, minio.GetObjectOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get object %s: %w", objectName, err)
+		}
+		_, err = io.Copy(f.IOStreams.Out, object)
+		object.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read object %s: %w", objectName, err)
+		}
+	}
+	return nil
+}
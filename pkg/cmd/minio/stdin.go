@@ -0,0 +1,112 @@
+package minio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+// StdinSource is the file argument that uploads stdin
+const StdinSource = "-"
+
+// streamPartSize is the part size of streams of unknown size, such as stdin,
+// without --part-size. Every part is buffered in memory; 16MiB parts allow
+// objects of up to 160GiB.
+const streamPartSize = 16 * 1024 * 1024
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// validateStdinUpload rejects options that need the whole content before the
+// upload starts, which a stream cannot provide
+func validateStdinUpload(opts *UploadOptions, tmpl *keyTemplate) error {
+	switch {
+	case opts.Journal != "":
+		return errors.New("stdin uploads cannot be journaled")
+	case opts.Dedupe || tmpl.Has("sha256"):
+		return errors.New("stdin uploads cannot be named by content hash")
+	case opts.FromClipboard:
+		return errors.New("stdin and --from-clipboard cannot be uploaded together")
+	}
+	return nil
+}
+
+// uploadStdin streams f.IOStreams.In as a single object named after
+// opts.Name. Its size is unknown, so it is uploaded in parts as it is read;
+// it is neither resized nor retried.
+func uploadStdin(ctx context.Context, f *cmdutil.Factory, opts *UploadOptions, tmpl *keyTemplate) error {
+	if err := validateStdinUpload(opts, tmpl); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "stdin"
+	}
+	source := uploadSource{Path: StdinSource, RelPath: name}
+	objectName, err := tmpl.Render(source, name, 0, time.Now())
+	if err != nil {
+		return fmt.Errorf("file processing error: %w", err)
+	}
+
+	store, err := newStore(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+	objectNames := []string{objectName}
+	if err := resolveKeyConflicts(ctx, store, tmpl, []uploadSource{source}, objectNames, opts.IfExists); err != nil {
+		return fmt.Errorf("upload error: %w", err)
+	}
+
+	// The content type is detected from the first bytes of the stream
+	reader := bufio.NewReaderSize(f.IOStreams.In, 512)
+	header, err := reader.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+	putOpts := opts.metadata.ForStream(name, header).putOptions()
+	putOpts.PartSize = uint64(opts.PartSize)
+	if putOpts.PartSize == 0 {
+		putOpts.PartSize = streamPartSize
+	}
+
+	counter := &countingReader{r: reader}
+	body, size, err := opts.encryption.seal(counter, -1, &putOpts)
+	if err != nil {
+		return err
+	}
+	started := time.Now()
+	if err := store.Put(ctx, objectNames[0], body, size, putOpts); err != nil {
+		return fmt.Errorf("upload error: failed to upload stdin: %w", err)
+	}
+
+	results := []uploadResult{{
+		Source:   StdinSource,
+		Key:      objectNames[0],
+		Status:   uploadSucceeded,
+		Duration: time.Since(started),
+	}}
+	records, err := uploadRecords(ctx, store, opts, []string{StdinSource}, []int64{counter.n}, objectNames, results)
+	if err != nil {
+		return err
+	}
+	records[0].name = name
+	if records[0].ContentType == "" {
+		records[0].ContentType = putOpts.ContentType
+	}
+	return writeUploadRecords(f.IOStreams.Out, opts, records)
+}
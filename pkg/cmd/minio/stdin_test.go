@@ -0,0 +1,95 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestRunUploadStdin(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	big := bytes.Repeat([]byte("0123456789abcdef"), 400*1024) // 6.25MiB, two parts
+
+	tests := []struct {
+		name            string
+		input           []byte
+		opts            UploadOptions
+		wantKey         string
+		wantContentType string
+		wantOut         string
+	}{
+		{
+			name:            "default name",
+			input:           []byte("hello from a pipe\n"),
+			opts:            UploadOptions{KeyTemplate: "{basename}.{ext}"},
+			wantKey:         "stdin.bin",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "named image",
+			input:           png,
+			opts:            UploadOptions{KeyTemplate: "{basename}.{ext}", Name: "shot.png", PrintURLs: true, Format: "markdown"},
+			wantKey:         "shot.png",
+			wantContentType: "image/png",
+			wantOut:         "![shot.png](",
+		},
+		{
+			name:            "multipart",
+			input:           big,
+			opts:            UploadOptions{KeyTemplate: "{basename}.{ext}", Name: "dump.sql", PartSize: 5 * 1024 * 1024},
+			wantKey:         "dump.sql",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, cfg := newFakeS3(t, nil)
+			out := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{In: bytes.NewReader(tt.input), Out: out}}
+			opts := tt.opts
+			opts.Config = cfg
+
+			if err := runUpload(context.Background(), f, &opts, []string{StdinSource}); err != nil {
+				t.Fatalf("runUpload() unexpected error: %v", err)
+			}
+			if got := fake.object(tt.wantKey); !bytes.Equal(got, tt.input) {
+				t.Fatalf("object %s has %d bytes, want %d", tt.wantKey, len(got), len(tt.input))
+			}
+			if got := fake.header(tt.wantKey).Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("stored Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRunUploadStdinErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    UploadOptions
+		wantErr string
+	}{
+		{"journal", UploadOptions{Journal: "upload.journal"}, "cannot be journaled"},
+		{"dedupe", UploadOptions{Dedupe: true}, "content hash"},
+		{"sha256 key", UploadOptions{KeyTemplate: "{sha256}.{ext}"}, "content hash"},
+		{"clipboard", UploadOptions{FromClipboard: true}, "--from-clipboard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cfg := newFakeS3(t, nil)
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{In: strings.NewReader("data"), Out: &bytes.Buffer{}}}
+			opts := tt.opts
+			opts.Config = cfg
+
+			err := runUpload(context.Background(), f, &opts, []string{StdinSource})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runUpload() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
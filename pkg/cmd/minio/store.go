@@ -0,0 +1,168 @@
+package minio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Storage backends
+const (
+	BackendS3     = "s3"
+	BackendLocal  = "local"
+	BackendWebDAV = "webdav"
+)
+
+// ObjectStore is the storage the upload, download, ls and sync commands work
+// on. Objects are described with the types of minio-go whatever the backend;
+// the local and WebDAV backends do not keep object metadata, content types
+// follow from the extension of the key.
+type ObjectStore interface {
+	// Put stores size bytes of r as key, size is -1 if unknown
+	Put(ctx context.Context, key string, r io.Reader, size int64, opts minio.PutObjectOptions) error
+
+	// Get opens key for reading, honouring the range and ETag condition of opts
+	Get(ctx context.Context, key string, opts minio.GetObjectOptions) (io.ReadCloser, error)
+
+	// Stat returns the description of key. Missing keys fail with an error
+	// isNotFound reports.
+	Stat(ctx context.Context, key string, opts minio.StatObjectOptions) (minio.ObjectInfo, error)
+
+	// List returns the objects whose keys start with prefix. Unless
+	// recursive, objects below the next "/" are returned as one common
+	// prefix, a key ending with "/".
+	List(ctx context.Context, prefix string, recursive bool) ([]minio.ObjectInfo, error)
+
+	// Delete removes key, missing keys are not an error
+	Delete(ctx context.Context, key string) error
+
+	// URL returns the public URL of key
+	URL(key string) string
+}
+
+// backendName returns the backend of the configuration, S3 if none is set
+func (c *MinIOConfig) backendName() string {
+	if c.Backend == "" {
+		return BackendS3
+	}
+	return c.Backend
+}
+
+// newStore opens the store of the configured backend and makes sure the
+// configured bucket exists
+func newStore(ctx context.Context, cfg *MinIOConfig) (ObjectStore, error) {
+	switch cfg.backendName() {
+	case BackendLocal:
+		return newLocalStore(cfg)
+	case BackendWebDAV:
+		return newWebDAVStore(ctx, cfg)
+	}
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &minioStore{client: client, cfg: cfg}, nil
+}
+
+// requireS3Backend fails unless cfg uses the S3 backend, for the features
+// the other backends lack
+func requireS3Backend(cfg *MinIOConfig, feature string) error {
+	if backend := cfg.backendName(); backend != BackendS3 {
+		return fmt.Errorf("%s is only supported by the %s backend, not by %s", feature, BackendS3, backend)
+	}
+	return nil
+}
+
+// s3Client returns the client of an S3 store
+func s3Client(store ObjectStore, feature string) (*minio.Client, error) {
+	s, ok := store.(*minioStore)
+	if !ok {
+		return nil, fmt.Errorf("%s is only supported by the %s backend", feature, BackendS3)
+	}
+	return s.client, nil
+}
+
+// isNotFound reports whether err tells that an object does not exist
+func isNotFound(err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NotFound"
+}
+
+// minioStore keeps objects in the bucket of an S3 server
+type minioStore struct {
+	client *minio.Client
+	cfg    *MinIOConfig
+}
+
+func (s *minioStore) Put(ctx context.Context, key string, r io.Reader, size int64, opts minio.PutObjectOptions) error {
+	_, err := s.client.PutObject(ctx, s.cfg.BucketName, key, r, size, opts)
+	return err
+}
+
+func (s *minioStore) Get(ctx context.Context, key string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, s.cfg.BucketName, key, opts)
+}
+
+func (s *minioStore) Stat(ctx context.Context, key string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	return s.client.StatObject(ctx, s.cfg.BucketName, key, opts)
+}
+
+func (s *minioStore) List(ctx context.Context, prefix string, recursive bool) ([]minio.ObjectInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects []minio.ObjectInfo
+	listOpts := minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive, WithMetadata: true}
+	for object := range s.client.ListObjects(ctx, s.cfg.BucketName, listOpts) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func (s *minioStore) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.cfg.BucketName, key, minio.RemoveObjectOptions{})
+}
+
+func (s *minioStore) URL(key string) string {
+	return s.cfg.GetObjectURL(key)
+}
+
+// storeReader reads r until ctx is cancelled and reports the bytes read to
+// progress, the way minio-go reports uploaded bytes to
+// minio.PutObjectOptions.Progress
+type storeReader struct {
+	ctx      context.Context
+	r        io.Reader
+	progress io.Reader
+}
+
+func (s *storeReader) Read(p []byte) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := s.r.Read(p)
+	if n > 0 && s.progress != nil {
+		io.CopyN(io.Discard, s.progress, int64(n))
+	}
+	return n, err
+}
+
+// commonPrefix returns the common prefix key is listed under when listing
+// prefix non-recursively, or "" if key is listed itself
+func commonPrefix(prefix, key string) string {
+	if i := strings.Index(key[len(prefix):], "/"); i >= 0 {
+		return key[:len(prefix)+i+1]
+	}
+	return ""
+}
@@ -0,0 +1,302 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
+	"golang.org/x/net/webdav"
+)
+
+// newLocalTestConfig returns the configuration of an empty local bucket
+func newLocalTestConfig(t *testing.T) *MinIOConfig {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "bucket"), 0755); err != nil {
+		t.Fatalf("Failed to create bucket directory: %v", err)
+	}
+	return &MinIOConfig{Backend: BackendLocal, Endpoint: dir, BucketName: "bucket"}
+}
+
+// newWebDAVTestConfig returns the configuration of an empty bucket on a
+// WebDAV server that requires basic auth
+func newWebDAVTestConfig(t *testing.T) *MinIOConfig {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "bucket"), 0755); err != nil {
+		t.Fatalf("Failed to create bucket directory: %v", err)
+	}
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.Dir(dir),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return &MinIOConfig{Backend: BackendWebDAV, Endpoint: server.URL + "/dav", BucketName: "bucket", AccessKeyID: "user", SecretAccessKey: "pass"}
+}
+
+// listKeys returns the keys listed below prefix
+func listKeys(t *testing.T, store ObjectStore, prefix string, recursive bool) string {
+	objects, err := store.List(context.Background(), prefix, recursive)
+	if err != nil {
+		t.Fatalf("List(%q) unexpected error: %v", prefix, err)
+	}
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestObjectStores(t *testing.T) {
+	backends := []struct {
+		name string
+		cfg  func(t *testing.T) *MinIOConfig
+	}{
+		{name: BackendLocal, cfg: newLocalTestConfig},
+		{name: BackendWebDAV, cfg: newWebDAVTestConfig},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newTestStore(t, backend.cfg(t))
+
+			// Known and unknown sizes, progress is reported for both
+			progress := &countingReader{r: bytes.NewReader(make([]byte, 64))}
+			putOpts := minio.PutObjectOptions{ContentType: "text/plain", Progress: progress}
+			if err := store.Put(ctx, "a.txt", strings.NewReader("hello"), 5, putOpts); err != nil {
+				t.Fatalf("Put() unexpected error: %v", err)
+			}
+			for _, key := range []string{"dir/c.txt", "dir/sub/b.png"} {
+				if err := store.Put(ctx, key, strings.NewReader(key), -1, minio.PutObjectOptions{Progress: progress}); err != nil {
+					t.Fatalf("Put(%s) unexpected error: %v", key, err)
+				}
+			}
+			if want := int64(5 + len("dir/c.txt") + len("dir/sub/b.png")); progress.n != want {
+				t.Errorf("progress = %d bytes, want %d", progress.n, want)
+			}
+
+			info, err := store.Stat(ctx, "a.txt", minio.StatObjectOptions{})
+			if err != nil {
+				t.Fatalf("Stat() unexpected error: %v", err)
+			}
+			if info.Size != 5 || !strings.HasPrefix(info.ContentType, "text/plain") || info.ETag == "" || info.LastModified.IsZero() {
+				t.Errorf("Stat() = %+v, want a 5 byte text/plain object", info)
+			}
+			for _, key := range []string{"missing.txt", "dir"} {
+				if _, err := store.Stat(ctx, key, minio.StatObjectOptions{}); !isNotFound(err) {
+					t.Errorf("Stat(%s) error = %v, want not found", key, err)
+				}
+			}
+
+			// Ranges resume downloads of the same version only
+			getOpts := minio.GetObjectOptions{}
+			getOpts.SetMatchETag(info.ETag)
+			getOpts.SetRange(2, 0)
+			object, err := store.Get(ctx, "a.txt", getOpts)
+			if err != nil {
+				t.Fatalf("Get() unexpected error: %v", err)
+			}
+			data, err := io.ReadAll(object)
+			object.Close()
+			if err != nil || string(data) != "llo" {
+				t.Errorf("Get() = %q, %v, want \"llo\"", data, err)
+			}
+			getOpts.SetMatchETag("changed")
+			if object, err := store.Get(ctx, "a.txt", getOpts); err == nil {
+				object.Close()
+				t.Errorf("Get() expected error for a changed ETag")
+			}
+			if _, err := store.Get(ctx, "missing.txt", minio.GetObjectOptions{}); !isNotFound(err) {
+				t.Errorf("Get() error = %v, want not found", err)
+			}
+
+			lists := []struct {
+				prefix    string
+				recursive bool
+				want      string
+			}{
+				{prefix: "", want: "a.txt,dir/"},
+				{prefix: "", recursive: true, want: "a.txt,dir/c.txt,dir/sub/b.png"},
+				{prefix: "dir/", want: "dir/c.txt,dir/sub/"},
+				{prefix: "dir/s", recursive: true, want: "dir/sub/b.png"},
+				{prefix: "nothing/", recursive: true, want: ""},
+			}
+			for _, list := range lists {
+				if got := listKeys(t, store, list.prefix, list.recursive); got != list.want {
+					t.Errorf("List(%q, %t) = %s, want %s", list.prefix, list.recursive, got, list.want)
+				}
+			}
+
+			for _, key := range []string{"dir/sub/b.png", "missing.txt"} {
+				if err := store.Delete(ctx, key); err != nil {
+					t.Errorf("Delete(%s) unexpected error: %v", key, err)
+				}
+			}
+			if got := listKeys(t, store, "", true); got != "a.txt,dir/c.txt" {
+				t.Errorf("List() after Delete() = %s, want a.txt,dir/c.txt", got)
+			}
+
+			// Cancelled uploads leave nothing behind
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			if err := store.Put(cancelled, "cancelled.txt", strings.NewReader("data"), 4, minio.PutObjectOptions{}); err == nil {
+				t.Errorf("Put() expected error for a cancelled context")
+			}
+			if _, err := store.Stat(ctx, "cancelled.txt", minio.StatObjectOptions{}); !isNotFound(err) {
+				t.Errorf("cancelled Put() stored the object: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewStoreErrors(t *testing.T) {
+	local := newLocalTestConfig(t)
+	local.BucketName = "missing"
+	if _, err := newStore(context.Background(), local); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("newStore() error = %v, want missing bucket", err)
+	}
+
+	dav := newWebDAVTestConfig(t)
+	dav.BucketName = "missing"
+	if _, err := newStore(context.Background(), dav); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("newStore() error = %v, want missing bucket", err)
+	}
+	dav.BucketName, dav.SecretAccessKey = "bucket", "wrong"
+	if _, err := newStore(context.Background(), dav); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("newStore() error = %v, want unauthorized", err)
+	}
+
+	// S3 only commands refuse the other backends
+	if _, err := newClient(context.Background(), newLocalTestConfig(t)); err == nil || !strings.Contains(err.Error(), "only supported by the s3 backend") {
+		t.Errorf("newClient() error = %v, want unsupported backend", err)
+	}
+
+	// Keys cannot escape the bucket directory
+	store := newTestStore(t, newLocalTestConfig(t))
+	if err := store.Put(context.Background(), "../escaped.txt", strings.NewReader("x"), 1, minio.PutObjectOptions{}); err == nil {
+		t.Errorf("Put() expected error for a key outside the bucket")
+	}
+}
+
+func TestWebDAVETag(t *testing.T) {
+	tests := map[string]string{
+		`"17e2f3a4b5c6d7e8"`:                 "17e2f3a4b5c6d7e8",
+		`"1a2b-5f3c4d5e6f7a8"`:               "1a2b-5f3c4d5e6f7a8",
+		"plain_etag":                         "plain_etag",
+		`W/"17e2f3a4b5c6d7e8"`:               "",
+		`"../../etc/passwd"`:                 "",
+		`"a/b"`:                              "",
+		`"v1.2"`:                             "",
+		"":                                   "",
+		`"` + strings.Repeat("a", 129) + `"`: "",
+	}
+	for etag, want := range tests {
+		if got := webdavETag(etag); got != want {
+			t.Errorf("webdavETag(%q) = %q, want %q", etag, got, want)
+		}
+	}
+}
+
+func TestRunUploadLocalBackend(t *testing.T) {
+	cfg := newLocalTestConfig(t)
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	files := writeTestFiles(t, 2)
+
+	opts := &UploadOptions{Config: cfg, KeyTemplate: "uploads/{basename}.{ext}", PrintURLs: true}
+	if err := runUpload(context.Background(), f, opts, files); err != nil {
+		t.Fatalf("runUpload() unexpected error: %v", err)
+	}
+	stored, err := os.ReadFile(filepath.Join(cfg.Endpoint, "bucket", "uploads", "file1.txt"))
+	if err != nil || string(stored) != "content 1" {
+		t.Errorf("stored file = %q, %v, want the uploaded content", stored, err)
+	}
+	if want := cfg.GetObjectURL("uploads/file0.txt"); !strings.Contains(out.String(), want) {
+		t.Errorf("runUpload() output = %q, want %s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := runList(context.Background(), f, &ListOptions{Config: cfg, Output: OutputTable, Recursive: true}, "uploads/"); err != nil {
+		t.Fatalf("runList() unexpected error: %v", err)
+	}
+	for _, want := range []string{"uploads/file0.txt", "uploads/file1.txt", "text/plain"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("runList() output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	// Features that need S3 are rejected before anything is uploaded
+	rejected := []struct {
+		flag string
+		opts UploadOptions
+	}{
+		{flag: "--presign", opts: UploadOptions{PrintURLs: true, Presign: true, Expires: "1h"}},
+		{flag: "--meta", opts: UploadOptions{Meta: []string{"author=alice"}}},
+		{flag: "--tag", opts: UploadOptions{Tags: []string{"project=apollo"}}},
+		{flag: "--cache-control", opts: UploadOptions{CacheControl: "max-age=60"}},
+		{flag: "--storage-class", opts: UploadOptions{StorageClass: "STANDARD_IA"}},
+	}
+	for _, tt := range rejected {
+		opts := tt.opts
+		opts.Config, opts.KeyTemplate = cfg, "rejected/{basename}.{ext}"
+		if err := runUpload(context.Background(), f, &opts, files); err == nil || !strings.Contains(err.Error(), tt.flag) {
+			t.Errorf("runUpload() error = %v, want %s rejected", err, tt.flag)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Endpoint, "bucket", "rejected")); !os.IsNotExist(err) {
+		t.Errorf("files were uploaded with unsupported flags")
+	}
+}
+
+func TestRunSyncWebDAVBackend(t *testing.T) {
+	cfg := newWebDAVTestConfig(t)
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"})
+	// WebDAV modification times have a resolution of seconds
+	modTime := time.Now().Add(-time.Hour)
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if err := os.Chtimes(filepath.Join(src, filepath.FromSlash(name)), modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	opts := &SyncOptions{Config: cfg, Concurrency: 2}
+
+	if err := runSync(context.Background(), f, opts, src, ":site"); err != nil {
+		t.Fatalf("runSync() upload unexpected error: %v", err)
+	}
+	if err := runSync(context.Background(), f, opts, ":site", dst); err != nil {
+		t.Fatalf("runSync() download unexpected error: %v", err)
+	}
+	for name, want := range map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"} {
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	out.Reset()
+	if err := runSync(context.Background(), f, opts, src, ":site"); err != nil {
+		t.Fatalf("runSync() second run unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Synced: 0 changes, 2 files unchanged") {
+		t.Errorf("second sync transferred files:\n%s", out.String())
+	}
+}
@@ -0,0 +1,397 @@
+package minio
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// Sync actions
+const (
+	syncUpload       = "upload"
+	syncDownload     = "download"
+	syncDeleteRemote = "delete-remote"
+	syncDeleteLocal  = "delete-local"
+)
+
+type SyncOptions struct {
+	Config      *MinIOConfig
+	Delete      bool
+	DryRun      bool
+	Include     []string
+	Exclude     []string
+	Concurrency int
+	Progress    bool
+}
+
+func NewCmdMinIOSync(f *cmdutil.Factory) *cobra.Command {
+	opts := &SyncOptions{
+		Config:      NewDefaultConfig(),
+		Concurrency: 4,
+		Progress:    true,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "sync [flags] <source> <destination>",
+		Short: "Mirror a local directory and a bucket prefix",
+		Long: `Mirror a local directory to a bucket prefix or a bucket prefix to a local
+directory. The remote side is written with a leading ":", e.g. ":photos/" for
+the prefix photos/ or ":" for the whole bucket.
+
+Files are transferred when they are missing on the destination or differ in
+size. Files of equal size are compared by modification time and, when the
+object's ETag is a plain MD5, by checksum, so touched but unchanged files are
+not transferred again.
+
+Paths relative to the synced directory can be filtered with --include and
+--exclude and with a .gogoboxignore file in the local directory. Patterns
+follow .gitignore rules: patterns without "/" match names at any depth, "**"
+matches across directories, a trailing "/" matches directories only and "!"
+re-includes paths in the ignore file. Like excluded directories, included
+directories apply to everything below them. Excluded paths are never deleted.`,
+		Example: `  # Upload changes of ./photos to the prefix photos/
+  gogobox minio sync ./photos :photos/
+
+  # Mirror the prefix back, removing local files that are gone remotely
+  gogobox minio sync --delete :photos/ ./photos
+
+  # Show what would be uploaded, without temporary files
+  gogobox minio sync --dry-run --exclude "*.tmp" --exclude "cache/" ./site :www/`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runSync(cmd.Context(), f, opts, args[0], args[1])
+		},
+	}
+
+	// MinIO connection flags
+	addConnectionFlags(cmd, opts.Config)
+
+	// Sync options flags
+	cmd.Flags().BoolVar(&opts.Delete, "delete", false, "Delete destination files that do not exist in the source")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print the planned changes without applying them")
+	cmd.Flags().StringArrayVar(&opts.Include, "include", nil, "Only sync paths matching this glob (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.Exclude, "exclude", nil, "Skip paths matching this glob (repeatable)")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", 4, "Number of concurrent transfers")
+	cmd.Flags().BoolVar(&opts.Progress, "progress", true, "Show transfer progress")
+
+	return cmd
+}
+
+// syncEntry is a file on either side of a sync, keyed by its relative path
+type syncEntry struct {
+	Size    int64
+	ModTime time.Time
+	ETag    string
+}
+
+// syncAction is a planned change
+type syncAction struct {
+	Op         string
+	RelPath    string
+	LocalPath  string
+	ObjectName string
+	Size       int64
+	ModTime    time.Time
+}
+
+func runSync(ctx context.Context, f *cmdutil.Factory, opts *SyncOptions, source, destination string) error {
+	// Validate configuration
+	if err := opts.Config.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	var localDir, prefix string
+	var upload bool
+	switch {
+	case strings.HasPrefix(destination, ":") && !strings.HasPrefix(source, ":"):
+		upload = true
+		localDir, prefix = source, destination[1:]
+		if stat, err := os.Stat(localDir); err != nil || !stat.IsDir() {
+			return fmt.Errorf("%s is not a directory", localDir)
+		}
+	case strings.HasPrefix(source, ":") && !strings.HasPrefix(destination, ":"):
+		localDir, prefix = destination, source[1:]
+	default:
+		return fmt.Errorf("exactly one of source and destination must be a bucket prefix starting with ':'")
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	filter, err := newPathFilter(opts.Include, opts.Exclude, filepath.Join(localDir, IgnoreFile))
+	if err != nil {
+		return err
+	}
+
+	store, err := newStore(ctx, opts.Config)
+	if err != nil {
+		return err
+	}
+
+	local, err := listLocalTree(localDir, filter)
+	if err != nil {
+		return err
+	}
+	remote, skipped, err := listRemoteTree(ctx, store, prefix, filter)
+	if err != nil {
+		return err
+	}
+	for _, key := range skipped {
+		fmt.Fprintf(f.IOStreams.Out, "Skipped %s (not a path below %s)\n", key, localDir)
+	}
+
+	actions, unchanged := planSync(upload, localDir, prefix, local, remote, opts.Delete)
+	for _, action := range actions {
+		switch action.Op {
+		case syncUpload:
+			fmt.Fprintf(f.IOStreams.Out, "upload %s -> %s\n", action.LocalPath, action.ObjectName)
+		case syncDownload:
+			fmt.Fprintf(f.IOStreams.Out, "download %s -> %s\n", action.ObjectName, action.LocalPath)
+		case syncDeleteRemote:
+			fmt.Fprintf(f.IOStreams.Out, "delete %s\n", action.ObjectName)
+		case syncDeleteLocal:
+			fmt.Fprintf(f.IOStreams.Out, "delete %s\n", action.LocalPath)
+		}
+	}
+	if opts.DryRun {
+		fmt.Fprintf(f.IOStreams.Out, "Dry run: %d changes, %d files unchanged\n", len(actions), unchanged)
+		return nil
+	}
+
+	errs := applySync(ctx, f, store, opts, actions)
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			fmt.Fprintf(f.IOStreams.Out, "Failed %s: %v\n", actions[i].RelPath, err)
+		}
+	}
+	fmt.Fprintf(f.IOStreams.Out, "Synced: %d changes, %d files unchanged\n", len(actions)-failed, unchanged)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d changes failed", failed, len(actions))
+	}
+	return nil
+}
+
+// listLocalTree returns the regular files below root accepted by filter
+func listLocalTree(root string, filter *pathFilter) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				// Downloading into a new directory
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if !filter.Match(relPath) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries[relPath] = syncEntry{Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", root, err)
+	}
+	return entries, nil
+}
+
+// listRemoteTree returns the objects below prefix accepted by filter. Keys
+// whose relative paths would leave the local directory, such as "../x", are
+// returned as skipped so they are neither downloaded nor deleted.
+func listRemoteTree(ctx context.Context, store ObjectStore, prefix string, filter *pathFilter) (map[string]syncEntry, []string, error) {
+	objects, err := store.List(ctx, prefix, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	entries := map[string]syncEntry{}
+	var skipped []string
+	for _, object := range objects {
+		// Skip directory markers
+		relPath := strings.TrimPrefix(object.Key, prefix)
+		if relPath == "" || strings.HasSuffix(relPath, "/") || !filter.Match(relPath) {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(relPath)) || path.Clean(relPath) != relPath {
+			skipped = append(skipped, object.Key)
+			continue
+		}
+		entries[relPath] = syncEntry{Size: object.Size, ModTime: object.LastModified, ETag: object.ETag}
+	}
+	return entries, skipped, nil
+}
+
+// planSync compares both sides and returns the actions sorted by path,
+// together with the number of unchanged files
+func planSync(upload bool, localDir, prefix string, local, remote map[string]syncEntry, deleteExtraneous bool) ([]syncAction, int) {
+	source, destination := remote, local
+	op, deleteOp := syncDownload, syncDeleteLocal
+	if upload {
+		source, destination = local, remote
+		op, deleteOp = syncUpload, syncDeleteRemote
+	}
+
+	var actions []syncAction
+	unchanged := 0
+	for relPath, src := range source {
+		localPath := filepath.Join(localDir, filepath.FromSlash(relPath))
+		if dst, ok := destination[relPath]; ok {
+			localEntry, remoteEntry := dst, src
+			if upload {
+				localEntry, remoteEntry = src, dst
+			}
+			if !syncChanged(localPath, localEntry, remoteEntry, upload) {
+				unchanged++
+				continue
+			}
+		}
+		actions = append(actions, syncAction{Op: op, RelPath: relPath, LocalPath: localPath, ObjectName: prefix + relPath, Size: src.Size, ModTime: src.ModTime})
+	}
+
+	if deleteExtraneous {
+		for relPath := range destination {
+			if _, ok := source[relPath]; !ok {
+				actions = append(actions, syncAction{
+					Op:         deleteOp,
+					RelPath:    relPath,
+					LocalPath:  filepath.Join(localDir, filepath.FromSlash(relPath)),
+					ObjectName: prefix + relPath,
+				})
+			}
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].RelPath != actions[j].RelPath {
+			return actions[i].RelPath < actions[j].RelPath
+		}
+		return actions[i].Op < actions[j].Op
+	})
+	return actions, unchanged
+}
+
+// syncChanged reports whether a file present on both sides has to be
+// transferred. Sizes decide first; if the source is not newer than the
+// destination the file is unchanged, otherwise an MD5 ETag settles it.
+func syncChanged(localPath string, local, remote syncEntry, upload bool) bool {
+	if local.Size != remote.Size {
+		return true
+	}
+	if upload && !local.ModTime.After(remote.ModTime) {
+		return false
+	}
+	if !upload && !remote.ModTime.After(local.ModTime) {
+		return false
+	}
+	if !isMD5ETag(remote.ETag) {
+		return true
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return true
+	}
+	return !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), remote.ETag)
+}
+
+// applySync runs the actions on a pool of opts.Concurrency workers and
+// returns their errors in the same order
+func applySync(ctx context.Context, f *cmdutil.Factory, store ObjectStore, opts *SyncOptions, actions []syncAction) []error {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = action.RelPath
+	}
+	progress := newTransferProgress(f.IOStreams, "Syncing", names, opts.Progress)
+	defer progress.Close()
+
+	uploadOpts := &UploadOptions{Config: opts.Config}
+	errs := make([]error, len(actions))
+	taskCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < util.MinInt(util.MaxInt(opts.Concurrency, 1), len(actions)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range taskCh {
+				// Nothing is started after an interrupt
+				if err := ctx.Err(); err != nil {
+					progress.Done(idx, err)
+					errs[idx] = err
+					continue
+				}
+				action := actions[idx]
+				var err error
+				switch action.Op {
+				case syncUpload:
+					err = uploadFile(ctx, store, action.LocalPath, action.ObjectName, uploadOpts, progress, idx)
+				case syncDownload:
+					progress.Start(idx, action.Size)
+					err = syncDownloadObject(ctx, store, action)
+				case syncDeleteRemote:
+					progress.Start(idx, 0)
+					if err = store.Delete(ctx, action.ObjectName); err != nil {
+						err = fmt.Errorf("failed to delete object: %w", err)
+					}
+				case syncDeleteLocal:
+					progress.Start(idx, 0)
+					err = os.Remove(action.LocalPath)
+				}
+				progress.Done(idx, err)
+				errs[idx] = err
+			}
+		}()
+	}
+
+	for i := range actions {
+		taskCh <- i
+	}
+	close(taskCh)
+	wg.Wait()
+
+	return errs
+}
+
+// syncDownloadObject downloads the object and sets the file's modification
+// time to the object's, so the next sync sees it as unchanged
+func syncDownloadObject(ctx context.Context, store ObjectStore, action syncAction) error {
+	task := downloadTask{ObjectName: action.ObjectName, LocalPath: action.LocalPath}
+	if _, err := downloadObject(ctx, store, task, true, nil); err != nil {
+		return err
+	}
+	return os.Chtimes(action.LocalPath, time.Now(), action.ModTime)
+}
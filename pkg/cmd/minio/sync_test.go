@@ -0,0 +1,303 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func TestPathFilter(t *testing.T) {
+	ignoreFile := filepath.Join(t.TempDir(), IgnoreFile)
+	ignore := "# build output\n*.log\nbuild/\n!keep.log\n/root-only.txt\ndocs/**/draft-*\n"
+	if err := os.WriteFile(ignoreFile, []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		paths    map[string]bool
+	}{
+		{
+			name: "ignore file",
+			paths: map[string]bool{
+				"main.go":                 true,
+				"app.log":                 false,
+				"sub/app.log":             false,
+				"keep.log":                true,
+				"build/out.bin":           false,
+				"src/build/out.bin":       false,
+				"build":                   true,
+				"root-only.txt":           false,
+				"sub/root-only.txt":       true,
+				"docs/draft-1.md":         false,
+				"docs/a/b/draft-2.md":     false,
+				"docs/final.md":           true,
+				IgnoreFile:                false,
+				"sub/" + IgnoreFile:       false,
+				"notes/build.txt":         true,
+				"notes/[weird]/file.txt":  true,
+				"sub/dir/nested/main.txt": true,
+			},
+		},
+		{
+			name:     "include and exclude flags",
+			includes: []string{"*.png", "*.jpg"},
+			excludes: []string{"thumbs/", "!*.log"},
+			paths: map[string]bool{
+				"a.png":          true,
+				"photos/b.jpg":   true,
+				"photos/c.gif":   false,
+				"thumbs/a.png":   false,
+				"x/thumbs/a.png": false,
+			},
+		},
+		{
+			name:     "include directories",
+			includes: []string{"docs/", "assets"},
+			paths: map[string]bool{
+				"docs/a.md":        true,
+				"docs/sub/b.md":    true,
+				"docs":             false,
+				"assets/logo.png":  true,
+				"assets":           true,
+				"src/docs/c.md":    true,
+				"docs-old/a.md":    false,
+				"src/main.go":      false,
+				"assets-old/x.png": false,
+			},
+		},
+		{
+			name:     "character class",
+			excludes: []string{"file[0-9].txt", "tmp?"},
+			paths: map[string]bool{
+				"file1.txt":  false,
+				"fileA.txt":  true,
+				"tmp1":       false,
+				"tmp12":      true,
+				"a/tmpx/b.c": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newPathFilter(tt.includes, tt.excludes, ignoreFile)
+			if err != nil {
+				t.Fatalf("newPathFilter() unexpected error: %v", err)
+			}
+			for path, want := range tt.paths {
+				if got := filter.Match(path); got != want {
+					t.Errorf("Match(%q) = %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPathFilterErrors(t *testing.T) {
+	ignoreFile := filepath.Join(t.TempDir(), IgnoreFile)
+	if err := os.WriteFile(ignoreFile, []byte("ok\n[broken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newPathFilter(nil, nil, ignoreFile); err == nil || !strings.Contains(err.Error(), IgnoreFile+":2") {
+		t.Errorf("newPathFilter() error = %v, want error at line 2", err)
+	}
+	if _, err := newPathFilter([]string{"!"}, nil, ""); err == nil || !strings.Contains(err.Error(), "--include") {
+		t.Errorf("newPathFilter() error = %v, want --include error", err)
+	}
+}
+
+// writeTree creates the files below dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunSyncUpload(t *testing.T) {
+	fake, cfg := newFakeS3(t, map[string][]byte{
+		"site/a.txt":   []byte("old content"),
+		"site/old.txt": []byte("removed locally"),
+		"site/x.log":   []byte("excluded, kept remotely"),
+		"other/b.txt":  []byte("outside the prefix"),
+	})
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"a.txt":     "new content",
+		"sub/b.txt": "nested",
+		"skip.tmp":  "ignored",
+		"x.log":     "excluded",
+		IgnoreFile:  "*.tmp\n",
+	})
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	opts := &SyncOptions{Config: cfg, Delete: true, Exclude: []string{"*.log"}, Concurrency: 2}
+
+	// A dry run only prints the plan
+	opts.DryRun = true
+	if err := runSync(context.Background(), f, opts, dir, ":site"); err != nil {
+		t.Fatalf("runSync() dry run unexpected error: %v", err)
+	}
+	for _, want := range []string{"upload " + filepath.Join(dir, "a.txt") + " -> site/a.txt", "delete site/old.txt", "Dry run: 3 changes"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry run output missing %q:\n%s", want, out.String())
+		}
+	}
+	if string(fake.object("site/a.txt")) != "old content" || fake.Stats().Puts != 0 {
+		t.Fatalf("dry run changed the bucket")
+	}
+
+	opts.DryRun = false
+	if err := runSync(context.Background(), f, opts, dir, ":site"); err != nil {
+		t.Fatalf("runSync() unexpected error: %v", err)
+	}
+	want := []string{"other/b.txt", "site/a.txt", "site/sub/b.txt", "site/x.log"}
+	if keys := fake.keys(); !reflect.DeepEqual(keys, want) {
+		t.Errorf("bucket keys = %v, want %v", keys, want)
+	}
+	if got := string(fake.object("site/a.txt")); got != "new content" {
+		t.Errorf("site/a.txt = %q, want updated content", got)
+	}
+
+	// Nothing changed since the last sync
+	out.Reset()
+	puts := fake.Stats().Puts
+	if err := runSync(context.Background(), f, opts, dir, ":site/"); err != nil {
+		t.Fatalf("runSync() second run unexpected error: %v", err)
+	}
+	if fake.Stats().Puts != puts || !strings.Contains(out.String(), "Synced: 0 changes, 2 files unchanged") {
+		t.Errorf("second sync transferred files (%d PUTs):\n%s", fake.Stats().Puts-puts, out.String())
+	}
+}
+
+func TestRunSyncIncludeDirectory(t *testing.T) {
+	for _, include := range []string{"docs/", "docs"} {
+		t.Run(include, func(t *testing.T) {
+			fake, cfg := newFakeS3(t, nil)
+			dir := t.TempDir()
+			writeTree(t, dir, map[string]string{
+				"docs/a.md":     "a",
+				"docs/sub/b.md": "b",
+				"main.go":       "package main",
+			})
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+			opts := &SyncOptions{Config: cfg, Include: []string{include}, Concurrency: 1}
+			if err := runSync(context.Background(), f, opts, dir, ":site"); err != nil {
+				t.Fatalf("runSync() unexpected error: %v", err)
+			}
+			want := []string{"site/docs/a.md", "site/docs/sub/b.md"}
+			if keys := fake.keys(); !reflect.DeepEqual(keys, want) {
+				t.Errorf("bucket keys = %v, want %v", keys, want)
+			}
+		})
+	}
+}
+
+func TestRunSyncDownload(t *testing.T) {
+	_, cfg := newFakeS3(t, map[string][]byte{
+		"site/a.txt":     []byte("remote a"),
+		"site/sub/b.txt": []byte("remote b"),
+	})
+	dir := filepath.Join(t.TempDir(), "mirror")
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	opts := &SyncOptions{Config: cfg, Concurrency: 2}
+
+	if err := runSync(context.Background(), f, opts, ":site/", dir); err != nil {
+		t.Fatalf("runSync() unexpected error: %v", err)
+	}
+	writeTree(t, dir, map[string]string{"extra.txt": "local only"})
+
+	// The downloaded files are unchanged, the extra file is deleted
+	out.Reset()
+	opts.Delete = true
+	if err := runSync(context.Background(), f, opts, ":site/", dir); err != nil {
+		t.Fatalf("runSync() second run unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Synced: 1 changes, 2 files unchanged") {
+		t.Errorf("unexpected second sync:\n%s", out.String())
+	}
+
+	got := map[string]string{}
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			data, _ := os.ReadFile(path)
+			got[filepath.ToSlash(rel)] = string(data)
+		}
+		return nil
+	})
+	want := map[string]string{"a.txt": "remote a", "sub/b.txt": "remote b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("local files = %v, want %v", got, want)
+	}
+}
+
+func TestRunSyncDownloadOutsideDir(t *testing.T) {
+	_, cfg := newFakeS3(t, map[string][]byte{
+		"site/a.txt":          []byte("remote a"),
+		"site/../victim.txt":  []byte("overwritten"),
+		"site/../../evil.txt": []byte("escaped"),
+	})
+	root := t.TempDir()
+	dir := filepath.Join(root, "mirror")
+	writeTree(t, root, map[string]string{"victim.txt": "keep me"})
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	opts := &SyncOptions{Config: cfg, Concurrency: 2, Delete: true}
+
+	if err := runSync(context.Background(), f, opts, ":site/", dir); err != nil {
+		t.Fatalf("runSync() unexpected error: %v", err)
+	}
+	if got := strings.Count(out.String(), "Skipped site/.."); got != 2 {
+		t.Errorf("expected 2 skipped keys, got output:\n%s", out.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "victim.txt")); err != nil || string(data) != "keep me" {
+		t.Errorf("file outside of the synced directory = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("object was written outside of the synced directory")
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "a.txt")); err != nil || string(data) != "remote a" {
+		t.Errorf("a.txt = %q, %v", data, err)
+	}
+}
+
+func TestRunSyncInvalidArgs(t *testing.T) {
+	_, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		source, dst string
+		wantErr     string
+	}{
+		{name: "both local", source: dir, dst: dir, wantErr: "exactly one"},
+		{name: "both remote", source: ":a/", dst: ":b/", wantErr: "exactly one"},
+		{name: "missing local directory", source: filepath.Join(dir, "missing"), dst: ":a/", wantErr: "is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runSync(context.Background(), f, &SyncOptions{Config: cfg}, tt.source, tt.dst)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runSync() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
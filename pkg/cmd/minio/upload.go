@@ -1,31 +1,128 @@
 package minio
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"os"
-	"strings"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 
 	"github.com/gogodjzhu/gogobox/internal/util"
 	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
-	"github.com/minio/minio-go/v6"
-	uuid "github.com/satori/go.uuid"
+	"github.com/minio/minio-go/v7"
 	"github.com/spf13/cobra"
 )
 
 type UploadOptions struct {
-	Config     *MinIOConfig
-	AutoResize bool
-	MaxSize    int64
-	PrintURLs  bool
+	Config      *MinIOConfig
+	AutoResize  bool
+	MaxSize     int64
+	PrintURLs   bool
+	Concurrency int
+	PartSize    int64
+	Progress    bool
+	Journal     string
+	Resume      string
+	KeyTemplate string
+	Prefix      string
+	KeepPath    bool
+	IfExists    string
+	Dedupe      bool
+	Presign     bool
+	Expires     string
+	Format      string
+	OnError     string
+	Retries     int
+	Output      string
+	Template    string
+	ContentType string
+
+	// Object metadata
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	StorageClass       string
+	Meta               []string
+	Tags               []string
+	RulesFile          string
+
+	// Sources besides files
+	Name            string
+	FromClipboard   bool
+	URLMaxSize      int64
+	URLTimeout      time.Duration
+	URLMaxRedirects int
+
+	// Encryption with a key from KeyFile or the KeySecret secret
+	SSEC      bool
+	Encrypt   bool
+	KeyFile   string
+	KeySecret string
+
+	// metadata is resolved from the flags and rules file by runUpload
+	metadata *uploadMetadata
+	// encryption is loaded by runUpload, nil for unencrypted uploads
+	encryption *objectEncryption
+	// remotes are the URL sources of the run by URL
+	remotes map[string]*remoteSource
 }
 
+// Policies for files that fail to upload
+const (
+	OnErrorRollback = "rollback"
+	OnErrorContinue = "continue"
+	OnErrorStop     = "stop"
+)
+
+// Outcomes of a file in the upload report
+const (
+	uploadSucceeded = "success"
+	uploadSkipped   = "skipped"
+	uploadFailed    = "failed"
+)
+
+// Reasons for skipped files
+var (
+	errNotStarted = errors.New("not started after an earlier failure")
+	errRolledBack = errors.New("uploaded, but rolled back after an earlier failure")
+)
+
+// uploadResult is the outcome of one file of an upload run
+type uploadResult struct {
+	Source   string
+	Key      string
+	Status   string
+	Err      error
+	Duration time.Duration
+}
+
+const (
+	// minPartSize and maxPartSize are the S3 limits for multipart part sizes
+	minPartSize = 5 * 1024 * 1024
+	maxPartSize = 5 * 1024 * 1024 * 1024
+)
+
 func NewCmdMinIOUpload(f *cmdutil.Factory) *cobra.Command {
 	opts := &UploadOptions{
-		Config:     NewDefaultConfig(),
-		AutoResize: true,
-		MaxSize:    512 * 1024, // 512KB default max size for images
-		PrintURLs:  true,
+		Config:      NewDefaultConfig(),
+		AutoResize:  true,
+		MaxSize:     512 * 1024, // 512KB default max size for images
+		PrintURLs:   true,
+		Concurrency: 4,
+		Progress:    true,
+		KeyTemplate: DefaultKeyTemplate,
+		IfExists:    IfExistsError,
+		Expires:     DefaultExpires,
+		Format:      LinkRaw,
+		OnError:     OnErrorRollback,
+		Retries:     DefaultRetries,
+		Output:      OutputText,
 	}
 
 	cmd := &cobra.Command{
@@ -36,247 +133,751 @@ func NewCmdMinIOUpload(f *cmdutil.Factory) *cobra.Command {
 This command uploads files to MinIO and can automatically resize large images
 to reduce file size. Supported image formats: PNG, JPG, JPEG.
 
+A single "-" uploads stdin as one object, streamed in parts of --part-size
+(16MiB by default) while it is read, so its size need not be known. --name
+sets the file name keys and the content type are derived from ("stdin" by
+default). Stdin cannot be journaled or named by content hash.
+
+http:// and https:// arguments are uploaded from their URL. They are named
+after the Content-Disposition file name or the URL path, with an extension
+matching the Content-Type if the name has none, and stored with that content
+type. URLs are streamed into their objects, unless they are needed as files:
+images that may be resized, {sha256} keys, --dedupe and --journal download
+them to temporary files first. Responses larger than --url-max-size, slower
+than --url-timeout and more than --url-max-redirects redirects fail.
+
+--from-clipboard uploads the image in the clipboard besides the file
+arguments, it fails if the clipboard holds no image. Images are read with
+wl-paste or xclip on Linux, pngpaste or osascript on macOS and PowerShell on
+Windows.
+
+The content type of every file is detected from the magic bytes of its header
+(PNG, JPEG, GIF, WebP, PDF, MP4, ...) and an extensive table of extensions
+(SVG, JSON, HTML, fonts, archives, ...); text files without a known extension
+are served as text. --content-type sets it for every file instead.
+
+Objects can be uploaded with Cache-Control, Content-Disposition and
+Content-Encoding headers, user metadata (--meta key=value), tags
+(--tag key=value) and a storage class. A rules file (--rules, by default
+upload-rules.yaml in the gogobox config directory if it exists) sets them by
+extension or content type; flags override matching rules:
+  rules:
+    - contentTypes: [image/*]
+      cacheControl: public, max-age=31536000, immutable
+    - extensions: [zip, gz]
+      contentDisposition: attachment
+      storageClass: STANDARD_IA
+      metadata: {team: web}
+      tags: {kind: archive}
+
 The command will:
 - Validate all required configuration parameters
 - Process and optimize images if they exceed the size limit
-- Upload files to the specified bucket
-- Return public URLs for uploaded files`,
+- Upload files to the specified bucket, several at a time
+- Show per-file and total progress (plain lines on stderr when stdout is not a terminal)
+- Return public URLs for uploaded files
+
+Files larger than the part size are uploaded in parts (multipart upload).
+
+Objects can be encrypted with a 32 byte key from --key-file or the secrets
+store (--key-secret), given raw, as hex or in base64:
+  --sse-c    the server encrypts them with the key (SSE-C), which it does not
+             keep; downloads need the same key
+  --encrypt  they are encrypted on the client with AES-256-GCM and a random
+             per-object data key. The data key, wrapped with the key, and the
+             algorithm are stored in the object metadata; "minio download"
+             decrypts them.
+Encrypted uploads cannot be journaled or deduplicated.
+
+With --journal the progress of the run is recorded in a file, down to single
+parts of multipart uploads. If the run is interrupted it is continued with
+--resume <journal>: objects that are already present with matching size and
+ETag are skipped and multipart uploads continue after their last completed
+part. Uploaded objects are not rolled back when a journaled run fails. The
+journal is removed once every file is uploaded.
+
+Directories are uploaded with every file below them. Object keys are rendered
+from --key-template, which supports the placeholders:
+  {basename}       file name without extension
+  {ext}            extension of the uploaded file without dot ("bin" if none)
+  {path}           path relative to the uploaded directory's parent, e.g. photos/2024/a.png
+  {dir}            directory part of {path} (empty for single files)
+  {sha256}         SHA-256 of the uploaded content
+  {uuid}           random UUID
+  {date:<layout>}  upload time in a Go time layout, e.g. {date:2006/01/02}
+  {index}          1-based position of the file in this run
+--keep-path is a shorthand for --key-template {path}. Keys that are already
+taken, in the bucket or by another file of the run, are rejected unless
+--if-exists is rename (append -1, -2, ...) or overwrite.
+
+With --dedupe objects are named by the SHA-256 of their content
+("{sha256}.{ext}", below --prefix). Files whose object already exists are not
+uploaded again, so repeated uploads of the same file are cheap and return the
+same URL.
+
+Public URLs only work for public-read buckets. For private buckets --presign
+prints presigned download URLs instead, valid for --expires (at most 7d).
+
+--format prints the URLs as snippets for pasting: markdown, html or bbcode.
+Images are embedded, other files are linked with their file name.
+
+Transient network and server errors are retried --retries times with
+exponential backoff. What happens when a file still fails is chosen with
+--on-error:
+  rollback  stop starting uploads and delete the objects uploaded so far (default)
+  continue  upload the remaining files
+  stop      stop starting uploads but keep the objects uploaded so far
+If any file fails, a report of every file (success, skipped, or failed with
+the reason) is printed to stderr, URLs are printed for the uploaded files and
+the command exits with code 2 if some files were uploaded, 1 otherwise.
+
+Output formats:
+- text:     the URLs of the uploaded files (default)
+- json:     a single JSON array with one object per file
+- ndjson:   one JSON object per file and line
+- table:    human readable columns
+- template: --template rendered for every file, e.g. '{{.Key}} {{.ETag}}'
+Every file is described by its source path, object key, URL, status (and the
+reason if it was skipped or failed), size before and after resizing, content
+type, ETag and upload duration.`,
 		Example: `  # Upload files with basic configuration
-  gogobox minio upload -e localhost:9000 -a mykey -s mysecret -b mybucket image.jpg`,
-		Args: cobra.MinimumNArgs(1),
+  gogobox minio upload -e localhost:9000 -a mykey -s mysecret -b mybucket image.jpg
+
+  # Upload large backups 2 at a time in 64MiB parts
+  gogobox minio upload --concurrency 2 --part-size 64MiB --resize=false backup-*.tar.gz
+
+  # Record progress and continue after an interruption
+  gogobox minio upload --journal upload.journal --resize=false backup-*.tar.gz
+  gogobox minio upload --resume upload.journal
+
+  # Mirror a directory below a prefix, keeping relative paths
+  gogobox minio upload --resize=false --keep-path --prefix backups/2024 --if-exists overwrite ./photos
+
+  # Name objects by date and content hash
+  gogobox minio upload --key-template "{date:2006/01/02}/{sha256}.{ext}" image.png
+
+  # Upload a screenshot only once, however often it is uploaded
+  gogobox minio upload --dedupe --prefix screenshots screenshot.png
+
+  # Upload to a private bucket and print URLs valid for a week
+  gogobox minio upload --presign --expires 7d report.pdf
+
+  # Print a Markdown image link for a blog post
+  gogobox minio upload --format markdown screenshot.png
+
+  # Upload the output of a command
+  pg_dump mydb | gzip | gogobox minio upload --name mydb.sql.gz -
+
+  # Copy a file from the web into the bucket without saving it locally
+  gogobox minio upload --url-max-size 10GiB https://example.com/releases/app.tar.gz
+
+  # Upload a screenshot from the clipboard as a Markdown image
+  gogobox minio upload --from-clipboard --format markdown
+
+  # Encrypt a release on the client with a key from the secrets store
+  gogobox minio upload --encrypt --key-secret artifacts-key --resize=false release.tar.gz
+
+  # Upload a download with metadata and tags
+  gogobox minio upload --content-disposition attachment --meta author=alice --tag project=apollo report.pdf
+
+  # Record the uploaded objects of a script
+  gogobox minio upload -o ndjson --resize=false ./exports >> uploads.ndjson
+
+  # Upload whatever can be uploaded from a nightly batch
+  gogobox minio upload --on-error continue --retries 5 --resize=false ./exports`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.Resume != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("files cannot be given with --resume, they are read from the journal")
+				}
+				return nil
+			}
+			for _, arg := range args {
+				if arg == StdinSource && len(args) > 1 {
+					return fmt.Errorf("stdin (%s) must be the only file argument", StdinSource)
+				}
+			}
+			if opts.FromClipboard {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUpload(opts, args)
+			if err := resolveConfig(f, cmd, opts.Config); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+			return runUpload(cmd.Context(), f, opts, args)
 		},
 	}
 
 	// MinIO connection flags
-	cmd.Flags().StringVarP(&opts.Config.Endpoint, "endpoint", "e", "", "MinIO server endpoint (required)")
-	cmd.Flags().StringVarP(&opts.Config.AccessKeyID, "access-key", "a", "", "MinIO access key ID (required)")
-	cmd.Flags().StringVarP(&opts.Config.SecretAccessKey, "secret-key", "s", "", "MinIO secret access key (required)")
-	cmd.Flags().StringVarP(&opts.Config.BucketName, "bucket", "b", "", "MinIO bucket name (required)")
-	cmd.Flags().BoolVar(&opts.Config.UseSSL, "ssl", false, "Use SSL/TLS connection")
+	addConnectionFlags(cmd, opts.Config)
 
 	// Upload options flags
 	cmd.Flags().BoolVar(&opts.AutoResize, "resize", true, "Automatically resize large images")
 	cmd.Flags().Int64Var(&opts.MaxSize, "max-size", 512*1024, "Maximum file size in bytes after resize")
 	cmd.Flags().BoolVar(&opts.PrintURLs, "print-urls", true, "Print public URLs for uploaded files")
-
-	// Mark required flags
-	cmd.MarkFlagRequired("endpoint")
-	cmd.MarkFlagRequired("access-key")
-	cmd.MarkFlagRequired("secret-key")
-	cmd.MarkFlagRequired("bucket")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", 4, "Number of files uploaded concurrently")
+	cmd.Flags().Var(cmdutil.NewSizeValue(&opts.PartSize, 0), "part-size", "Multipart part size, e.g. 16MiB (5MiB-5GiB, default chosen by object size)")
+	cmd.Flags().BoolVar(&opts.Progress, "progress", true, "Show upload progress")
+	cmd.Flags().StringVar(&opts.Journal, "journal", "", "Record upload progress in this file so the run can be resumed")
+	cmd.Flags().StringVar(&opts.Resume, "resume", "", "Resume the interrupted upload recorded in this journal")
+	cmd.Flags().StringVar(&opts.KeyTemplate, "key-template", DefaultKeyTemplate, "Template for object keys, see the placeholders above")
+	cmd.Flags().StringVar(&opts.Prefix, "prefix", "", "Prefix prepended to every object key")
+	cmd.Flags().BoolVar(&opts.KeepPath, "keep-path", false, "Keep the relative path of uploaded directories as object key")
+	cmd.Flags().StringVar(&opts.IfExists, "if-exists", IfExistsError, "What to do with keys that are already taken: error, rename or overwrite")
+	cmd.Flags().BoolVar(&opts.Presign, "presign", false, "Print presigned URLs that work for private buckets")
+	cmd.Flags().StringVar(&opts.Expires, "expires", DefaultExpires, "Lifetime of presigned URLs, e.g. 90m, 24h or 7d")
+	cmd.Flags().StringVar(&opts.Format, "format", LinkRaw, "Format of printed URLs: raw, markdown, html or bbcode")
+	cmd.Flags().StringVar(&opts.OnError, "on-error", OnErrorRollback, "What to do when a file fails: rollback, continue or stop")
+	cmd.Flags().IntVar(&opts.Retries, "retries", DefaultRetries, "Number of retries after transient network errors")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputText, "Output format: text, json, ndjson, table or template")
+	cmd.Flags().StringVar(&opts.Template, "template", "", "Go template rendered for every file with --output template")
+	cmd.Flags().StringVar(&opts.ContentType, "content-type", "", "Content type of the uploaded files (default detected per file)")
+	cmd.Flags().StringVar(&opts.CacheControl, "cache-control", "", "Cache-Control header of the uploaded files, e.g. \"public, max-age=86400\"")
+	cmd.Flags().StringVar(&opts.ContentDisposition, "content-disposition", "", "Content-Disposition header of the uploaded files, e.g. attachment")
+	cmd.Flags().StringVar(&opts.ContentEncoding, "content-encoding", "", "Content-Encoding header of the uploaded files, e.g. gzip")
+	cmd.Flags().StringVar(&opts.StorageClass, "storage-class", "", "Storage class of the uploaded objects, e.g. STANDARD_IA")
+	cmd.Flags().StringArrayVar(&opts.Meta, "meta", nil, "User metadata key=value stored as x-amz-meta-<key> (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.Tags, "tag", nil, "Object tag key=value (repeatable)")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "File name of stdin or clipboard uploads, used for the key and content type")
+	cmd.Flags().BoolVar(&opts.FromClipboard, "from-clipboard", false, "Upload the image in the clipboard")
+	cmd.Flags().Var(cmdutil.NewSizeValue(&opts.URLMaxSize, DefaultURLMaxSize), "url-max-size", "Maximum size of URL sources, 0 for no limit")
+	cmd.Flags().DurationVar(&opts.URLTimeout, "url-timeout", DefaultURLTimeout, "Timeout for responses of URL sources and pauses while reading them, 0 for none")
+	cmd.Flags().IntVar(&opts.URLMaxRedirects, "url-max-redirects", DefaultURLMaxRedirects, "Maximum number of redirects followed for URL sources")
+	cmd.Flags().BoolVar(&opts.SSEC, "sse-c", false, "Encrypt objects on the server with the key (SSE-C)")
+	cmd.Flags().BoolVar(&opts.Encrypt, "encrypt", false, "Encrypt objects on the client with AES-256-GCM and a per-object key wrapped by the key")
+	cmd.Flags().StringVar(&opts.KeyFile, "key-file", "", "File with the 32 byte encryption key (raw, hex or base64)")
+	cmd.Flags().StringVar(&opts.KeySecret, "key-secret", "", "Name of the secret in the secrets store holding the encryption key")
+	cmd.Flags().StringVar(&opts.RulesFile, "rules", "", "Per-extension metadata rules file (default "+DefaultRulesFile+" in the config directory)")
+	cmd.MarkFlagsMutuallyExclusive("journal", "resume")
+	cmd.Flags().BoolVar(&opts.Dedupe, "dedupe", false, "Name objects by content hash and skip files that are already uploaded")
+	cmd.MarkFlagsMutuallyExclusive("key-template", "keep-path", "dedupe")
 
 	return cmd
 }
 
-func runUpload(opts *UploadOptions, filenames []string) error {
+func runUpload(ctx context.Context, f *cmdutil.Factory, opts *UploadOptions, filenames []string) error {
 	// Validate configuration
 	if err := opts.Config.Validate(); err != nil {
 		return fmt.Errorf("configuration error: %w", err)
 	}
+	if opts.PartSize != 0 && (opts.PartSize < minPartSize || opts.PartSize > maxPartSize) {
+		return fmt.Errorf("configuration error: part size must be between %s and %s",
+			util.HumanSize(minPartSize), util.HumanSize(maxPartSize))
+	}
+
+	switch opts.IfExists {
+	case "":
+		opts.IfExists = IfExistsError
+	case IfExistsError, IfExistsRename, IfExistsOverwrite:
+	default:
+		return fmt.Errorf("configuration error: unsupported --if-exists policy: %s", opts.IfExists)
+	}
+	switch opts.OnError {
+	case "":
+		opts.OnError = OnErrorRollback
+	case OnErrorRollback, OnErrorContinue, OnErrorStop:
+	default:
+		return fmt.Errorf("configuration error: unsupported --on-error policy: %s", opts.OnError)
+	}
+	if opts.Retries < 0 {
+		return fmt.Errorf("configuration error: --retries must not be negative")
+	}
+	if opts.Presign {
+		if _, err := parseExpiry(opts.Expires); err != nil {
+			return fmt.Errorf("configuration error: %w", err)
+		}
+	}
+	if err := validateLinkFormat(opts.Format); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if err := validateUploadOutput(opts); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if opts.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(opts.ContentType); err != nil {
+			return fmt.Errorf("configuration error: invalid --content-type %q: %w", opts.ContentType, err)
+		}
+	}
+	metadata, err := newUploadMetadata(opts)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	opts.metadata = metadata
+	if err := validateUploadEncryption(opts); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if err := validateUploadBackend(opts); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if opts.encryption, err = loadObjectEncryption(f.IOStreams, opts.KeyFile, opts.KeySecret, opts.SSEC, opts.Encrypt); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	keyTemplate := opts.KeyTemplate
+	switch {
+	case opts.Dedupe:
+		keyTemplate = DedupeKeyTemplate
+	case opts.KeepPath:
+		keyTemplate = KeepPathKeyTemplate
+	case keyTemplate == "":
+		keyTemplate = DefaultKeyTemplate
+	}
+	tmpl, err := parseKeyTemplate(keyTemplate, opts.Prefix)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	if len(filenames) == 1 && filenames[0] == StdinSource {
+		return uploadStdin(ctx, f, opts, tmpl)
+	}
+
+	var journal *uploadJournal
+	var sources []uploadSource
+	var processedFiles, objectNames []string
+	temporary := map[string]bool{}
+	var clip uploadSource
+	if opts.Resume != "" {
+		// Files were processed and named when the journal was written
+		journal, err = loadUploadJournal(opts.Resume)
+		if err != nil {
+			return err
+		}
+		if err := journal.Check(opts.Config); err != nil {
+			return err
+		}
+		processedFiles = journal.Sources()
+		objectNames = journal.ObjectNames()
+		for _, file := range journal.TemporaryFiles() {
+			temporary[file] = true
+		}
+		filenames = processedFiles
+	} else {
+		sources, err = collectUploadSources(filenames)
+		if err != nil {
+			return fmt.Errorf("file processing error: %w", err)
+		}
+		if opts.FromClipboard {
+			clip, err = clipboardSource(opts.Name)
+			if err != nil {
+				return fmt.Errorf("file processing error: %w", err)
+			}
+			temporary[clip.Path] = true
+			sources = append(sources, clip)
+		}
+		opts.remotes, err = resolveRemoteSources(ctx, sources, opts, tmpl, temporary)
+		if err != nil {
+			return fmt.Errorf("file processing error: %w", err)
+		}
+		defer closeRemoteSources(opts.remotes)
+		filenames = make([]string, len(sources))
+		for i, source := range sources {
+			filenames[i] = source.Path
+		}
+
+		// Process files (resize if needed)
+		processedFiles, err = processFiles(filenames, opts)
+		if err != nil {
+			return fmt.Errorf("file processing error: %w", err)
+		}
+		for i, processedFile := range processedFiles {
+			if processedFile != filenames[i] {
+				temporary[processedFile] = true
+			}
+		}
+		objectNames, err = renderObjectNames(tmpl, sources, remoteUploadPaths(processedFiles, opts.remotes))
+		if err != nil {
+			return fmt.Errorf("file processing error: %w", err)
+		}
+	}
 
-	// Process files (resize if needed)
-	processedFiles, err := processFiles(filenames, opts)
+	// Open the storage
+	store, err := newStore(ctx, opts.Config)
 	if err != nil {
-		return fmt.Errorf("file processing error: %w", err)
+		return err
+	}
+	allObjectNames := objectNames
+	sourcePaths := make([]string, len(processedFiles))
+	originalSizes := make([]int64, len(processedFiles))
+	for i, file := range processedFiles {
+		if sources != nil {
+			file = sources[i].Path
+		}
+		sourcePaths[i] = file
+		if remote := opts.remotes[file]; remote != nil {
+			originalSizes[i] = util.MaxInt64(remote.Size, 0)
+		} else if stat, err := os.Stat(file); err == nil {
+			originalSizes[i] = stat.Size()
+		}
+	}
+	if journal == nil {
+		if opts.Dedupe {
+			// Existing objects have the same content, only upload the rest
+			pending, err := pendingDedupeUploads(ctx, store, processedFiles, objectNames)
+			if err != nil {
+				return fmt.Errorf("upload error: %w", err)
+			}
+			filenames = selectIndexes(filenames, pending)
+			processedFiles = selectIndexes(processedFiles, pending)
+			objectNames = selectIndexes(objectNames, pending)
+		} else if err := resolveKeyConflicts(ctx, store, tmpl, sources, objectNames, opts.IfExists); err != nil {
+			return fmt.Errorf("upload error: %w", err)
+		}
+		if opts.Journal != "" {
+			journal = newUploadJournal(opts.Journal, opts.Config, processedFiles, objectNames, temporary, opts.PartSize, opts.metadata)
+			// Written before anything is uploaded, runs failing early are
+			// resumable too
+			if err := journal.Save(); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Upload files
-	urls, err := uploadFiles(processedFiles, opts)
+	progress := newTransferProgress(f.IOStreams, "Uploading", filenames, opts.Progress)
+	results, err := uploadFiles(ctx, store, processedFiles, objectNames, opts, journal, progress)
+	progress.Close()
+
+	// Clean up temporary files, journaled runs need them to be resumed
+	if err == nil || journal == nil {
+		for file := range temporary {
+			os.Remove(file)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("upload error: %w", err)
+		// Report the original files rather than resized copies
+		for i := range results {
+			results[i].Source = filenames[i]
+		}
+		reportOut := f.IOStreams.ErrOut
+		if reportOut == nil {
+			reportOut = io.Discard
+		}
+		if reportErr := writeUploadReport(reportOut, results); reportErr != nil {
+			return reportErr
+		}
+		if journal != nil {
+			err = fmt.Errorf("upload error: %w (resume with --resume %s)", err, journal.path)
+		} else {
+			err = fmt.Errorf("upload error: %w", err)
+		}
+	} else if journal != nil {
+		journal.Remove()
 	}
 
-	// Display results
-	if opts.PrintURLs {
-		fmt.Println("Upload Success:")
-		for _, url := range urls {
-			fmt.Printf("%s\n", url)
+	// Describe every file, including files that were already present
+	records, recordErr := uploadRecords(ctx, store, opts, sourcePaths, originalSizes, allObjectNames, results)
+	if recordErr != nil {
+		return recordErr
+	}
+	for i := range records {
+		if clip.Path != "" && records[i].Source == clip.Path {
+			records[i].Source, records[i].name = ClipboardSource, filepath.Base(clip.RelPath)
+			if records[i].ContentType == "" {
+				records[i].ContentType = util.ContentTypeByExtension(clip.Path)
+			}
+		}
+		if remote := opts.remotes[records[i].Source]; remote != nil {
+			records[i].name = remote.Name
+			if records[i].ContentType == "" {
+				records[i].ContentType = remote.ContentType
+			}
+		}
+	}
+	available := 0
+	for _, record := range records {
+		if record.Status == uploadSucceeded || record.Reason == reasonPresent {
+			available++
+		}
+	}
+	if err != nil {
+		// Nothing is left of rolled back runs, otherwise the failure decides
+		// the exit code
+		if journal == nil && opts.OnError == OnErrorRollback {
+			available = 0
+		}
+		if available > 0 {
+			err = &cmdutil.PartialError{Err: err}
 		}
-	} else {
-		fmt.Printf("Uploaded %d files successfully\n", len(urls))
 	}
+	if opts.Output == OutputText && available == 0 && err != nil {
+		return err
+	}
+	if writeErr := writeUploadRecords(f.IOStreams.Out, opts, records); writeErr != nil {
+		return writeErr
+	}
+	return err
+}
 
+// validateUploadBackend rejects the flags the local and WebDAV backends do
+// not support: they keep no metadata besides the content type, no multipart
+// uploads to resume and cannot presign URLs
+func validateUploadBackend(opts *UploadOptions) error {
+	switch {
+	case len(opts.Meta) > 0:
+		return requireS3Backend(opts.Config, "--meta")
+	case len(opts.Tags) > 0:
+		return requireS3Backend(opts.Config, "--tag")
+	case opts.CacheControl != "":
+		return requireS3Backend(opts.Config, "--cache-control")
+	case opts.ContentDisposition != "":
+		return requireS3Backend(opts.Config, "--content-disposition")
+	case opts.ContentEncoding != "":
+		return requireS3Backend(opts.Config, "--content-encoding")
+	case opts.StorageClass != "":
+		return requireS3Backend(opts.Config, "--storage-class")
+	case opts.SSEC || opts.Encrypt:
+		return requireS3Backend(opts.Config, "encryption")
+	case opts.Journal != "" || opts.Resume != "":
+		return requireS3Backend(opts.Config, "--journal")
+	case opts.Presign:
+		return requireS3Backend(opts.Config, "--presign")
+	}
 	return nil
 }
 
+// processFiles returns the files that are uploaded for filenames: temporary
+// copies of URLs (streamed URLs are kept), and resized copies of large images
 func processFiles(filenames []string, opts *UploadOptions) ([]string, error) {
-	if !opts.AutoResize {
-		return filenames, nil
-	}
-
 	processedFiles := make([]string, 0, len(filenames))
 
 	for _, filename := range filenames {
-		if strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://") {
-			// Download the file to a temporary location
-			tmpFile, err := util.DownloadToTempFile(filename)
-			if err != nil {
-				return nil, fmt.Errorf("failed to download file %s: %w", filename, err)
+		if remote := opts.remotes[filename]; remote != nil {
+			if remote.File == "" {
+				processedFiles = append(processedFiles, filename)
+				continue
 			}
-			filename = tmpFile
-		}
-
-		file, err := os.Open(filename)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
-		}
-
-		stat, err := file.Stat()
-		if err != nil {
-			file.Close()
-			return nil, fmt.Errorf("failed to get file stats for %s: %w", filename, err)
+			filename = remote.File
 		}
-
-		// If file is small enough or not an image, use original
-		if stat.Size() <= opts.MaxSize || !isImage(filename) {
-			file.Close()
+		if !opts.AutoResize {
 			processedFiles = append(processedFiles, filename)
 			continue
 		}
 
-		// Process large image
-		processedFile, err := util.CompressImage(filename, opts.MaxSize, "jpeg")
-		file.Close()
+		// Small files and files that are no images are kept
+		processedFile, err := util.ResizeImage(filename, opts.MaxSize)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resize image %s: %w", filename, err)
 		}
-
 		processedFiles = append(processedFiles, processedFile)
 	}
 
 	return processedFiles, nil
 }
 
+// isImage reports whether the file is an image, detected like its content type
 func isImage(filename string) bool {
-	lower := strings.ToLower(filename)
-	return strings.HasSuffix(lower, ".png") ||
-		strings.HasSuffix(lower, ".jpg") ||
-		strings.HasSuffix(lower, ".jpeg")
+	return util.IsImageContentType(getContentType(filename))
 }
 
-func uploadFiles(filenames []string, opts *UploadOptions) ([]string, error) {
-	// Initialize MinIO client
-	minioClient, err := minio.New(opts.Config.Endpoint, opts.Config.AccessKeyID, opts.Config.SecretAccessKey, opts.Config.UseSSL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
-	}
+// isResizableImage reports whether the file is an image that can be resized
+func isResizableImage(filename string) bool {
+	return util.IsResizableContentType(getContentType(filename))
+}
 
-	// Check if bucket exists
-	exists, err := minioClient.BucketExists(opts.Config.BucketName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
+// uploadFiles uploads the files as objectNames on a pool of opts.Concurrency
+// workers, retrying transient errors. What happens after a file failed is
+// decided by opts.OnError: rollback stops starting new uploads and removes
+// every object uploaded so far, unless the run is recorded in a journal to be
+// resumed, stop only stops starting new uploads and continue uploads the
+// remaining files. The results are in the same order as filenames, the error
+// is not nil if any file failed.
+func uploadFiles(ctx context.Context, store ObjectStore, filenames, objectNames []string, opts *UploadOptions, journal *uploadJournal, progress *transferProgress) ([]uploadResult, error) {
+	results := make([]uploadResult, len(filenames))
+	for i := range results {
+		results[i] = uploadResult{Source: filenames[i], Key: objectNames[i], Status: uploadSkipped}
+	}
+	var client *minio.Client
+	if journal != nil {
+		var err error
+		if client, err = s3Client(store, "--journal"); err != nil {
+			return results, err
+		}
 	}
-	if !exists {
-		return nil, fmt.Errorf("bucket '%s' does not exist", opts.Config.BucketName)
+	onError := opts.OnError
+	if onError == "" {
+		onError = OnErrorRollback
+	}
+	var stopped atomic.Bool
+
+	taskCh := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < util.MinInt(util.MaxInt(opts.Concurrency, 1), len(filenames)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range taskCh {
+				// Stop starting new uploads once one failed or the run was
+				// interrupted
+				if stopped.Load() || ctx.Err() != nil {
+					results[idx].Err = errNotStarted
+					continue
+				}
+				started := time.Now()
+				err := retryTransient(ctx, opts.Retries, func() error {
+					if journal != nil {
+						return uploadFileJournaled(ctx, client, filenames[idx], journal, idx, progress)
+					}
+					return uploadFile(ctx, store, filenames[idx], objectNames[idx], opts, progress, idx)
+				})
+				progress.Done(idx, err)
+				results[idx].Duration = time.Since(started)
+				if err != nil {
+					results[idx].Status, results[idx].Err = uploadFailed, err
+					if onError != OnErrorContinue {
+						stopped.Store(true)
+					}
+					continue
+				}
+				results[idx].Status = uploadSucceeded
+			}
+		}()
 	}
 
-	var urls []string
-	var uploadedObjects []string // Keep track of successfully uploaded objects for potential rollback
-
-	for _, filename := range filenames {
-		file, err := os.Open(filename)
-		if err != nil {
-			// Clean up any already uploaded files on error
-			cleanupUploadedFiles(minioClient, opts.Config.BucketName, uploadedObjects)
-			return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	for i := range filenames {
+		taskCh <- i
+	}
+	close(taskCh)
+	wg.Wait()
+
+	var failed int
+	var firstErr error
+	for _, result := range results {
+		if result.Status == uploadFailed {
+			failed++
+			if firstErr == nil {
+				firstErr = result.Err
+			}
 		}
+	}
+	if failed == 0 && ctx.Err() == nil {
+		return results, nil
+	}
 
-		fileStat, err := file.Stat()
-		if err != nil {
-			file.Close()
-			// Clean up any already uploaded files on error
-			cleanupUploadedFiles(minioClient, opts.Config.BucketName, uploadedObjects)
-			return nil, fmt.Errorf("failed to get file stats for %s: %w", filename, err)
+	// Clean up any already uploaded files, journaled runs keep them to be
+	// resumed. Interrupted runs are rolled back like failed ones.
+	if onError == OnErrorRollback && journal == nil {
+		var uploadedObjects []string
+		for i, result := range results {
+			if result.Status == uploadSucceeded {
+				uploadedObjects = append(uploadedObjects, result.Key)
+				results[i].Status, results[i].Err = uploadSkipped, errRolledBack
+			}
 		}
+		cleanupUploadedFiles(ctx, store, uploadedObjects)
+	}
+	if failed == 0 {
+		return results, fmt.Errorf("upload interrupted: %w", ctx.Err())
+	}
+	return results, fmt.Errorf("%d of %d files failed: %w", failed, len(results), firstErr)
+}
 
-		// Generate object name
-		objectName := generateObjectName(filename)
-
-		// Determine content type
-		contentType := getContentType(filename)
-
-		// Upload file
-		_, err = minioClient.PutObject(
-			opts.Config.BucketName,
-			objectName,
-			file,
-			fileStat.Size(),
-			minio.PutObjectOptions{ContentType: contentType},
-		)
-
-		// Close file immediately after upload
-		file.Close()
-
-		if err != nil {
-			// Clean up any already uploaded files on error
-			cleanupUploadedFiles(minioClient, opts.Config.BucketName, uploadedObjects)
-			return nil, fmt.Errorf("failed to upload file %s: %w", filename, err)
+// writeUploadReport prints the outcome of every file of an upload run
+func writeUploadReport(out io.Writer, results []uploadResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Upload report:")
+	for _, result := range results {
+		switch {
+		case result.Status == uploadSucceeded:
+			fmt.Fprintf(w, "  %s\t%s\t-> %s\n", result.Status, result.Source, result.Key)
+		case result.Err != nil:
+			fmt.Fprintf(w, "  %s\t%s\t%v\n", result.Status, result.Source, result.Err)
+		default:
+			fmt.Fprintf(w, "  %s\t%s\t\n", result.Status, result.Source)
 		}
+	}
+	return w.Flush()
+}
 
-		// Track successfully uploaded object
-		uploadedObjects = append(uploadedObjects, objectName)
-
-		// Generate public URL if requested
-		if opts.PrintURLs {
-			url := opts.Config.GetObjectURL(objectName)
-			urls = append(urls, url)
-		} else {
+// objectURLs returns the public (or presigned) URLs of the objects, or their
+// names if URLs are not requested
+func objectURLs(ctx context.Context, store ObjectStore, opts *UploadOptions, objectNames []string) ([]string, error) {
+	var urls []string
+	for _, objectName := range objectNames {
+		switch {
+		case !opts.PrintURLs:
 			urls = append(urls, objectName)
-		}
-
-		// Clean up temporary files
-		if strings.HasPrefix(filename, "/tmp/") {
-			os.Remove(filename)
+		case opts.Presign:
+			expires, err := parseExpiry(opts.Expires)
+			if err != nil {
+				return nil, err
+			}
+			client, err := s3Client(store, "--presign")
+			if err != nil {
+				return nil, err
+			}
+			url, err := presignedGetURL(ctx, client, opts.Config.BucketName, objectName, expires)
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, url)
+		default:
+			// Generate public URL if requested
+			urls = append(urls, store.URL(objectName))
 		}
 	}
-
 	return urls, nil
 }
 
-// cleanupUploadedFiles removes objects that were successfully uploaded before an error occurred
-func cleanupUploadedFiles(client *minio.Client, bucketName string, objectNames []string) {
-	for _, objectName := range objectNames {
-		// Best effort cleanup - don't propagate errors from cleanup
-		client.RemoveObject(bucketName, objectName)
+// selectIndexes returns the elements of values at the given indexes
+func selectIndexes(values []string, indexes []int) []string {
+	selected := make([]string, len(indexes))
+	for i, idx := range indexes {
+		selected[i] = values[idx]
 	}
+	return selected
 }
 
-func generateObjectName(filename string) string {
-	// Extract file extension
-	parts := strings.Split(filename, ".")
-	suffix := "bin"
-	if len(parts) > 1 {
-		suffix = parts[len(parts)-1]
+// uploadFile uploads a single file as objectName. Files larger than the part
+// size are uploaded in parts.
+func uploadFile(ctx context.Context, store ObjectStore, filename, objectName string, opts *UploadOptions, progress *transferProgress, id int) error {
+	if remote := opts.remotes[filename]; remote != nil {
+		return uploadRemote(ctx, store, remote, objectName, opts, progress, id)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	fileStat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file stats for %s: %w", filename, err)
+	}
+
+	// Determine content type and metadata
+	putOpts := opts.metadata.For(filename).putOptions()
+	putOpts.PartSize = uint64(opts.PartSize)
+	putOpts.Progress = progress.Reader(id)
+	reader, size, err := opts.encryption.seal(file, fileStat.Size(), &putOpts)
+	if err != nil {
+		return err
 	}
 
-	// Get current year for directory structure
-	now := time.Now()
-	year := now.Format("200601")
+	progress.Start(id, size)
 
-	// Generate unique object name with timestamp and UUID
-	timestamp := now.Format("20060102_150405")
-	uuidStr := uuid.NewV4().String()
+	// Upload file
+	err = store.Put(ctx, objectName, reader, size, putOpts)
+	if err != nil {
+		return fmt.Errorf("failed to upload file %s: %w", filename, err)
+	}
 
-	// Return object name with year directory structure
-	return fmt.Sprintf("%s/%s_%s.%s", year, timestamp, uuidStr, suffix)
+	return nil
 }
 
-func getContentType(filename string) string {
-	lower := strings.ToLower(filename)
-	switch {
-	case strings.HasSuffix(lower, ".png"):
-		return "image/png"
-	case strings.HasSuffix(lower, ".jpg") || strings.HasSuffix(lower, ".jpeg"):
-		return "image/jpeg"
-	case strings.HasSuffix(lower, ".gif"):
-		return "image/gif"
-	case strings.HasSuffix(lower, ".pdf"):
-		return "application/pdf"
-	case strings.HasSuffix(lower, ".txt"):
-		return "text/plain"
-	default:
-		return "application/octet-stream"
+// cleanupUploadedFiles removes objects that were successfully uploaded before
+// an error occurred. It also runs when ctx was cancelled by an interrupt.
+func cleanupUploadedFiles(ctx context.Context, store ObjectStore, objectNames []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, objectName := range objectNames {
+		// Best effort cleanup - don't propagate errors from cleanup
+		store.Delete(ctx, objectName)
 	}
 }
+
+// getContentType detects the content type of a file from its header and
+// extension, see util.DetectContentType
+func getContentType(filename string) string {
+	return util.DetectContentType(filename)
+}
@@ -1,11 +1,20 @@
 package minio
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/minio/minio-go/v7"
 )
 
 // TestIsImage tests the isImage function
@@ -29,6 +38,10 @@ func TestIsImage(t *testing.T) {
 		{"Empty filename", "", false},
 		{"Multiple dots", "test.backup.png", true},
 		{"Multiple dots non-image", "test.backup.txt", false},
+		{"GIF file", "test.gif", true},
+		{"SVG file", "test.svg", true},
+		{"WebP file", "test.webp", true},
+		{"Video file", "test.mp4", false},
 	}
 
 	for _, tt := range tests {
@@ -63,6 +76,11 @@ func TestGetContentType(t *testing.T) {
 		{"No extension", "test", "application/octet-stream"},
 		{"Empty filename", "", "application/octet-stream"},
 		{"Multiple dots", "test.backup.png", "image/png"},
+		{"SVG file", "test.svg", "image/svg+xml"},
+		{"WebP file", "test.webp", "image/webp"},
+		{"MP4 file", "test.mp4", "video/mp4"},
+		{"JSON file", "test.json", "application/json"},
+		{"HTML file", "test.html", "text/html"},
 	}
 
 	for _, tt := range tests {
@@ -74,8 +92,8 @@ func TestGetContentType(t *testing.T) {
 	}
 }
 
-// TestGenerateObjectName tests the generateObjectName function
-func TestGenerateObjectName(t *testing.T) {
+// TestDefaultKeyTemplate tests the keys rendered by DefaultKeyTemplate
+func TestDefaultKeyTemplate(t *testing.T) {
 	tests := []struct {
 		name     string
 		filename string
@@ -90,21 +108,27 @@ func TestGenerateObjectName(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := generateObjectName(tt.filename)
-			
+			tmpl, err := parseKeyTemplate(DefaultKeyTemplate, "")
+			if err != nil {
+				t.Fatalf("parseKeyTemplate() unexpected error: %v", err)
+			}
+			got, err := tmpl.Render(uploadSource{Path: tt.filename, RelPath: filepath.Base(tt.filename)}, tt.filename, 0, time.Now())
+			if err != nil {
+				t.Fatalf("Render() unexpected error: %v", err)
+			}
 			// Check that the result is not empty
 			if got == "" {
-				t.Errorf("generateObjectName(%s) returned empty string", tt.filename)
+				t.Errorf("Render(%s) returned empty string", tt.filename)
 			}
 			
 			// Check that the result contains a timestamp pattern (YYYYMMDD_HHMMSS)
 			if !strings.Contains(got, "_") {
-				t.Errorf("generateObjectName(%s) = %s, expected to contain timestamp", tt.filename, got)
+				t.Errorf("Render(%s) = %s, expected to contain timestamp", tt.filename, got)
 			}
 			
 			// Check that the result contains a UUID-like pattern (contains hyphens)
 			if !strings.Contains(got, "-") {
-				t.Errorf("generateObjectName(%s) = %s, expected to contain UUID", tt.filename, got)
+				t.Errorf("Render(%s) = %s, expected to contain UUID", tt.filename, got)
 			}
 			
 			// Check that the extension is preserved correctly
@@ -115,7 +139,65 @@ func TestGenerateObjectName(t *testing.T) {
 			}
 			
 			if !strings.HasSuffix(got, "."+expectedSuffix) {
-				t.Errorf("generateObjectName(%s) = %s, expected to end with .%s", tt.filename, got, expectedSuffix)
+				t.Errorf("Render(%s) = %s, expected to end with .%s", tt.filename, got, expectedSuffix)
+			}
+		})
+	}
+}
+
+func TestContentTypeSniffing(t *testing.T) {
+	dir := t.TempDir()
+	// A PNG named like a text file, and an SVG that cannot be resized
+	png := filepath.Join(dir, "screenshot.txt")
+	if err := ioutil.WriteFile(png, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	svg := filepath.Join(dir, "logo.svg")
+	if err := ioutil.WriteFile(svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if got := getContentType(png); got != "image/png" {
+		t.Errorf("getContentType(%s) = %v, want image/png", png, got)
+	}
+	if !isImage(png) || !isResizableImage(png) {
+		t.Errorf("sniffed PNG is not a resizable image")
+	}
+	if !isImage(svg) || isResizableImage(svg) {
+		t.Errorf("SVG: isImage = %v, isResizableImage = %v, want true, false", isImage(svg), isResizableImage(svg))
+	}
+}
+
+func TestRunUploadContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+		wantErr     string
+	}{
+		{name: "detected", want: "text/plain"},
+		{name: "override", contentType: "text/markdown; charset=utf-8", want: "text/markdown; charset=utf-8"},
+		{name: "invalid", contentType: "not a type", wantErr: "invalid --content-type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, cfg := newFakeS3(t, nil)
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+			opts := &UploadOptions{Config: cfg, KeyTemplate: "{basename}.{ext}", ContentType: tt.contentType}
+
+			err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("runUpload() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runUpload() unexpected error: %v", err)
+			}
+			if got := fake.header("file0.txt").Get("Content-Type"); got != tt.want {
+				t.Errorf("stored content type = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -274,3 +356,273 @@ func TestGetContentTypeEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// writeTestFiles creates count small files and returns their paths
+func writeTestFiles(t *testing.T, count int) []string {
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < count; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		if err := ioutil.WriteFile(file, []byte(fmt.Sprintf("content %d", i)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// testObjectNames returns distinct object names for files
+func testObjectNames(files []string) []string {
+	objectNames := make([]string, len(files))
+	for i, file := range files {
+		objectNames[i] = fmt.Sprintf("test/%d_%s", i, filepath.Base(file))
+	}
+	return objectNames
+}
+
+func newTestStore(t *testing.T, cfg *MinIOConfig) ObjectStore {
+	store, err := newStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newStore() unexpected error: %v", err)
+	}
+	return store
+}
+
+func TestUploadFilesConcurrent(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	files := writeTestFiles(t, 5)
+	opts := &UploadOptions{Config: cfg, Concurrency: 3, PrintURLs: false}
+	progress := newTransferProgress(&cmdutil.IOStreams{}, "Uploading", files, false)
+
+	results, err := uploadFiles(context.Background(), newTestStore(t, cfg), files, testObjectNames(files), opts, nil, progress)
+	if err != nil {
+		t.Fatalf("uploadFiles() unexpected error: %v", err)
+	}
+	if len(results) != len(files) {
+		t.Fatalf("uploadFiles() returned %d results, want %d", len(results), len(files))
+	}
+
+	// Results keep the order of the input files
+	for i, result := range results {
+		if result.Status != uploadSucceeded {
+			t.Errorf("result %d = %+v, want success", i, result)
+		}
+		objectName := result.Key
+		if got := string(fake.object(objectName)); got != fmt.Sprintf("content %d", i) {
+			t.Errorf("object %s = %q, want content of file %d", objectName, got, i)
+		}
+	}
+
+	snapshot := progress.Snapshot()
+	current, total := snapshot.Totals()
+	if current != total || total == 0 {
+		t.Errorf("progress totals = %d/%d, want complete", current, total)
+	}
+}
+
+func TestUploadFilesRollback(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	files := writeTestFiles(t, 4)
+	files = append(files, filepath.Join(t.TempDir(), "missing.txt"))
+	opts := &UploadOptions{Config: cfg, Concurrency: 2}
+	progress := newTransferProgress(&cmdutil.IOStreams{}, "Uploading", files, false)
+
+	if _, err := uploadFiles(context.Background(), newTestStore(t, cfg), files, testObjectNames(files), opts, nil, progress); err == nil {
+		t.Fatalf("uploadFiles() expected error for missing file")
+	}
+	if keys := fake.keys(); len(keys) != 0 {
+		t.Errorf("uploaded objects were not rolled back: %v", keys)
+	}
+}
+
+func TestCleanupUploadedFiles(t *testing.T) {
+	fake, cfg := newFakeS3(t, map[string][]byte{
+		"test/0_a.txt": []byte("a"),
+		"test/1_b.txt": []byte("b"),
+		"keep.txt":     []byte("keep"),
+	})
+	store := newTestStore(t, cfg)
+
+	// Cleanup also runs after an interrupt cancelled the upload
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cleanupUploadedFiles(ctx, store, []string{"test/0_a.txt", "test/1_b.txt", "test/missing.txt"})
+
+	if keys := fake.keys(); !reflect.DeepEqual(keys, []string{"keep.txt"}) {
+		t.Errorf("bucket keys = %v, want only keep.txt", keys)
+	}
+}
+
+func TestUploadFilesOnError(t *testing.T) {
+	tests := []struct {
+		name       string
+		onError    string
+		wantKeys   []string
+		wantStatus []string
+	}{
+		{
+			name:       "rollback",
+			onError:    OnErrorRollback,
+			wantStatus: []string{uploadSkipped, uploadFailed, uploadSkipped},
+		},
+		{
+			name:       "stop",
+			onError:    OnErrorStop,
+			wantKeys:   []string{"test/0_file0.txt"},
+			wantStatus: []string{uploadSucceeded, uploadFailed, uploadSkipped},
+		},
+		{
+			name:       "continue",
+			onError:    OnErrorContinue,
+			wantKeys:   []string{"test/0_file0.txt", "test/2_file2.txt"},
+			wantStatus: []string{uploadSucceeded, uploadFailed, uploadSucceeded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, cfg := newFakeS3(t, nil)
+			files := writeTestFiles(t, 3)
+			files[1] = filepath.Join(t.TempDir(), "missing.txt")
+			// One worker makes the order of the uploads predictable
+			opts := &UploadOptions{Config: cfg, Concurrency: 1, OnError: tt.onError}
+			progress := newTransferProgress(&cmdutil.IOStreams{}, "Uploading", files, false)
+
+			results, err := uploadFiles(context.Background(), newTestStore(t, cfg), files, testObjectNames(files), opts, nil, progress)
+			if err == nil || !strings.Contains(err.Error(), "1 of 3 files failed") {
+				t.Errorf("uploadFiles() error = %v, want 1 of 3 failed", err)
+			}
+			var status []string
+			for _, result := range results {
+				status = append(status, result.Status)
+			}
+			if !reflect.DeepEqual(status, tt.wantStatus) {
+				t.Errorf("result status = %v, want %v", status, tt.wantStatus)
+			}
+			if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "missing.txt") {
+				t.Errorf("failed result reason = %v", results[1].Err)
+			}
+			if keys := fake.keys(); !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("bucket keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestUploadFilesRetry(t *testing.T) {
+	defer func(retries int, delay time.Duration) {
+		minio.MaxRetry, retryBaseDelay = retries, delay
+	}(minio.MaxRetry, retryBaseDelay)
+	// Leave retries to uploadFiles rather than the client
+	minio.MaxRetry, retryBaseDelay = 1, time.Millisecond
+
+	fake, cfg := newFakeS3(t, nil)
+	fake.FailPuts(2)
+	files := writeTestFiles(t, 1)
+	progress := newTransferProgress(&cmdutil.IOStreams{}, "Uploading", files, false)
+
+	opts := &UploadOptions{Config: cfg, Concurrency: 1, Retries: 2}
+	if _, err := uploadFiles(context.Background(), newTestStore(t, cfg), files, testObjectNames(files), opts, nil, progress); err != nil {
+		t.Fatalf("uploadFiles() unexpected error: %v", err)
+	}
+	if keys := fake.keys(); len(keys) != 1 {
+		t.Errorf("bucket keys = %v, want the retried object", keys)
+	}
+
+	fake.FailPuts(2)
+	opts.Retries = 1
+	if _, err := uploadFiles(context.Background(), newTestStore(t, cfg), files, testObjectNames(files), opts, nil, progress); err == nil {
+		t.Errorf("uploadFiles() succeeded with fewer retries than failures")
+	}
+}
+
+func TestUploadFilesCancelled(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	files := writeTestFiles(t, 3)
+	store := newTestStore(t, cfg)
+	progress := newTransferProgress(&cmdutil.IOStreams{}, "Uploading", files, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := &UploadOptions{Config: cfg, Concurrency: 2, OnError: OnErrorContinue}
+	results, err := uploadFiles(ctx, store, files, testObjectNames(files), opts, nil, progress)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("uploadFiles() error = %v, want context.Canceled", err)
+	}
+	for i, result := range results {
+		if result.Status != uploadSkipped {
+			t.Errorf("result %d = %+v, want not started", i, result)
+		}
+	}
+	if keys := fake.keys(); len(keys) != 0 {
+		t.Errorf("bucket keys = %v, want none after interrupt", keys)
+	}
+}
+
+func TestRunUploadPartialFailure(t *testing.T) {
+	fake, cfg := newFakeS3(t, nil)
+	files := writeTestFiles(t, 2)
+	files = append(files, filepath.Join(t.TempDir(), "missing.txt"))
+	errOut := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}, ErrOut: errOut}}
+	opts := &UploadOptions{Config: cfg, Concurrency: 1, OnError: OnErrorContinue, KeyTemplate: "{basename}.{ext}"}
+
+	err := runUpload(context.Background(), f, opts, files)
+	var partial *cmdutil.PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("runUpload() error = %v, want a partial failure", err)
+	}
+	if keys := fake.keys(); !reflect.DeepEqual(keys, []string{"file0.txt", "file1.txt"}) {
+		t.Errorf("bucket keys = %v", keys)
+	}
+	for _, want := range []string{"success", "-> file0.txt", "failed", "missing.txt"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, errOut.String())
+		}
+	}
+
+	// Without any uploaded file the failure is complete
+	opts.OnError = OnErrorStop
+	err = runUpload(context.Background(), f, opts, files[2:])
+	if err == nil || errors.As(err, &partial) {
+		t.Errorf("runUpload() error = %v, want a complete failure", err)
+	}
+
+	opts.OnError = "retry"
+	if err := runUpload(context.Background(), f, opts, files[:1]); err == nil || !strings.Contains(err.Error(), "--on-error") {
+		t.Errorf("runUpload() error = %v, want unsupported policy", err)
+	}
+}
+
+func TestRunUploadPartSize(t *testing.T) {
+	_, cfg := newFakeS3(t, nil)
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: &bytes.Buffer{}}}
+	opts := &UploadOptions{Config: cfg, PartSize: 1024}
+
+	if err := runUpload(context.Background(), f, opts, writeTestFiles(t, 1)); err == nil || !strings.Contains(err.Error(), "part size") {
+		t.Errorf("runUpload() error = %v, want part size error", err)
+	}
+}
+
+func TestTransferProgressPlain(t *testing.T) {
+	errOut := &bytes.Buffer{}
+	progress := newTransferProgress(&cmdutil.IOStreams{Out: &bytes.Buffer{}, ErrOut: errOut}, "Uploading", []string{"a.bin", "b.bin"}, true)
+
+	progress.Start(0, 100)
+	progress.Add(0, 30)
+	progress.Add(0, 30)
+	progress.Done(0, nil)
+	progress.Start(1, 10)
+	progress.Done(1, fmt.Errorf("boom"))
+	progress.Close()
+
+	out := errOut.String()
+	for _, want := range []string{"a.bin: started (100B)", "a.bin: 25%", "a.bin: 50%", "a.bin: done", "b.bin: failed: boom", "Transferred"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plain progress output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "a.bin: 75%") {
+		t.Errorf("plain progress printed an unreached milestone:\n%s", out)
+	}
+}
@@ -0,0 +1,350 @@
+package minio
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// webdavTimeout bounds connecting to WebDAV servers and waiting for their
+// responses
+const webdavTimeout = 30 * time.Second
+
+// webdavPropfind asks for the properties objects are described with
+const webdavPropfind = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop>
+<resourcetype/><getcontentlength/><getlastmodified/><getetag/><getcontenttype/>
+</prop></propfind>`
+
+// webdavStore keeps objects on a WebDAV server: the bucket is a collection
+// below the Endpoint URL and keys are paths in it. The access key and secret
+// key are sent as basic auth credentials.
+type webdavStore struct {
+	client *http.Client
+	base   *url.URL
+	cfg    *MinIOConfig
+
+	// collections holds the collections known to exist
+	collections sync.Map
+}
+
+// webdavMultistatus is the response to PROPFIND requests
+type webdavMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string     `xml:"DAV: status"`
+			Prop   webdavProp `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// webdavProp holds the properties of a resource
+type webdavProp struct {
+	ResourceType struct {
+		Collection *struct{} `xml:"DAV: collection"`
+	} `xml:"DAV: resourcetype"`
+	ContentLength int64  `xml:"DAV: getcontentlength"`
+	LastModified  string `xml:"DAV: getlastmodified"`
+	ETag          string `xml:"DAV: getetag"`
+	ContentType   string `xml:"DAV: getcontenttype"`
+}
+
+// webdavResource is a resource of a PROPFIND response
+type webdavResource struct {
+	// key is the path relative to the bucket, collections below the bucket
+	// end with "/"
+	key        string
+	collection bool
+	info       minio.ObjectInfo
+}
+
+// newWebDAVStore opens the bucket collection of cfg
+func newWebDAVStore(ctx context.Context, cfg *MinIOConfig) (*webdavStore, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/") + "/" + url.PathEscape(cfg.BucketName) + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV endpoint: %w", err)
+	}
+	s := &webdavStore{client: newWebDAVClient(), base: base, cfg: cfg}
+
+	resources, err := s.propfind(ctx, "", "0")
+	if err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
+	}
+	if bucket, ok := findResource(resources, ""); !ok || !bucket.collection {
+		return nil, fmt.Errorf("bucket '%s' does not exist (create the collection %s)", cfg.BucketName, base)
+	}
+	return s, nil
+}
+
+// newWebDAVClient returns a client that gives up on servers that do not
+// connect or respond in time. Bodies are not bounded, transfers of large
+// files take as long as they need and are cancelled with their context.
+func newWebDAVClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: webdavTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = webdavTimeout
+	transport.ResponseHeaderTimeout = webdavTimeout
+	return &http.Client{Transport: transport}
+}
+
+// request returns a request for the resource at key, a path relative to the
+// bucket
+func (s *webdavStore) request(ctx context.Context, method, key string, body io.Reader, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.base.String()+escapeObjectName(key), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if s.cfg.AccessKeyID != "" {
+		req.SetBasicAuth(s.cfg.AccessKeyID, s.cfg.SecretAccessKey)
+	}
+	return req, nil
+}
+
+// do sends a request for the resource at key
+func (s *webdavStore) do(ctx context.Context, method, key string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := s.request(ctx, method, key, body, header)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
+
+// statusError describes an unexpected response. Missing resources satisfy
+// isNotFound.
+func (s *webdavStore) statusError(method, key string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", method, key, fs.ErrNotExist)
+	}
+	return fmt.Errorf("%s %s: %s", method, key, resp.Status)
+}
+
+// mkcol creates the collections of dir and its parents that are missing
+func (s *webdavStore) mkcol(ctx context.Context, dir string) error {
+	if dir == "." || dir == "/" || dir == "" {
+		return nil
+	}
+	if _, ok := s.collections.Load(dir); ok {
+		return nil
+	}
+	if err := s.mkcol(ctx, path.Dir(dir)); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, "MKCOL", dir+"/", nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// Existing collections answer 405 Method Not Allowed
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return s.statusError("MKCOL", dir, resp)
+	}
+	s.collections.Store(dir, true)
+	return nil
+}
+
+func (s *webdavStore) Put(ctx context.Context, key string, r io.Reader, size int64, opts minio.PutObjectOptions) error {
+	if err := s.mkcol(ctx, path.Dir(key)); err != nil {
+		return err
+	}
+	header := http.Header{}
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	}
+	req, err := s.request(ctx, http.MethodPut, key, &storeReader{ctx: ctx, r: r, progress: opts.Progress}, header)
+	if err != nil {
+		return err
+	}
+	// Bodies of unknown size are sent chunked
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s.statusError("PUT", key, resp)
+	}
+	return nil
+}
+
+func (s *webdavStore) Get(ctx context.Context, key string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	header := opts.Header()
+	resp, err := s.do(ctx, http.MethodGet, key, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode == http.StatusOK && header.Get("Range") == "":
+	default:
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil, fmt.Errorf("GET %s: the server ignored the range request", key)
+		}
+		return nil, s.statusError("GET", key, resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *webdavStore) Stat(ctx context.Context, key string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	resources, err := s.propfind(ctx, key, "0")
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	resource, ok := findResource(resources, key)
+	if !ok || resource.collection {
+		return minio.ObjectInfo{}, fmt.Errorf("object %s: %w", key, fs.ErrNotExist)
+	}
+	return resource.info, nil
+}
+
+func (s *webdavStore) List(ctx context.Context, prefix string, recursive bool) ([]minio.ObjectInfo, error) {
+	// Only the collection of the prefix and, if recursive, the collections
+	// below it are listed
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i+1]
+	}
+
+	var objects []minio.ObjectInfo
+	pending := []string{dir}
+	for len(pending) > 0 {
+		collection := pending[0]
+		pending = pending[1:]
+		resources, err := s.propfind(ctx, collection, "1")
+		if isNotFound(err) && collection == dir {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			if resource.key == collection || !strings.HasPrefix(resource.key, prefix) {
+				continue
+			}
+			switch {
+			case resource.collection && recursive:
+				pending = append(pending, resource.key)
+			case resource.collection:
+				objects = append(objects, minio.ObjectInfo{Key: resource.key})
+			default:
+				objects = append(objects, resource.info)
+			}
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *webdavStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return s.statusError("DELETE", key, resp)
+	}
+	return nil
+}
+
+func (s *webdavStore) URL(key string) string {
+	return s.cfg.GetObjectURL(key)
+}
+
+// propfind returns the resources at key with the given depth
+func (s *webdavStore) propfind(ctx context.Context, key, depth string) ([]webdavResource, error) {
+	header := http.Header{}
+	header.Set("Depth", depth)
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := s.do(ctx, "PROPFIND", key, strings.NewReader(webdavPropfind), header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, s.statusError("PROPFIND", key, resp)
+	}
+
+	var ms webdavMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("PROPFIND %s: invalid response: %w", key, err)
+	}
+	var resources []webdavResource
+	for _, response := range ms.Responses {
+		href, err := url.Parse(response.Href)
+		if err != nil || !strings.HasPrefix(href.Path, s.base.Path) {
+			continue
+		}
+		resourceKey := strings.TrimPrefix(href.Path, s.base.Path)
+		for _, propstat := range response.Propstats {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+			prop := propstat.Prop
+			collection := prop.ResourceType.Collection != nil
+			if collection && resourceKey != "" && !strings.HasSuffix(resourceKey, "/") {
+				resourceKey += "/"
+			}
+			info := minio.ObjectInfo{
+				Key:         resourceKey,
+				Size:        prop.ContentLength,
+				ETag:        webdavETag(prop.ETag),
+				ContentType: prop.ContentType,
+			}
+			if modified, err := http.ParseTime(prop.LastModified); err == nil {
+				info.LastModified = modified
+			}
+			resources = append(resources, webdavResource{key: resourceKey, collection: collection, info: info})
+		}
+	}
+	return resources, nil
+}
+
+// webdavETag returns the ETag of a resource if it can be relied on. Weak
+// ETags cannot be sent in If-Match, and ETags are opaque strings that name
+// partial downloads, so only plain ones are kept.
+func webdavETag(etag string) string {
+	if strings.HasPrefix(etag, "W/") {
+		return ""
+	}
+	etag = strings.Trim(etag, `"`)
+	if len(etag) > 128 {
+		return ""
+	}
+	for _, c := range etag {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return ""
+		}
+	}
+	return etag
+}
+
+// findResource returns the resource of key
+func findResource(resources []webdavResource, key string) (webdavResource, bool) {
+	for _, resource := range resources {
+		if resource.key == key {
+			return resource, true
+		}
+	}
+	return webdavResource{}, false
+}
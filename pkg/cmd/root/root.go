@@ -2,6 +2,8 @@ package root
 
 import (
 	"github.com/gogodjzhu/gogobox/pkg/cmd/minio"
+	"github.com/gogodjzhu/gogobox/pkg/cmd/secrets"
+	"github.com/gogodjzhu/gogobox/pkg/cmd/serve"
 	"github.com/gogodjzhu/gogobox/pkg/cmd/timefmt"
 	"github.com/gogodjzhu/gogobox/pkg/cmd/version"
 	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
@@ -23,6 +25,8 @@ func NewCmdRoot(f *cmdutil.Factory) (*cobra.Command, error) {
 	cmd.AddCommand(version.NewCmdVersion(f))
 	cmd.AddCommand(minio.NewCmdMinIO(f))
 	cmd.AddCommand(timefmt.NewCmdTimeFmt(f))
+	cmd.AddCommand(secrets.NewCmdSecrets(f))
+	cmd.AddCommand(serve.NewCmdServe(f))
 
 	return cmd, nil
 }
@@ -0,0 +1,237 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gogodjzhu/gogobox/internal/secretstore"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil/tui/tui_textinput"
+	"github.com/spf13/cobra"
+)
+
+// EnvPassphrase holds the passphrase for unattended use of the secrets store
+const EnvPassphrase = "GOGOBOX_SECRETS_PASSPHRASE"
+
+func NewCmdSecrets(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage the encrypted secrets store",
+		Long: `Manage the encrypted secrets store.
+
+Secrets are kept in ~/.config/gogobox/secrets.enc (or the file named by
+$GOGOBOX_SECRETS_FILE), encrypted with AES-256-GCM using a key derived from a
+passphrase with scrypt. Other commands reference secrets by name, e.g. the
+secretRef setting of a MinIO profile.
+
+The passphrase is read from $GOGOBOX_SECRETS_PASSPHRASE, or prompted for when
+running in a terminal.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newCmdSecretsSet(f))
+	cmd.AddCommand(newCmdSecretsGet(f))
+	cmd.AddCommand(newCmdSecretsRemove(f))
+	cmd.AddCommand(newCmdSecretsList(f))
+
+	return cmd
+}
+
+func newCmdSecretsSet(f *cmdutil.Factory) *cobra.Command {
+	var fromStdin bool
+
+	cmd := &cobra.Command{
+		Use:   "set <name> [value]",
+		Short: "Add or replace a secret",
+		Long: `Add or replace a secret.
+
+Avoid passing the value as an argument, where it ends up in the shell history:
+leave it out to be prompted for it, or pipe it in with --stdin.`,
+		Example: `  # Prompt for the value
+  gogobox secrets set minio-prod
+
+  # Read the value from a file
+  gogobox secrets set minio-prod --stdin < secret.txt`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := readSecretValue(f, args, fromStdin)
+			if err != nil {
+				return err
+			}
+
+			store, err := Unlock(f.IOStreams)
+			if err != nil {
+				return err
+			}
+			store.Set(args[0], value)
+			if err := store.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(f.IOStreams.Out, "Saved secret %s\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the secret value from standard input")
+
+	return cmd
+}
+
+func newCmdSecretsGet(f *cmdutil.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <name>",
+		Short: "Print a secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := Unlock(f.IOStreams)
+			if err != nil {
+				return err
+			}
+			value, ok := store.Get(args[0])
+			if !ok {
+				return fmt.Errorf("secret '%s' does not exist", args[0])
+			}
+			fmt.Fprintln(f.IOStreams.Out, value)
+			return nil
+		},
+	}
+}
+
+func newCmdSecretsRemove(f *cmdutil.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <name>",
+		Aliases: []string{"remove"},
+		Short:   "Remove a secret",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := Unlock(f.IOStreams)
+			if err != nil {
+				return err
+			}
+			if !store.Remove(args[0]) {
+				return fmt.Errorf("secret '%s' does not exist", args[0])
+			}
+			if err := store.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(f.IOStreams.Out, "Removed secret %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newCmdSecretsList(f *cmdutil.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the names of all secrets",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := Unlock(f.IOStreams)
+			if err != nil {
+				return err
+			}
+			for _, name := range store.Names() {
+				fmt.Fprintln(f.IOStreams.Out, name)
+			}
+			return nil
+		},
+	}
+}
+
+// Unlock opens the secrets store with the passphrase from $GOGOBOX_SECRETS_PASSPHRASE,
+// prompting for it when stdin is a terminal
+func Unlock(streams *cmdutil.IOStreams) (*secretstore.Store, error) {
+	path, err := secretstore.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := os.Getenv(EnvPassphrase)
+	if passphrase == "" {
+		if !streams.IsStdinTTY() {
+			return nil, fmt.Errorf("secrets store is locked: set %s or run in a terminal", EnvPassphrase)
+		}
+		if passphrase, err = promptPassphrase(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return secretstore.Open(path, passphrase)
+}
+
+// Lookup returns the named secret from the secrets store
+func Lookup(streams *cmdutil.IOStreams, name string) (string, error) {
+	store, err := Unlock(streams)
+	if err != nil {
+		return "", err
+	}
+	value, ok := store.Get(name)
+	if !ok {
+		return "", fmt.Errorf("secret '%s' does not exist", name)
+	}
+	return value, nil
+}
+
+func promptPassphrase(path string) (string, error) {
+	if secretstore.Exists(path) {
+		return promptHidden("Enter the passphrase to unlock the secrets store:")
+	}
+
+	// A typo in a new passphrase would lock the user out, ask twice
+	passphrase, err := promptHidden("Choose a passphrase for the new secrets store:")
+	if err != nil {
+		return "", err
+	}
+	confirmation, err := promptHidden("Repeat the passphrase:")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirmation {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// promptHidden asks for a value with a masked text input. The prompt is drawn
+// on stderr so stdout stays clean for piping.
+func promptHidden(title string) (string, error) {
+	var value string
+	p := tea.NewProgram(tui_textinput.NewPasswordModel(title, "", func(s string) {
+		value = s
+	}), tea.WithOutput(os.Stderr))
+	if _, err := p.Run(); err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", errors.New("no input given")
+	}
+	return value, nil
+}
+
+func readSecretValue(f *cmdutil.Factory, args []string, fromStdin bool) (string, error) {
+	switch {
+	case len(args) == 2:
+		return args[1], nil
+	case fromStdin:
+		data, err := io.ReadAll(f.IOStreams.In)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret from stdin: %w", err)
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+		if value == "" {
+			return "", errors.New("secret value must not be empty")
+		}
+		return value, nil
+	case f.IOStreams.IsStdinTTY():
+		return promptHidden(fmt.Sprintf("Enter the value of secret %s:", args[0]))
+	default:
+		return "", errors.New("no secret value given: pass it as an argument, use --stdin or run in a terminal")
+	}
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/internal/secretstore"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+func runSecrets(t *testing.T, stdin string, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{In: strings.NewReader(stdin), Out: out}}
+	cmd := NewCmdSecrets(f)
+	cmd.SetArgs(args)
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestSecretsCommands(t *testing.T) {
+	t.Setenv(secretstore.EnvSecretsFile, filepath.Join(t.TempDir(), "secrets.enc"))
+	t.Setenv(EnvPassphrase, "test passphrase")
+
+	if _, err := runSecrets(t, "", "set", "from-arg", "value1"); err != nil {
+		t.Fatalf("secrets set failed: %v", err)
+	}
+	if _, err := runSecrets(t, "value2\n", "set", "from-stdin", "--stdin"); err != nil {
+		t.Fatalf("secrets set --stdin failed: %v", err)
+	}
+	if _, err := runSecrets(t, "", "set", "no-value"); err == nil {
+		t.Errorf("secrets set without value should fail when not in a terminal")
+	}
+
+	out, err := runSecrets(t, "", "get", "from-stdin")
+	if err != nil || out != "value2\n" {
+		t.Errorf("secrets get = %q, %v", out, err)
+	}
+	if _, err := runSecrets(t, "", "get", "missing"); err == nil {
+		t.Errorf("secrets get should fail for missing secret")
+	}
+
+	out, err = runSecrets(t, "", "list")
+	if err != nil || out != "from-arg\nfrom-stdin\n" {
+		t.Errorf("secrets list = %q, %v", out, err)
+	}
+
+	if _, err := runSecrets(t, "", "rm", "from-arg"); err != nil {
+		t.Fatalf("secrets rm failed: %v", err)
+	}
+	if _, err := runSecrets(t, "", "rm", "from-arg"); err == nil {
+		t.Errorf("secrets rm should fail for missing secret")
+	}
+
+	value, err := Lookup(&cmdutil.IOStreams{In: strings.NewReader("")}, "from-stdin")
+	if err != nil || value != "value2" {
+		t.Errorf("Lookup() = %q, %v", value, err)
+	}
+}
+
+func TestUnlockWithoutPassphrase(t *testing.T) {
+	t.Setenv(secretstore.EnvSecretsFile, filepath.Join(t.TempDir(), "secrets.enc"))
+	t.Setenv(EnvPassphrase, "")
+	os.Unsetenv(EnvPassphrase)
+
+	_, err := Unlock(&cmdutil.IOStreams{In: strings.NewReader("")})
+	if err == nil || !strings.Contains(err.Error(), EnvPassphrase) {
+		t.Errorf("Unlock() error = %v, want hint about %s", err, EnvPassphrase)
+	}
+}
@@ -0,0 +1,519 @@
+package serve
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+)
+
+// tempPrefix starts the names of uploads that are still being written. Like
+// every name starting with a dot they are neither listed nor served.
+const tempPrefix = ".gogobox-upload-"
+
+// tokenCookie keeps the token of browsers that opened a URL with ?token=
+const tokenCookie = "gogobox_token"
+
+// maxNumberedNames limits the numbered names tried for form uploads
+const maxNumberedNames = 1000
+
+// errInvalidName rejects file names that are no plain names
+var errInvalidName = errors.New("invalid file name")
+
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Path}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; }
+td { padding: 0.2em 1em 0.2em 0; }
+td.size { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Path}}</h1>
+{{- if .Upload}}
+<form method="post" enctype="multipart/form-data">
+<input type="hidden" name="redirect" value="1">
+<input type="file" name="file" multiple required>
+<button type="submit">Upload</button>
+</form>
+{{- end}}
+<table>
+{{- if ne .Path "/"}}
+<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.URL}}">{{.Name}}</a></td><td class="size">{{.Size}}</td><td>{{.Modified}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// listing is the data of listingTemplate
+type listing struct {
+	Path    string
+	Upload  bool
+	Entries []listingEntry
+}
+
+type listingEntry struct {
+	Name     string
+	URL      string
+	Size     string
+	Modified string
+}
+
+// handler serves the files below root. Symbolic links are followed as long
+// as they stay below root, hidden files and directories are never served.
+type handler struct {
+	opts *ServeOptions
+	root string
+	out  io.Writer
+}
+
+func newHandler(opts *ServeOptions, out io.Writer) *handler {
+	// Paths are checked against the real root, which may be a link itself
+	root, err := filepath.Abs(opts.Dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		root = opts.Dir
+	}
+	return &handler{opts: opts, root: root, out: out}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(w, r) {
+		return
+	}
+	name := path.Clean("/" + r.URL.Path)
+	if isHidden(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		h.serveFile(w, r, name)
+	case r.Method == http.MethodPost && h.opts.Upload:
+		h.uploadForm(w, r, name)
+	case r.Method == http.MethodPut && h.opts.Upload:
+		h.put(w, r, name)
+	default:
+		allow := "GET, HEAD"
+		if h.opts.Upload {
+			allow += ", POST, PUT"
+		}
+		w.Header().Set("Allow", allow)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authorized reports whether the request has the credentials of --auth or
+// --token, and answers it with 401 Unauthorized if not
+func (h *handler) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if h.opts.Auth == "" && h.opts.Token == "" {
+		return true
+	}
+	if h.opts.Auth != "" {
+		if user, password, ok := r.BasicAuth(); ok && secureEqual(user+":"+password, h.opts.Auth) {
+			return true
+		}
+	}
+	if h.opts.Token != "" {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(bearer, h.opts.Token) {
+			return true
+		}
+		if cookie, err := r.Cookie(tokenCookie); err == nil && secureEqual(cookie.Value, h.opts.Token) {
+			return true
+		}
+		if secureEqual(r.URL.Query().Get("token"), h.opts.Token) {
+			// Links of listings do not carry the token
+			http.SetCookie(w, &http.Cookie{Name: tokenCookie, Value: h.opts.Token, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+			return true
+		}
+	}
+	if h.opts.Auth != "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="gogobox", charset="UTF-8"`)
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// secureEqual compares credentials in constant time
+func secureEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// isHidden reports whether a segment of the slash-separated path name starts
+// with a dot, like .git, .env or uploads in progress
+func isHidden(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the real path of name, a clean slash-separated path below
+// root. Paths whose symbolic links lead out of root do not exist.
+func (h *handler) resolve(name string) (string, error) {
+	real, err := filepath.EvalSymlinks(filepath.Join(h.root, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	if !h.contains(real) {
+		return "", fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return real, nil
+}
+
+// contains reports whether the real path p is root or below it
+func (h *handler) contains(p string) bool {
+	rel, err := filepath.Rel(h.root, p)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
+
+// mkdirAll creates the missing directories of name, a clean slash-separated
+// path below root, and returns its real path. Existing links are followed
+// like by resolve.
+func (h *handler) mkdirAll(name string) (string, error) {
+	dir := h.root
+	for _, segment := range strings.Split(strings.Trim(name, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		next := filepath.Join(dir, segment)
+		if err := os.Mkdir(next, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		real, err := filepath.EvalSymlinks(next)
+		if err != nil {
+			return "", err
+		}
+		if !h.contains(real) {
+			return "", fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+		}
+		if stat, err := os.Stat(real); err != nil || !stat.IsDir() {
+			return "", fmt.Errorf("%s is not a directory: %w", path.Join(name, segment), fs.ErrExist)
+		}
+		dir = real
+	}
+	return dir, nil
+}
+
+// serveFile serves a file with range requests, or lists a directory
+func (h *handler) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	real, err := h.resolve(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	file, err := os.Open(real)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !stat.IsDir() {
+		http.ServeContent(w, r, stat.Name(), stat.ModTime(), file)
+		return
+	}
+	// Relative links of listings need the trailing slash
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		target := path.Base(r.URL.Path) + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		w.Header().Set("Location", target)
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+
+	all, err := file.Readdir(-1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	type entryInfo struct {
+		name string
+		fs.FileInfo
+	}
+	var infos []entryInfo
+	for _, info := range all {
+		entryName := info.Name()
+		if strings.HasPrefix(entryName, ".") {
+			continue
+		}
+		// Links are listed as their targets, unless they lead out of root
+		if info.Mode()&fs.ModeSymlink != 0 {
+			target, err := h.resolve(path.Join(name, entryName))
+			if err != nil {
+				continue
+			}
+			if info, err = os.Stat(target); err != nil {
+				continue
+			}
+		}
+		infos = append(infos, entryInfo{name: entryName, FileInfo: info})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].IsDir() != infos[j].IsDir() {
+			return infos[i].IsDir()
+		}
+		return infos[i].name < infos[j].name
+	})
+	data := listing{Path: name, Upload: h.opts.Upload}
+	for _, info := range infos {
+		entry := listingEntry{
+			Name:     info.name,
+			URL:      (&url.URL{Path: info.name}).String(),
+			Size:     util.HumanSize(info.Size()),
+			Modified: info.ModTime().Format(time.DateTime),
+		}
+		if info.IsDir() {
+			entry.Name += "/"
+			entry.URL += "/"
+			entry.Size = ""
+		}
+		data.Entries = append(data.Entries, entry)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	listingTemplate.Execute(w, data)
+}
+
+// uploadForm saves the files of a multipart form posted to a directory
+func (h *handler) uploadForm(w http.ResponseWriter, r *http.Request, name string) {
+	dir, err := h.resolve(name)
+	if stat, statErr := os.Stat(dir); err != nil || statErr != nil || !stat.IsDir() {
+		http.Error(w, "uploads are posted to directories", http.StatusNotFound)
+		return
+	}
+	if h.opts.MaxUpload > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxUpload)
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var saved []string
+	redirect := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		switch {
+		case part.FormName() == "redirect":
+			redirect = true
+		case part.FormName() == "file" && part.FileName() != "":
+			savedName, err := h.save(dir, part.FileName(), part, false)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			saved = append(saved, savedName)
+		}
+		part.Close()
+	}
+	if len(saved) == 0 {
+		http.Error(w, "no files in the form field \"file\"", http.StatusBadRequest)
+		return
+	}
+
+	// Browsers return to the listing, other clients get the URLs
+	if redirect {
+		http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	for _, savedName := range saved {
+		fmt.Fprintln(w, fileURL(r, path.Join(name, savedName)))
+	}
+}
+
+// put saves the body of a PUT request as the file of name
+func (h *handler) put(w http.ResponseWriter, r *http.Request, name string) {
+	if strings.HasSuffix(r.URL.Path, "/") || name == "/" {
+		http.Error(w, "PUT needs a file name", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.opts.MaxUpload > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.opts.MaxUpload)
+	}
+	dirName, fileName := path.Split(name)
+	dir, err := h.mkdirAll(dirName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	savedName, err := h.save(dir, fileName, r.Body, true)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	u := fileURL(r, path.Join(dirName, savedName))
+	w.Header().Set("Location", u)
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintln(w, u)
+}
+
+// save writes the upload r as name into dir and returns the name of the
+// file, which has another extension if the image was resized. Unless exact
+// a numbered name is used if the file exists.
+func (h *handler) save(dir, name string, r io.Reader, exact bool) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%w: %q", errInvalidName, name)
+	}
+	ext := filepath.Ext(name)
+	upload, err := writeTemp(dir, ext, r)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(upload)
+
+	if h.opts.AutoResize {
+		resized, err := util.ResizeImage(upload, h.opts.MaxSize)
+		if err != nil {
+			return "", &resizeError{name: name, err: err}
+		}
+		if resized != upload {
+			defer os.Remove(resized)
+			file, err := os.Open(resized)
+			if err != nil {
+				return "", err
+			}
+			defer file.Close()
+			// The resized copy is moved next to its target
+			if upload, err = writeTemp(dir, ext, file); err != nil {
+				return "", err
+			}
+			defer os.Remove(upload)
+			name = strings.TrimSuffix(name, ext) + filepath.Ext(resized)
+			ext = filepath.Ext(resized)
+		}
+	}
+
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; i < maxNumberedNames; i++ {
+		target := name
+		if i > 0 {
+			target = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		err := moveNew(upload, filepath.Join(dir, target))
+		if err == nil {
+			if stat, err := os.Stat(filepath.Join(dir, target)); err == nil {
+				fmt.Fprintf(h.out, "Received %s (%s)\n", filepath.Join(dir, target), util.HumanSize(stat.Size()))
+			}
+			return target, nil
+		}
+		if !errors.Is(err, fs.ErrExist) || exact {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%w: no free name for %s", fs.ErrExist, name)
+}
+
+// writeTemp writes r into a new temporary file of dir
+func writeTemp(dir, ext string, r io.Reader) (string, error) {
+	file, err := os.CreateTemp(dir, tempPrefix+"*"+ext)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// moveNew moves source to target, failing with fs.ErrExist if target exists.
+// Hard links make the check atomic where the file system supports them.
+func moveNew(source, target string) error {
+	err := os.Link(source, target)
+	if err == nil || errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("%s: %w", target, fs.ErrExist)
+	}
+	return os.Rename(source, target)
+}
+
+// resizeError tells that an uploaded image could not be resized
+type resizeError struct {
+	name string
+	err  error
+}
+
+func (e *resizeError) Error() string {
+	return fmt.Sprintf("failed to process %s: %v", e.name, e.err)
+}
+
+func (e *resizeError) Unwrap() error {
+	return e.err
+}
+
+// fileURL returns the URL of the file at name on the server of r
+func fileURL(r *http.Request, name string) string {
+	return (&url.URL{Scheme: "http", Host: r.Host, Path: name}).String()
+}
+
+// writeError answers a request that failed with err
+func writeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	var resizeErr *resizeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, fmt.Sprintf("uploads are limited to %s", util.HumanSize(maxBytesErr.Limit)), http.StatusRequestEntityTooLarge)
+	case errors.As(err, &resizeErr):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, errInvalidName), errors.Is(err, multipart.ErrMessageTooLarge):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrExist):
+		http.Error(w, "file exists", http.StatusConflict)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,212 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogodjzhu/gogobox/internal/util"
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// DefaultMaxUpload is the default size limit of upload requests
+const DefaultMaxUpload = 1024 * 1024 * 1024
+
+type ServeOptions struct {
+	Dir  string
+	Addr string
+
+	// Auth is "user:password" for basic auth, Token a token accepted as
+	// ?token= or bearer token. Either is accepted if both are set.
+	Auth  string
+	Token string
+
+	Upload     bool
+	AutoResize bool
+	MaxSize    int64
+	MaxUpload  int64
+
+	QRCode bool
+}
+
+func NewCmdServe(f *cmdutil.Factory) *cobra.Command {
+	opts := &ServeOptions{
+		AutoResize: true,
+		QRCode:     true,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "serve [flags] [dir]",
+		Short: "Share a directory over HTTP",
+		Long: `Share a directory, by default the current one, over HTTP.
+
+Directories are listed and files are served with range requests, so downloads
+can be resumed and media can be streamed. The URLs of the server are printed
+with a QR code of the first one, for phones on the same network.
+
+Files and directories starting with a dot, like .git or .env, are hidden, and
+symbolic links are only followed within the directory. Without --auth or
+--token everyone on the network can read the share, so protect it with
+--auth (basic auth) or --token. Browsers opening the URL with ?token= keep the
+token in a cookie; scripts can send it as a bearer token instead.
+
+With --upload files can be added with the form of the directory listings or
+with PUT requests. Large images are resized like 'gogobox minio upload' does
+unless --resize=false. Uploads never replace existing files: PUT fails with
+409 Conflict and form uploads get a numbered name.`,
+		Example: `  # Share the current directory on port 8080
+  gogobox serve
+
+  # Share a directory with a token and accept uploads
+  gogobox serve --token s3cret --upload ~/Public
+
+  # Upload a file with curl
+  curl -T photo.jpg -H "Authorization: Bearer s3cret" http://192.168.1.5:8080/photo.jpg`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Dir = "."
+			if len(args) > 0 {
+				opts.Dir = args[0]
+			}
+			return runServe(cmd.Context(), f, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", ":8080", "Address to listen on")
+	cmd.Flags().StringVar(&opts.Auth, "auth", "", "Require basic auth with user:password")
+	cmd.Flags().StringVar(&opts.Token, "token", "", "Require the token as ?token= or bearer token")
+	cmd.Flags().BoolVar(&opts.Upload, "upload", false, "Accept uploads with the form of listings and PUT requests")
+	cmd.Flags().BoolVar(&opts.AutoResize, "resize", true, "Automatically resize large uploaded images")
+	cmd.Flags().Var(cmdutil.NewSizeValue(&opts.MaxSize, 512*1024), "max-size", "Maximum size of uploaded images after resize")
+	cmd.Flags().Var(cmdutil.NewSizeValue(&opts.MaxUpload, DefaultMaxUpload), "max-upload", "Maximum size of upload requests, 0 for no limit")
+	cmd.Flags().BoolVar(&opts.QRCode, "qr", true, "Print a QR code of the URL")
+
+	return cmd
+}
+
+func runServe(ctx context.Context, f *cmdutil.Factory, opts *ServeOptions) error {
+	if opts.Auth != "" && !strings.Contains(opts.Auth, ":") {
+		return fmt.Errorf("--auth must be user:password")
+	}
+	stat, err := os.Stat(opts.Dir)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("%s is not a directory", opts.Dir)
+	}
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", opts.Addr, err)
+	}
+	return serve(ctx, f, opts, listener)
+}
+
+// serve shares opts.Dir on listener until ctx is cancelled
+func serve(ctx context.Context, f *cmdutil.Factory, opts *ServeOptions, listener net.Listener) error {
+	out := &syncWriter{w: f.IOStreams.Out}
+	server := &http.Server{Handler: newHandler(opts, out), ReadHeaderTimeout: 10 * time.Second}
+
+	if opts.Auth == "" && opts.Token == "" && !isLoopback(listener.Addr()) {
+		errOut := f.IOStreams.ErrOut
+		if errOut == nil {
+			errOut = io.Discard
+		}
+		fmt.Fprintf(errOut, "Warning: %s is shared with everyone who can reach %s, protect it with --auth or --token\n", opts.Dir, listener.Addr())
+	}
+
+	urls := shareURLs(listener.Addr(), opts.Token)
+	fmt.Fprintf(out, "Serving %s on:\n", opts.Dir)
+	for _, u := range urls {
+		fmt.Fprintf(out, "  %s\n", u)
+	}
+	if opts.QRCode && len(urls) > 0 {
+		code, err := util.RenderQRCode(urls[0])
+		if err != nil {
+			return fmt.Errorf("failed to render QR code: %w", err)
+		}
+		fmt.Fprintf(out, "\n%s\n", code)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Serve(listener)
+	}()
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopback reports whether addr is only reachable from this machine
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
+
+// shareURLs returns the URLs of a server listening on addr. Servers listening
+// on all interfaces are reachable on the addresses of the machine, LAN
+// addresses come first.
+func shareURLs(addr net.Addr, token string) []string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return nil
+	}
+	port := fmt.Sprint(tcpAddr.Port)
+
+	var hosts []string
+	if tcpAddr.IP.IsUnspecified() {
+		if addrs, err := net.InterfaceAddrs(); err == nil {
+			for _, a := range addrs {
+				if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.To4() != nil && !ipNet.IP.IsLoopback() {
+					hosts = append(hosts, ipNet.IP.String())
+				}
+			}
+		}
+		hosts = append(hosts, "localhost")
+	} else {
+		hosts = append(hosts, tcpAddr.IP.String())
+	}
+
+	urls := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		u := url.URL{Scheme: "http", Host: net.JoinHostPort(host, port), Path: "/"}
+		if token != "" {
+			u.RawQuery = url.Values{"token": {token}}.Encode()
+		}
+		urls = append(urls, u.String())
+	}
+	return urls
+}
+
+// syncWriter serializes the writes of concurrent requests
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
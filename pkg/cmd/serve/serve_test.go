@@ -0,0 +1,409 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gogodjzhu/gogobox/pkg/cmdutil"
+)
+
+// newTestServer serves a directory holding a.txt and sub/b.txt
+func newTestServer(t *testing.T, opts ServeOptions) (*httptest.Server, string) {
+	dir := t.TempDir()
+	files := map[string]string{"a.txt": "hello world", "sub/b.txt": "beta", tempPrefix + "partial": "x"}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts.Dir = dir
+	server := httptest.NewServer(newHandler(&opts, &syncWriter{w: io.Discard}))
+	t.Cleanup(server.Close)
+	return server, dir
+}
+
+// do sends a request and returns the response with its body
+func do(t *testing.T, req *http.Request) (*http.Response, string) {
+	// Redirects are checked, not followed
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s unexpected error: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func newRequest(t *testing.T, method, url string, body io.Reader) *http.Request {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestServeFiles(t *testing.T) {
+	server, _ := newTestServer(t, ServeOptions{})
+
+	resp, body := do(t, newRequest(t, http.MethodGet, server.URL+"/", nil))
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `href="a.txt"`) || !strings.Contains(body, `href="sub/"`) {
+		t.Errorf("listing = %d %s", resp.StatusCode, body)
+	}
+	if strings.Contains(body, tempPrefix) || strings.Contains(body, "<form") {
+		t.Errorf("listing shows uploads in progress or the upload form:\n%s", body)
+	}
+
+	if resp, _ := do(t, newRequest(t, http.MethodGet, server.URL+"/sub?x=1", nil)); resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "sub/?x=1" {
+		t.Errorf("directory without slash = %d, Location %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if resp, body := do(t, newRequest(t, http.MethodGet, server.URL+"/sub/", nil)); !strings.Contains(body, `href="../"`) || !strings.Contains(body, `href="b.txt"`) {
+		t.Errorf("sub listing = %d %s", resp.StatusCode, body)
+	}
+
+	req := newRequest(t, http.MethodGet, server.URL+"/a.txt", nil)
+	req.Header.Set("Range", "bytes=6-")
+	if resp, body := do(t, req); resp.StatusCode != http.StatusPartialContent || body != "world" {
+		t.Errorf("range request = %d %q, want 206 \"world\"", resp.StatusCode, body)
+	}
+
+	for _, path := range []string{"/missing.txt", "/" + tempPrefix + "partial"} {
+		if resp, _ := do(t, newRequest(t, http.MethodGet, server.URL+path, nil)); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+	if resp, _ := do(t, newRequest(t, http.MethodPut, server.URL+"/c.txt", strings.NewReader("c"))); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("PUT without --upload = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestServeAuth(t *testing.T) {
+	server, _ := newTestServer(t, ServeOptions{Auth: "user:pass", Token: "s3cret"})
+
+	tests := []struct {
+		name    string
+		url     string
+		prepare func(req *http.Request)
+		want    int
+	}{
+		{name: "anonymous", url: "/a.txt", want: http.StatusUnauthorized},
+		{name: "basic auth", url: "/a.txt", prepare: func(req *http.Request) { req.SetBasicAuth("user", "pass") }, want: http.StatusOK},
+		{name: "wrong password", url: "/a.txt", prepare: func(req *http.Request) { req.SetBasicAuth("user", "wrong") }, want: http.StatusUnauthorized},
+		{name: "bearer token", url: "/a.txt", prepare: func(req *http.Request) { req.Header.Set("Authorization", "Bearer s3cret") }, want: http.StatusOK},
+		{name: "query token", url: "/a.txt?token=s3cret", want: http.StatusOK},
+		{name: "wrong token", url: "/a.txt?token=guess", want: http.StatusUnauthorized},
+		{name: "cookie", url: "/a.txt", prepare: func(req *http.Request) { req.AddCookie(&http.Cookie{Name: tokenCookie, Value: "s3cret"}) }, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(t, http.MethodGet, server.URL+tt.url, nil)
+			if tt.prepare != nil {
+				tt.prepare(req)
+			}
+			resp, _ := do(t, req)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Basic") {
+				t.Errorf("401 without basic auth challenge")
+			}
+		})
+	}
+
+	// Browsers keep the token of the shared URL for the links of listings
+	resp, _ := do(t, newRequest(t, http.MethodGet, server.URL+"/?token=s3cret", nil))
+	if cookies := resp.Cookies(); len(cookies) != 1 || cookies[0].Name != tokenCookie || !cookies[0].HttpOnly {
+		t.Errorf("cookies = %v, want the token cookie", cookies)
+	}
+}
+
+// multipartForm returns a form with the files and the content type of its body
+func multipartForm(t *testing.T, redirect bool, files map[string]string) (io.Reader, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if redirect {
+		writer.WriteField("redirect", "1")
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestServeUpload(t *testing.T) {
+	server, dir := newTestServer(t, ServeOptions{Upload: true, MaxUpload: 1024})
+
+	resp, body := do(t, newRequest(t, http.MethodGet, server.URL+"/", nil))
+	if !strings.Contains(body, `<form method="post" enctype="multipart/form-data">`) {
+		t.Errorf("listing has no upload form:\n%s", body)
+	}
+
+	// PUT creates missing directories and never replaces files
+	resp, body = do(t, newRequest(t, http.MethodPut, server.URL+"/new/dir/c.txt", strings.NewReader("gamma")))
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != server.URL+"/new/dir/c.txt" || strings.TrimSpace(body) != server.URL+"/new/dir/c.txt" {
+		t.Errorf("PUT = %d %q, Location %s", resp.StatusCode, body, resp.Header.Get("Location"))
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "new", "dir", "c.txt")); err != nil || string(got) != "gamma" {
+		t.Errorf("stored file = %q, %v", got, err)
+	}
+	if resp, _ := do(t, newRequest(t, http.MethodPut, server.URL+"/a.txt", strings.NewReader("replaced"))); resp.StatusCode != http.StatusConflict {
+		t.Errorf("PUT of an existing file = %d, want 409", resp.StatusCode)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(got) != "hello world" {
+		t.Errorf("existing file was replaced with %q", got)
+	}
+
+	// Form uploads get numbered names, browsers return to the listing
+	form, contentType := multipartForm(t, false, map[string]string{"a.txt": "second a"})
+	req := newRequest(t, http.MethodPost, server.URL+"/", form)
+	req.Header.Set("Content-Type", contentType)
+	if resp, body := do(t, req); resp.StatusCode != http.StatusCreated || strings.TrimSpace(body) != server.URL+"/a-1.txt" {
+		t.Errorf("form upload = %d %q, want a-1.txt", resp.StatusCode, body)
+	}
+	form, contentType = multipartForm(t, true, map[string]string{"d.txt": "delta"})
+	req = newRequest(t, http.MethodPost, server.URL+"/sub/", form)
+	req.Header.Set("Content-Type", contentType)
+	if resp, _ := do(t, req); resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/sub/" {
+		t.Errorf("browser upload = %d, Location %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "sub", "d.txt")); err != nil || string(got) != "delta" {
+		t.Errorf("uploaded file = %q, %v", got, err)
+	}
+
+	// Rejected uploads leave nothing behind
+	failures := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{name: "too large", path: "/big.txt", body: strings.Repeat("x", 2048), want: http.StatusRequestEntityTooLarge},
+		{name: "temporary name", path: "/" + tempPrefix + "x", body: "x", want: http.StatusNotFound},
+		{name: "directory", path: "/sub/", body: "x", want: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range failures {
+		if resp, _ := do(t, newRequest(t, http.MethodPut, server.URL+tt.path, strings.NewReader(tt.body))); resp.StatusCode != tt.want {
+			t.Errorf("%s: PUT = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, tempPrefix+"*"))
+	if len(matches) != 1 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "big.txt")); !os.IsNotExist(err) {
+		t.Errorf("too large upload was stored: %v", err)
+	}
+}
+
+func TestServeLinksAndHiddenFiles(t *testing.T) {
+	server, dir := newTestServer(t, ServeOptions{Upload: true})
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"out":        outside,
+		"secret.txt": filepath.Join(outside, "secret.txt"),
+		"inner":      filepath.Join(dir, "sub"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+	writeFiles := map[string]string{".env": "TOKEN=x", ".git/config": "[core]"}
+	for name, content := range writeFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, body := do(t, newRequest(t, http.MethodGet, server.URL+"/", nil))
+	if !strings.Contains(body, `href="inner/"`) {
+		t.Errorf("listing misses the link within the directory:\n%s", body)
+	}
+	for _, hidden := range []string{"out", "secret.txt", ".env", ".git"} {
+		if strings.Contains(body, `href="`+hidden) {
+			t.Errorf("listing shows %s:\n%s", hidden, body)
+		}
+	}
+
+	if resp, body := do(t, newRequest(t, http.MethodGet, server.URL+"/inner/b.txt", nil)); resp.StatusCode != http.StatusOK || body != "beta" {
+		t.Errorf("GET through a link within the directory = %d %q", resp.StatusCode, body)
+	}
+	for _, path := range []string{"/out/secret.txt", "/secret.txt", "/out/", "/.env", "/.git/config", "/.git/"} {
+		if resp, _ := do(t, newRequest(t, http.MethodGet, server.URL+path, nil)); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+
+	// Uploads cannot be written through links out of the directory or as
+	// hidden files
+	for _, path := range []string{"/out/x.txt", "/out/new/x.txt", "/.ssh/authorized_keys", "/sub/.env"} {
+		if resp, _ := do(t, newRequest(t, http.MethodPut, server.URL+path, strings.NewReader("x"))); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("PUT %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+	form, contentType := multipartForm(t, false, map[string]string{".bashrc": "x"})
+	req := newRequest(t, http.MethodPost, server.URL+"/", form)
+	req.Header.Set("Content-Type", contentType)
+	if resp, _ := do(t, req); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("form upload of a hidden file = %d, want 400", resp.StatusCode)
+	}
+	form, contentType = multipartForm(t, false, map[string]string{"x.txt": "x"})
+	req = newRequest(t, http.MethodPost, server.URL+"/out/", form)
+	req.Header.Set("Content-Type", contentType)
+	if resp, _ := do(t, req); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("form upload through a link out of the directory = %d, want 404", resp.StatusCode)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 1 {
+		t.Errorf("files were written outside of the directory: %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".ssh")); !os.IsNotExist(err) {
+		t.Errorf("hidden directory was created")
+	}
+}
+
+func TestServeUploadResize(t *testing.T) {
+	server, dir := newTestServer(t, ServeOptions{Upload: true, AutoResize: true, MaxSize: 10 * 1024})
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * y), uint8(x ^ y), uint8(x + y), 255})
+		}
+	}
+	data := &bytes.Buffer{}
+	if err := png.Encode(data, img); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, body := do(t, newRequest(t, http.MethodPut, server.URL+"/photo.png", data))
+	if resp.StatusCode != http.StatusCreated || !strings.HasSuffix(strings.TrimSpace(body), "/photo.jpeg") {
+		t.Fatalf("PUT = %d %q, want the resized photo.jpeg", resp.StatusCode, body)
+	}
+	stat, err := os.Stat(filepath.Join(dir, "photo.jpeg"))
+	if err != nil || stat.Size() > 10*1024 {
+		t.Errorf("resized image = %v, %v, want at most 10KiB", stat, err)
+	}
+
+	// Files that are no images are kept
+	if resp, _ := do(t, newRequest(t, http.MethodPut, server.URL+"/notes.txt", strings.NewReader("notes"))); resp.StatusCode != http.StatusCreated {
+		t.Errorf("PUT of a text file = %d, want 201", resp.StatusCode)
+	}
+	if resp, _ := do(t, newRequest(t, http.MethodPut, server.URL+"/broken.png", strings.NewReader(strings.Repeat("x", 20*1024)))); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("PUT of a broken image = %d, want 422", resp.StatusCode)
+	}
+}
+
+func TestRunServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &bytes.Buffer{}
+	f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: out}}
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, f, &ServeOptions{Dir: t.TempDir(), Token: "s3cret", QRCode: true}, listener)
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/?token=s3cret")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("GET / = %v, %v", resp, err)
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serve() unexpected error: %v", err)
+	}
+	if want := "http://" + listener.Addr().String() + "/?token=s3cret"; !strings.Contains(out.String(), want) || !strings.Contains(out.String(), "█") {
+		t.Errorf("serve() output missing the URL %s or its QR code:\n%s", want, out.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(file, nil, 0644)
+	errorTests := []ServeOptions{
+		{Dir: file, Addr: "127.0.0.1:0"},
+		{Dir: t.TempDir(), Addr: "127.0.0.1:0", Auth: "nopassword"},
+	}
+	for _, opts := range errorTests {
+		if err := runServe(context.Background(), f, &opts); err == nil {
+			t.Errorf("runServe(%+v) expected error", opts)
+		}
+	}
+}
+
+func TestServeWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		addr        string
+		opts        ServeOptions
+		wantWarning bool
+	}{
+		{name: "all interfaces", addr: ":0", wantWarning: true},
+		{name: "all interfaces with token", addr: ":0", opts: ServeOptions{Token: "s3cret"}},
+		{name: "loopback", addr: "127.0.0.1:0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", tt.addr)
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			errOut := &bytes.Buffer{}
+			f := &cmdutil.Factory{IOStreams: &cmdutil.IOStreams{Out: io.Discard, ErrOut: errOut}}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			opts := tt.opts
+			opts.Dir = t.TempDir()
+			if err := serve(ctx, f, &opts, listener); err != nil {
+				t.Fatalf("serve() unexpected error: %v", err)
+			}
+			if got := strings.Contains(errOut.String(), "--auth or --token"); got != tt.wantWarning {
+				t.Errorf("warning = %t, want %t:\n%s", got, tt.wantWarning, errOut.String())
+			}
+		})
+	}
+}
+
+func TestShareURLs(t *testing.T) {
+	urls := shareURLs(&net.TCPAddr{IP: net.IPv4zero, Port: 8080}, "a b")
+	if len(urls) == 0 || urls[len(urls)-1] != "http://localhost:8080/?token=a+b" {
+		t.Errorf("shareURLs() = %v, want localhost last", urls)
+	}
+	for _, u := range urls[:len(urls)-1] {
+		if strings.Contains(u, "127.0.0.1") {
+			t.Errorf("shareURLs() lists the loopback address %s", u)
+		}
+	}
+	if urls := shareURLs(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}, ""); len(urls) != 1 || urls[0] != "http://127.0.0.1:80/" {
+		t.Errorf("shareURLs() = %v", urls)
+	}
+}
@@ -137,6 +137,55 @@ func (tf *TimeFormatter) ParseInput(input string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse time input: %s", input)
 }
 
+// durationUnits extends the units of time.ParseDuration with days and weeks
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseDuration parses durations like "90m", "24h", "7d" or "1w2d12h". Besides
+// the units of time.ParseDuration it accepts "d" (days) and "w" (weeks).
+func (tf *TimeFormatter) ParseDuration(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	rest := input
+	for rest != "" {
+		// Split off the leading number and its unit
+		i := 0
+		for i < len(rest) && (rest[i] == '.' || ('0' <= rest[i] && rest[i] <= '9')) {
+			i++
+		}
+		j := i
+		for j < len(rest) && 'a' <= rest[j] && rest[j] <= 'z' {
+			j++
+		}
+		if i == 0 || j == i {
+			return 0, fmt.Errorf("invalid duration: %s", input)
+		}
+
+		number, unit := rest[:i], rest[i:j]
+		if factor, ok := durationUnits[unit]; ok {
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", input)
+			}
+			total += time.Duration(value * float64(factor))
+		} else {
+			d, err := time.ParseDuration(number + unit)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", input)
+			}
+			total += d
+		}
+		rest = rest[j:]
+	}
+	return total, nil
+}
+
 // parseTimestamp attempts to parse input as a timestamp
 func (tf *TimeFormatter) parseTimestamp(input string) (time.Time, error) {
 	// Remove any whitespace
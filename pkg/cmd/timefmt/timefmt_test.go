@@ -239,3 +239,38 @@ func TestTimeFormatter_WithInputFormat(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, result)
 	}
 }
+
+func TestTimeFormatter_ParseDuration(t *testing.T) {
+	tf := &TimeFormatter{}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{name: "Hours", input: "24h", expected: 24 * time.Hour},
+		{name: "Minutes and seconds", input: "1m30s", expected: 90 * time.Second},
+		{name: "Days", input: "7d", expected: 7 * 24 * time.Hour},
+		{name: "Fractional day", input: "1.5d", expected: 36 * time.Hour},
+		{name: "Weeks, days and hours", input: "1w2d12h", expected: (9*24 + 12) * time.Hour},
+		{name: "Surrounding whitespace", input: " 15m ", expected: 15 * time.Minute},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Missing unit", input: "24", wantErr: true},
+		{name: "Unknown unit", input: "3y", wantErr: true},
+		{name: "Missing number", input: "h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tf.ParseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("ParseDuration() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
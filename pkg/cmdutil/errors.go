@@ -0,0 +1,15 @@
+package cmdutil
+
+// PartialError reports that a command succeeded for some of its inputs only,
+// the process exits with a distinct code so scripts can tell it apart
+type PartialError struct {
+	Err error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
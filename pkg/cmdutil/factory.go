@@ -3,6 +3,8 @@ package cmdutil
 import (
 	"io"
 	"os"
+
+	"github.com/mattn/go-isatty"
 )
 
 type Factory struct {
@@ -17,13 +19,33 @@ func NewFactory() *Factory {
 }
 
 type IOStreams struct {
-	In  io.Reader
-	Out io.Writer
+	In     io.Reader
+	Out    io.Writer
+	ErrOut io.Writer
 }
 
 func ioStreams() *IOStreams {
 	return &IOStreams{
-		In:  os.Stdin,
-		Out: os.Stdout,
+		In:     os.Stdin,
+		Out:    os.Stdout,
+		ErrOut: os.Stderr,
+	}
+}
+
+// IsStdinTTY reports whether In is an interactive terminal
+func (s *IOStreams) IsStdinTTY() bool {
+	return isTerminal(s.In)
+}
+
+// IsStdoutTTY reports whether Out is an interactive terminal
+func (s *IOStreams) IsStdoutTTY() bool {
+	return isTerminal(s.Out)
+}
+
+func isTerminal(v interface{}) bool {
+	file, ok := v.(*os.File)
+	if !ok {
+		return false
 	}
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
 }
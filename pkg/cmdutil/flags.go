@@ -0,0 +1,37 @@
+package cmdutil
+
+import (
+	"github.com/gogodjzhu/gogobox/internal/util"
+)
+
+// SizeValue is a pflag.Value for byte counts accepting unit suffixes such as
+// "512KiB" or "16M"
+type SizeValue struct {
+	size *int64
+}
+
+// NewSizeValue returns a SizeValue storing into size, which is set to def
+func NewSizeValue(size *int64, def int64) *SizeValue {
+	*size = def
+	return &SizeValue{size: size}
+}
+
+func (v *SizeValue) String() string {
+	if v.size == nil || *v.size == 0 {
+		return "0"
+	}
+	return util.HumanSize(*v.size)
+}
+
+func (v *SizeValue) Set(s string) error {
+	size, err := util.ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*v.size = size
+	return nil
+}
+
+func (v *SizeValue) Type() string {
+	return "size"
+}
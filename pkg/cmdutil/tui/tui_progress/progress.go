@@ -0,0 +1,153 @@
+package tui_progress
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gogodjzhu/gogobox/internal/util"
+)
+
+const (
+	refreshInterval = 100 * time.Millisecond
+	nameWidth       = 30
+)
+
+// FileProgress is the transfer state of a single file
+type FileProgress struct {
+	Name    string
+	Total   int64
+	Current int64
+	Started bool
+	Done    bool
+	Err     error
+}
+
+// Snapshot is the transfer state of all files at a point in time
+type Snapshot struct {
+	Files   []FileProgress
+	Started time.Time
+}
+
+// Totals returns the number of transferred and total bytes
+func (s Snapshot) Totals() (current, total int64) {
+	for _, file := range s.Files {
+		current += file.Current
+		total += file.Total
+	}
+	return current, total
+}
+
+// Throughput returns the average transfer rate in bytes per second and the
+// estimated time until all bytes are transferred
+func (s Snapshot) Throughput(now time.Time) (float64, time.Duration) {
+	current, total := s.Totals()
+	elapsed := now.Sub(s.Started).Seconds()
+	if elapsed <= 0 || current == 0 {
+		return 0, 0
+	}
+	rate := float64(current) / elapsed
+	eta := time.Duration(float64(total-current) / rate * float64(time.Second))
+	return rate, eta.Round(time.Second)
+}
+
+// DoneMsg tells the model that all transfers are finished
+type DoneMsg struct{}
+
+type tickMsg time.Time
+
+type model struct {
+	title    string
+	snapshot func() Snapshot
+	current  Snapshot
+	bar      progress.Model
+	done     bool
+}
+
+// NewModel returns a model rendering per-file and aggregate progress. The
+// state is pulled from snapshot periodically, so producers never block on
+// the UI. Send DoneMsg to render the final state and quit.
+func NewModel(title string, snapshot func() Snapshot) tea.Model {
+	return model{
+		title:    title,
+		snapshot: snapshot,
+		current:  snapshot(),
+		bar:      progress.New(progress.WithDefaultGradient(), progress.WithWidth(40)),
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case tickMsg:
+		m.current = m.snapshot()
+		return m, tick()
+	case DoneMsg:
+		m.current = m.snapshot()
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	s := strings.Builder{}
+	s.WriteString(m.title + "\n")
+
+	finished, failed := 0, 0
+	for _, file := range m.current.Files {
+		switch {
+		case file.Err != nil:
+			failed++
+			s.WriteString(fmt.Sprintf("%s failed: %v\n", fitName(file.Name), file.Err))
+		case file.Done:
+			finished++
+		case file.Started:
+			s.WriteString(fmt.Sprintf("%s %s %s/%s\n",
+				fitName(file.Name),
+				m.bar.ViewAs(percent(file.Current, file.Total)),
+				util.HumanSize(file.Current),
+				util.HumanSize(file.Total)))
+		}
+	}
+
+	current, total := m.current.Totals()
+	rate, eta := m.current.Throughput(time.Now())
+	s.WriteString(fmt.Sprintf("\n%s %s/%s  %s/s  ETA %s\n",
+		fitName(fmt.Sprintf("Total (%d/%d files)", finished, len(m.current.Files))),
+		util.HumanSize(current),
+		util.HumanSize(total),
+		util.HumanSize(int64(rate)),
+		eta))
+	if failed > 0 {
+		s.WriteString(fmt.Sprintf("%d failed\n", failed))
+	}
+	return s.String()
+}
+
+func percent(current, total int64) float64 {
+	if total <= 0 {
+		return 1
+	}
+	return float64(current) / float64(total)
+}
+
+// fitName pads or truncates name to a fixed column width
+func fitName(name string) string {
+	runes := []rune(name)
+	if len(runes) > nameWidth {
+		return "…" + string(runes[len(runes)-nameWidth+1:])
+	}
+	return name + strings.Repeat(" ", nameWidth-len(runes))
+}
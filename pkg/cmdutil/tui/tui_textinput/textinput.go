@@ -26,6 +26,14 @@ func NewModel(title, placeholder string, callbackFunc func(string)) tea.Model {
 	}
 }
 
+// NewPasswordModel is like NewModel but masks the typed characters
+func NewPasswordModel(title, placeholder string, callbackFunc func(string)) tea.Model {
+	m := NewModel(title, placeholder, callbackFunc).(model)
+	m.textInput.EchoMode = textinput.EchoPassword
+	m.textInput.EchoCharacter = '*'
+	return m
+}
+
 func (m model) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -0,0 +1,702 @@
+package s3fake
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3Namespace is the XML namespace of S3 documents
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// timeFormat is the format of times in S3 documents
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+// maxKeys is the largest page of a listing
+const maxKeys = 1000
+
+// storedHeaders are the request headers of uploads kept as object metadata,
+// besides the X-Amz-Meta-* headers
+var storedHeaders = []string{"Content-Type", "Cache-Control", "Content-Disposition", "Content-Encoding", "X-Amz-Storage-Class", "X-Amz-Tagging",
+	"X-Amz-Server-Side-Encryption-Customer-Algorithm"}
+
+// bucketConfigs maps the configuration subresources to the error code of
+// a missing document and the status of PUT responses
+var bucketConfigs = map[string]struct {
+	notFound  string
+	putStatus int
+}{
+	ConfigPolicy:     {notFound: "NoSuchBucketPolicy", putStatus: http.StatusNoContent},
+	ConfigLifecycle:  {notFound: "NoSuchLifecycleConfiguration", putStatus: http.StatusOK},
+	ConfigVersioning: {putStatus: http.StatusOK},
+}
+
+// emptyVersioning is the versioning configuration of new buckets
+const emptyVersioning = `<VersioningConfiguration xmlns="` + s3Namespace + `"/>`
+
+type listBucketsResult struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Buckets []struct {
+		Name         string
+		CreationDate string
+	} `xml:"Buckets>Bucket"`
+}
+
+type listObjectsResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Xmlns                 string   `xml:"xmlns,attr"`
+	Name                  string
+	Prefix                string
+	Delimiter             string `xml:",omitempty"`
+	Marker                string `xml:",omitempty"`
+	NextMarker            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	StartAfter            string `xml:",omitempty"`
+	KeyCount              int
+	MaxKeys               int
+	IsTruncated           bool
+	Contents              []listEntry
+	CommonPrefixes        []struct{ Prefix string }
+}
+
+type listEntry struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	StorageClass string
+}
+
+type listUploadsResult struct {
+	XMLName     xml.Name `xml:"ListMultipartUploadsResult"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	Bucket      string
+	Prefix      string
+	MaxUploads  int
+	IsTruncated bool
+	Uploads     []struct {
+		Key       string
+		UploadID  string `xml:"UploadId"`
+		Initiated string
+	} `xml:"Upload"`
+}
+
+type deleteRequest struct {
+	Quiet   bool
+	Objects []struct {
+		Key string
+	} `xml:"Object"`
+}
+
+type deleteResult struct {
+	XMLName xml.Name `xml:"DeleteResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Deleted []struct{ Key string }
+}
+
+type completeRequest struct {
+	Parts []struct {
+		PartNumber int
+	} `xml:"Part"`
+}
+
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Tags    []struct {
+		Key   string
+		Value string
+	} `xml:"TagSet>Tag"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case bucketName == "" && r.Method == http.MethodGet:
+		s.listBuckets(w)
+	case bucketName == "":
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	case key == "":
+		s.serveBucket(w, r, bucketName)
+	case !s.BucketExists(bucketName):
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+	default:
+		s.serveObject(w, r, bucketName, key)
+	}
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := listBucketsResult{Xmlns: s3Namespace}
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, struct {
+			Name         string
+			CreationDate string
+		}{Name: name, CreationDate: time.Unix(0, 0).UTC().Format(timeFormat)})
+	}
+	writeXML(w, result)
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+	if r.Method == http.MethodPut && len(query) == 0 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.buckets[name]; ok {
+			writeError(w, http.StatusConflict, "BucketAlreadyOwnedByYou")
+			return
+		}
+		s.createBucket(name)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !s.BucketExists(name) {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	for config := range bucketConfigs {
+		if query.Has(config) {
+			s.serveBucketConfig(w, r, name, config)
+			return
+		}
+	}
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case query.Has("location"):
+		fmt.Fprintf(w, `<LocationConstraint xmlns="%s"></LocationConstraint>`, s3Namespace)
+	case r.Method == http.MethodPost && query.Has("delete"):
+		s.deleteObjects(w, r, name)
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		s.listUploads(w, name, query.Get("prefix"))
+	case r.Method == http.MethodDelete && len(query) == 0:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.buckets[name].objects) > 0 {
+			writeError(w, http.StatusConflict, "BucketNotEmpty")
+			return
+		}
+		delete(s.buckets, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet:
+		s.listObjects(w, name, query)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// serveBucketConfig stores, returns and deletes a configuration document
+func (s *Server) serveBucketConfig(w http.ResponseWriter, r *http.Request, name, config string) {
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.SetBucketConfig(name, config, string(body))
+		w.WriteHeader(bucketConfigs[config].putStatus)
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.buckets[name].configs, config)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		document, ok := s.BucketConfig(name, config)
+		switch {
+		case ok:
+			fmt.Fprint(w, document)
+		case config == ConfigVersioning:
+			fmt.Fprint(w, emptyVersioning)
+		default:
+			writeError(w, http.StatusNotFound, bucketConfigs[config].notFound)
+		}
+	}
+}
+
+// listObjects answers V2 listings, and V1 listings without list-type
+func (s *Server) listObjects(w http.ResponseWriter, name string, query url.Values) {
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	limit := maxKeys
+	if v := query.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "InvalidArgument")
+			return
+		}
+		limit = min(n, maxKeys)
+	}
+	v2 := query.Get("list-type") == "2"
+	// Continuation tokens are the last key or prefix of the previous page
+	after := query.Get("marker")
+	if v2 {
+		after = max(query.Get("start-after"), query.Get("continuation-token"))
+	}
+
+	result := listObjectsResult{Xmlns: s3Namespace, Name: name, Prefix: prefix, Delimiter: delimiter, MaxKeys: limit}
+	if v2 {
+		result.ContinuationToken = query.Get("continuation-token")
+		result.StartAfter = query.Get("start-after")
+	} else {
+		result.Marker = after
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects := s.buckets[name].objects
+	// Keys and common prefixes are listed in one order
+	entries := map[string]bool{}
+	for key := range objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entries[key[:len(prefix)+i+len(delimiter)]] = true
+				continue
+			}
+		}
+		entries[key] = false
+	}
+	names := make([]string, 0, len(entries))
+	for entry := range entries {
+		if entry > after {
+			names = append(names, entry)
+		}
+	}
+	sort.Strings(names)
+
+	for i, entry := range names {
+		if i == limit {
+			result.IsTruncated = true
+			last := names[i-1]
+			if v2 {
+				result.NextContinuationToken = last
+			} else {
+				result.NextMarker = last
+			}
+			break
+		}
+		if entries[entry] {
+			result.CommonPrefixes = append(result.CommonPrefixes, struct{ Prefix string }{entry})
+			continue
+		}
+		o := objects[entry]
+		storageClass := o.header.Get("X-Amz-Storage-Class")
+		if storageClass == "" {
+			storageClass = "STANDARD"
+		}
+		result.Contents = append(result.Contents, listEntry{
+			Key:          entry,
+			LastModified: o.modTime.Format(timeFormat),
+			ETag:         `"` + o.etag + `"`,
+			Size:         len(o.data),
+			StorageClass: storageClass,
+		})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+	writeXML(w, result)
+}
+
+// listUploads answers listings of the multipart uploads in progress
+func (s *Server) listUploads(w http.ResponseWriter, name, prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := listUploadsResult{Xmlns: s3Namespace, Bucket: name, Prefix: prefix, MaxUploads: maxKeys}
+	ids := make([]string, 0, len(s.uploads))
+	for id, u := range s.uploads {
+		if u.bucket == name && strings.HasPrefix(u.key, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if s.uploads[ids[i]].key != s.uploads[ids[j]].key {
+			return s.uploads[ids[i]].key < s.uploads[ids[j]].key
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		u := s.uploads[id]
+		result.Uploads = append(result.Uploads, struct {
+			Key       string
+			UploadID  string `xml:"UploadId"`
+			Initiated string
+		}{Key: u.key, UploadID: id, Initiated: u.initiated.Format(timeFormat)})
+	}
+	writeXML(w, result)
+}
+
+// deleteObjects answers multi-object delete requests
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, name string) {
+	var request deleteRequest
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := deleteResult{Xmlns: s3Namespace}
+	for _, o := range request.Objects {
+		delete(s.buckets[name].objects, o.Key)
+		if !request.Quiet {
+			result.Deleted = append(result.Deleted, struct{ Key string }{o.Key})
+		}
+	}
+	writeXML(w, result)
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.initiateMultipart(w, r, bucketName, key)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.putPart(w, r, query.Get("uploadId"), query.Get("partNumber"))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeMultipart(w, r, bucketName, key, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		s.mu.Lock()
+		_, ok := s.uploads[query.Get("uploadId")]
+		delete(s.uploads, query.Get("uploadId"))
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case query.Has("tagging"):
+		s.serveTagging(w, r, bucketName, key)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, bucketName, key)
+	case r.Method == http.MethodPut:
+		s.put(w, r, bucketName, key)
+	case r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.buckets[bucketName].objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.get(w, r, bucketName, key)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// get answers GET and HEAD requests, including ranges and conditions
+func (s *Server) get(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	s.mu.Lock()
+	o, ok := s.object(bucketName, key)
+	if ok && r.Header.Get("Range") != "" {
+		s.stats.Ranges = append(s.stats.Ranges, r.Header.Get("Range"))
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	w.Header().Set("ETag", `"`+o.etag+`"`)
+	w.Header().Set("Last-Modified", o.modTime.Format(http.TimeFormat))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	for name, values := range o.header {
+		w.Header()[name] = values
+	}
+	http.ServeContent(w, r, key, o.modTime, bytes.NewReader(o.data))
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failPuts > 0 {
+		s.failPuts--
+		writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable")
+		return
+	}
+	o := newObject(body, storedHeader(r.Header))
+	s.buckets[bucketName].objects[key] = o
+	s.stats.Puts++
+	w.Header().Set("ETag", `"`+o.etag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// copyObject answers server-side copies, within or across buckets
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	source, _, _ = strings.Cut(source, "?")
+	sourceBucket, sourceKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	src, ok := s.object(sourceBucket, sourceKey)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	header := src.header.Clone()
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		header = storedHeader(r.Header)
+	}
+	o := newObject(src.data, header)
+	o.tagging = src.tagging
+	if r.Header.Get("X-Amz-Tagging-Directive") == "REPLACE" {
+		o.tagging = taggingDocument(r.Header.Get("X-Amz-Tagging"))
+	}
+	s.buckets[bucketName].objects[key] = o
+	s.stats.Copies++
+	fmt.Fprintf(w, `<CopyObjectResult><LastModified>%s</LastModified><ETag>"%s"</ETag></CopyObjectResult>`,
+		o.modTime.Format(timeFormat), o.etag)
+}
+
+// serveTagging stores, returns and deletes the Tagging document of an object
+func (s *Server) serveTagging(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	var body []byte
+	if r.Method == http.MethodPut {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.object(bucketName, key)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		o.tagging = string(body)
+	case http.MethodDelete:
+		o.tagging = ""
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		document := o.tagging
+		if document == "" {
+			document = taggingDocument("")
+		}
+		fmt.Fprint(w, document)
+	}
+}
+
+func (s *Server) initiateMultipart(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	s.mu.Lock()
+	s.uploadCount++
+	uploadID := fmt.Sprintf("upload-%d", s.uploadCount)
+	s.uploads[uploadID] = &upload{
+		bucket:    bucketName,
+		key:       key,
+		header:    storedHeader(r.Header),
+		parts:     map[int][]byte{},
+		initiated: time.Now().UTC(),
+	}
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`,
+		xmlEscape(bucketName), xmlEscape(key), uploadID)
+}
+
+func (s *Server) putPart(w http.ResponseWriter, r *http.Request, uploadID, partNumber string) {
+	number, err := strconv.Atoi(partNumber)
+	if err != nil || number < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[uploadID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	if s.failPartsAfter > 0 && s.stats.PartPuts >= s.failPartsAfter {
+		writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	s.stats.PartPuts++
+	u.parts[number] = body
+	w.Header().Set("ETag", `"`+md5Hex(body)+`"`)
+}
+
+func (s *Server) completeMultipart(w http.ResponseWriter, r *http.Request, bucketName, key, uploadID string) {
+	var request completeRequest
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.uploads[uploadID]
+	if !ok || u.bucket != bucketName || u.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	// The ETag of multipart objects is the MD5 of the part MD5s
+	var data, sums []byte
+	for i, part := range request.Parts {
+		body, ok := u.parts[part.PartNumber]
+		if !ok || (i > 0 && part.PartNumber <= request.Parts[i-1].PartNumber) {
+			writeError(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		data = append(data, body...)
+		sum := md5.Sum(body)
+		sums = append(sums, sum[:]...)
+	}
+	o := newObject(data, u.header)
+	o.etag = fmt.Sprintf("%s-%d", md5Hex(sums), len(request.Parts))
+	s.buckets[bucketName].objects[key] = o
+	delete(s.uploads, uploadID)
+
+	fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"%s"</ETag></CompleteMultipartUploadResult>`,
+		xmlEscape(bucketName), xmlEscape(key), o.etag)
+}
+
+// newObject returns an object stored now
+func newObject(data []byte, header http.Header) *object {
+	return &object{
+		data:    data,
+		etag:    md5Hex(data),
+		modTime: time.Now().UTC().Truncate(time.Second),
+		header:  header,
+		tagging: taggingDocument(header.Get("X-Amz-Tagging")),
+	}
+}
+
+// storedHeader returns the metadata headers of a request
+func storedHeader(requestHeader http.Header) http.Header {
+	header := http.Header{}
+	for name, values := range requestHeader {
+		if strings.HasPrefix(http.CanonicalHeaderKey(name), "X-Amz-Meta-") {
+			header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	for _, name := range storedHeaders {
+		if v := requestHeader.Get(name); v != "" {
+			header.Set(name, v)
+		}
+	}
+	return header
+}
+
+// taggingDocument returns the Tagging document of an X-Amz-Tagging header,
+// tags in URL query format
+func taggingDocument(header string) string {
+	doc := tagging{Xmlns: s3Namespace}
+	values, _ := url.ParseQuery(header)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		doc.Tags = append(doc.Tags, struct {
+			Key   string
+			Value string
+		}{Key: k, Value: values.Get(k)})
+	}
+	data, _ := xml.Marshal(doc)
+	return string(data)
+}
+
+// readBody returns the payload of an upload request, decoding streaming
+// (aws-chunked) bodies
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body = decodeAWSChunked(body)
+	}
+	return body, nil
+}
+
+// decodeAWSChunked strips the chunk headers of a streaming body:
+// "<hex size>[;chunk-signature=<sig>]\r\n<data>\r\n" repeated until size 0,
+// followed by the trailers of unsigned payloads
+func decodeAWSChunked(body []byte) []byte {
+	var data []byte
+	for len(body) > 0 {
+		idx := bytes.Index(body, []byte("\r\n"))
+		if idx < 0 {
+			break
+		}
+		size, err := strconv.ParseInt(string(bytes.SplitN(body[:idx], []byte(";"), 2)[0]), 16, 64)
+		if err != nil || size == 0 || int64(len(body)-idx-2) < size {
+			break
+		}
+		body = body[idx+2:]
+		data = append(data, body[:size]...)
+		body = body[min(int(size)+2, len(body)):]
+	}
+	return data
+}
+
+// writeXML writes a document
+func writeXML(w http.ResponseWriter, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "InternalError")
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, xml.Header)
+	w.Write(data)
+}
+
+// writeError writes an S3 error document
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+// xmlEscape escapes s for XML text
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
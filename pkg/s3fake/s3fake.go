@@ -0,0 +1,240 @@
+// Package s3fake provides an in-memory S3 compatible server for tests.
+//
+// The server speaks the path-style subset of the S3 API that gogobox uses:
+// buckets (create, exists, remove, location, policy, lifecycle, versioning),
+// objects (put, get with ranges and conditions, stat, copy, delete,
+// multi-object delete, tagging), listings (V1 and V2, with prefixes,
+// delimiters and pagination) and multipart uploads. Request signatures are
+// not checked, so any credentials are accepted.
+//
+//	fake := s3fake.New("images")
+//	server := httptest.NewServer(fake)
+//	defer server.Close()
+//	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+//		Creds: credentials.NewStaticV4("access", "secret", ""),
+//	})
+package s3fake
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bucket configuration documents
+const (
+	ConfigPolicy     = "policy"
+	ConfigLifecycle  = "lifecycle"
+	ConfigVersioning = "versioning"
+)
+
+// Server is an in-memory S3 endpoint, an http.Handler. It is safe for
+// concurrent use.
+type Server struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	uploads map[string]*upload
+	// uploadCount numbers the upload IDs
+	uploadCount int
+	stats       Stats
+
+	// failPuts is the number of PUT requests still to fail
+	failPuts int
+	// failPartsAfter is the number of parts accepted before all part
+	// uploads fail, 0 if they never fail
+	failPartsAfter int
+}
+
+// Object is a copy of a stored object
+type Object struct {
+	Data         []byte
+	ETag         string
+	LastModified time.Time
+	// Header holds the metadata stored with the object: Content-Type,
+	// Cache-Control, Content-Disposition, Content-Encoding, the storage
+	// class, SSE-C algorithm, tags and X-Amz-Meta-* headers
+	Header http.Header
+}
+
+// Stats counts the requests the server answered
+type Stats struct {
+	// Puts is the number of objects stored with single PUT requests
+	Puts int
+	// PartPuts is the number of parts stored for multipart uploads
+	PartPuts int
+	// Copies is the number of server-side copies
+	Copies int
+	// Ranges are the Range headers of object requests in order
+	Ranges []string
+}
+
+type bucket struct {
+	objects map[string]*object
+	// configs holds the configuration documents by name
+	configs map[string]string
+}
+
+type object struct {
+	data    []byte
+	etag    string
+	modTime time.Time
+	header  http.Header
+	// tagging is the Tagging document, built from X-Amz-Tagging on PUT
+	tagging string
+}
+
+type upload struct {
+	bucket    string
+	key       string
+	header    http.Header
+	parts     map[int][]byte
+	initiated time.Time
+}
+
+// New returns a server with the given buckets
+func New(buckets ...string) *Server {
+	s := &Server{buckets: map[string]*bucket{}, uploads: map[string]*upload{}}
+	for _, name := range buckets {
+		s.CreateBucket(name)
+	}
+	return s
+}
+
+// CreateBucket creates the bucket unless it exists
+func (s *Server) CreateBucket(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createBucket(name)
+}
+
+// createBucket creates the bucket unless it exists, the caller holds s.mu
+func (s *Server) createBucket(name string) *bucket {
+	if b, ok := s.buckets[name]; ok {
+		return b
+	}
+	b := &bucket{objects: map[string]*object{}, configs: map[string]string{}}
+	s.buckets[name] = b
+	return b
+}
+
+// BucketExists reports whether the bucket exists
+func (s *Server) BucketExists(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.buckets[name]
+	return ok
+}
+
+// PutObject stores an object as if it was uploaded, creating the bucket if
+// it does not exist. header holds the metadata, see Object.
+func (s *Server) PutObject(bucketName, key string, data []byte, header http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createBucket(bucketName).objects[key] = newObject(data, storedHeader(header))
+}
+
+// Object returns a copy of the object
+func (s *Server) Object(bucketName, key string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.object(bucketName, key)
+	if !ok {
+		return Object{}, false
+	}
+	return Object{
+		Data:         append([]byte(nil), o.data...),
+		ETag:         o.etag,
+		LastModified: o.modTime,
+		Header:       o.header.Clone(),
+	}, true
+}
+
+// object returns the object, the caller holds s.mu
+func (s *Server) object(bucketName, key string) (*object, bool) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, false
+	}
+	o, ok := b.objects[key]
+	return o, ok
+}
+
+// Keys returns the sorted keys of the bucket's objects
+func (s *Server) Keys(bucketName string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetModTime changes the modification time of an object
+func (s *Server) SetModTime(bucketName, key string, modTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.object(bucketName, key); ok {
+		o.modTime = modTime.UTC()
+	}
+}
+
+// SetObjectTagging replaces the Tagging document of an object
+func (s *Server) SetObjectTagging(bucketName, key, document string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o, ok := s.object(bucketName, key); ok {
+		o.tagging = document
+	}
+}
+
+// BucketConfig returns a configuration document of the bucket, name is one
+// of ConfigPolicy, ConfigLifecycle and ConfigVersioning
+func (s *Server) BucketConfig(bucketName, name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return "", false
+	}
+	document, ok := b.configs[name]
+	return document, ok
+}
+
+// SetBucketConfig replaces a configuration document of the bucket
+func (s *Server) SetBucketConfig(bucketName, name, document string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createBucket(bucketName).configs[name] = document
+}
+
+// Stats returns a copy of the request counters
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Ranges = append([]string(nil), s.stats.Ranges...)
+	return stats
+}
+
+// FailPuts answers the next n PUT object requests with 503 Service
+// Unavailable, a transient error clients retry
+func (s *Server) FailPuts(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failPuts = n
+}
+
+// FailPartsAfter answers every part upload with 403 Access Denied once n
+// parts were stored, simulating a lost connection. Zero stops the failures.
+func (s *Server) FailPartsAfter(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failPartsAfter = n
+}
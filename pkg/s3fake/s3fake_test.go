@@ -0,0 +1,257 @@
+package s3fake
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// newTestClient returns a client of a new server with the given buckets
+func newTestClient(t *testing.T, buckets ...string) (*Server, *minio.Core) {
+	fake := New(buckets...)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := minio.NewCore(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds: credentials.NewStaticV4("access", "secret", ""),
+	})
+	if err != nil {
+		t.Fatalf("minio.NewCore() unexpected error: %v", err)
+	}
+	return fake, client
+}
+
+func TestBuckets(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newTestClient(t, "first")
+
+	if err := client.MakeBucket(ctx, "second", minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("MakeBucket() unexpected error: %v", err)
+	}
+	if err := client.MakeBucket(ctx, "second", minio.MakeBucketOptions{}); minio.ToErrorResponse(err).Code != "BucketAlreadyOwnedByYou" {
+		t.Errorf("MakeBucket() of an existing bucket error = %v", err)
+	}
+	buckets, err := client.ListBuckets(ctx)
+	if err != nil || len(buckets) != 2 || buckets[0].Name != "first" || buckets[1].Name != "second" {
+		t.Errorf("ListBuckets() = %+v, %v, want first and second", buckets, err)
+	}
+
+	fake.PutObject("first", "a.txt", []byte("a"), nil)
+	if err := client.RemoveBucket(ctx, "first"); minio.ToErrorResponse(err).Code != "BucketNotEmpty" {
+		t.Errorf("RemoveBucket() of a bucket with objects error = %v", err)
+	}
+	if err := client.RemoveBucket(ctx, "second"); err != nil {
+		t.Errorf("RemoveBucket() unexpected error: %v", err)
+	}
+	for name, want := range map[string]bool{"first": true, "second": false} {
+		if exists, err := client.BucketExists(ctx, name); err != nil || exists != want {
+			t.Errorf("BucketExists(%s) = %t, %v, want %t", name, exists, err, want)
+		}
+	}
+
+	if err := client.SetBucketPolicy(ctx, "first", `{"Version":"2012-10-17"}`); err != nil {
+		t.Fatalf("SetBucketPolicy() unexpected error: %v", err)
+	}
+	if policy, ok := fake.BucketConfig("first", ConfigPolicy); !ok || policy != `{"Version":"2012-10-17"}` {
+		t.Errorf("stored policy = %q, %t", policy, ok)
+	}
+	if _, err := client.GetBucketLifecycle(ctx, "first"); minio.ToErrorResponse(err).Code != "NoSuchLifecycleConfiguration" {
+		t.Errorf("GetBucketLifecycle() without configuration error = %v", err)
+	}
+}
+
+func TestObjects(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newTestClient(t, "bucket", "other")
+	key := "docs/a&b <1>.txt"
+
+	putOpts := minio.PutObjectOptions{
+		ContentType:  "text/plain",
+		UserMetadata: map[string]string{"author": "alice"},
+		UserTags:     map[string]string{"project": "apollo"},
+	}
+	if _, err := client.Client.PutObject(ctx, "bucket", key, strings.NewReader("hello world"), 11, putOpts); err != nil {
+		t.Fatalf("PutObject() unexpected error: %v", err)
+	}
+	if _, err := client.Client.PutObject(ctx, "missing", "a.txt", strings.NewReader("a"), 1, minio.PutObjectOptions{}); minio.ToErrorResponse(err).Code != "NoSuchBucket" {
+		t.Errorf("PutObject() into a missing bucket error = %v", err)
+	}
+
+	info, err := client.StatObject(ctx, "bucket", key, minio.StatObjectOptions{})
+	if err != nil {
+		t.Fatalf("StatObject() unexpected error: %v", err)
+	}
+	if info.Size != 11 || info.ContentType != "text/plain" || info.UserMetadata["Author"] != "alice" || info.ETag != md5Hex([]byte("hello world")) {
+		t.Errorf("StatObject() = %+v", info)
+	}
+	tags, err := client.GetObjectTagging(ctx, "bucket", key, minio.GetObjectTaggingOptions{})
+	if err != nil || !reflect.DeepEqual(tags.ToMap(), map[string]string{"project": "apollo"}) {
+		t.Errorf("GetObjectTagging() = %v, %v, want project=apollo", tags, err)
+	}
+
+	getOpts := minio.GetObjectOptions{}
+	getOpts.SetRange(6, 0)
+	getOpts.SetMatchETag(info.ETag)
+	object, _, _, err := client.GetObject(ctx, "bucket", key, getOpts)
+	if err != nil {
+		t.Fatalf("GetObject() unexpected error: %v", err)
+	}
+	data, err := io.ReadAll(object)
+	object.Close()
+	if err != nil || string(data) != "world" {
+		t.Errorf("GetObject() range = %q, %v, want \"world\"", data, err)
+	}
+	if ranges := fake.Stats().Ranges; len(ranges) != 1 || ranges[0] != "bytes=6-" {
+		t.Errorf("recorded ranges = %v", ranges)
+	}
+	getOpts.SetMatchETag("changed")
+	if _, _, _, err := client.GetObject(ctx, "bucket", key, getOpts); err == nil {
+		t.Errorf("GetObject() expected error for a changed ETag")
+	}
+
+	src := minio.CopySrcOptions{Bucket: "bucket", Object: key}
+	if _, err := client.Client.CopyObject(ctx, minio.CopyDestOptions{Bucket: "other", Object: "copy.txt"}, src); err != nil {
+		t.Fatalf("CopyObject() unexpected error: %v", err)
+	}
+	copied, ok := fake.Object("other", "copy.txt")
+	if !ok || string(copied.Data) != "hello world" || copied.Header.Get("X-Amz-Meta-Author") != "alice" {
+		t.Errorf("copied object = %+v, %t", copied, ok)
+	}
+	if stats := fake.Stats(); stats.Puts != 1 || stats.Copies != 1 {
+		t.Errorf("stats = %+v, want 1 put and 1 copy", stats)
+	}
+
+	if err := client.RemoveObject(ctx, "bucket", key, minio.RemoveObjectOptions{}); err != nil {
+		t.Errorf("RemoveObject() unexpected error: %v", err)
+	}
+	if _, err := client.StatObject(ctx, "bucket", key, minio.StatObjectOptions{}); minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Errorf("StatObject() of a removed object error = %v", err)
+	}
+}
+
+func TestListObjects(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newTestClient(t, "bucket")
+	for _, key := range []string{"a.txt", "b/1.txt", "b/2.txt", "c.txt", "d/e/f.txt", "e.txt"} {
+		fake.PutObject("bucket", key, []byte(key), nil)
+	}
+
+	list := func(opts minio.ListObjectsOptions) string {
+		var keys []string
+		for object := range client.Client.ListObjects(ctx, "bucket", opts) {
+			if object.Err != nil {
+				t.Fatalf("ListObjects(%+v) unexpected error: %v", opts, object.Err)
+			}
+			keys = append(keys, object.Key)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	tests := []struct {
+		name string
+		opts minio.ListObjectsOptions
+		want string
+	}{
+		{name: "top level", want: "a.txt,c.txt,e.txt,b/,d/"},
+		{name: "recursive", opts: minio.ListObjectsOptions{Recursive: true}, want: "a.txt,b/1.txt,b/2.txt,c.txt,d/e/f.txt,e.txt"},
+		{name: "prefix", opts: minio.ListObjectsOptions{Prefix: "d/"}, want: "d/e/"},
+		// Pages list their objects before their common prefixes
+		{name: "pages", opts: minio.ListObjectsOptions{MaxKeys: 2}, want: "a.txt,b/,c.txt,d/,e.txt"},
+		{name: "recursive pages", opts: minio.ListObjectsOptions{Recursive: true, MaxKeys: 4}, want: "a.txt,b/1.txt,b/2.txt,c.txt,d/e/f.txt,e.txt"},
+		{name: "v1 pages", opts: minio.ListObjectsOptions{UseV1: true, Recursive: true, MaxKeys: 2}, want: "a.txt,b/1.txt,b/2.txt,c.txt,d/e/f.txt,e.txt"},
+		{name: "start after", opts: minio.ListObjectsOptions{Recursive: true, StartAfter: "c.txt"}, want: "d/e/f.txt,e.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := list(tt.opts); got != tt.want {
+				t.Errorf("ListObjects() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	objects := make(chan minio.ObjectInfo, 2)
+	objects <- minio.ObjectInfo{Key: "a.txt"}
+	objects <- minio.ObjectInfo{Key: "b/1.txt"}
+	close(objects)
+	for result := range client.RemoveObjects(ctx, "bucket", objects, minio.RemoveObjectsOptions{}) {
+		t.Errorf("RemoveObjects() error for %s: %v", result.ObjectName, result.Err)
+	}
+	if keys := fake.Keys("bucket"); !reflect.DeepEqual(keys, []string{"b/2.txt", "c.txt", "d/e/f.txt", "e.txt"}) {
+		t.Errorf("keys after RemoveObjects() = %v", keys)
+	}
+}
+
+func TestMultipart(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newTestClient(t, "bucket")
+
+	uploadID, err := client.NewMultipartUpload(ctx, "bucket", "big.bin", minio.PutObjectOptions{ContentType: "application/x-big"})
+	if err != nil {
+		t.Fatalf("NewMultipartUpload() unexpected error: %v", err)
+	}
+	var parts []minio.CompletePart
+	for i, data := range []string{"first ", "second"} {
+		part, err := client.PutObjectPart(ctx, "bucket", "big.bin", uploadID, i+1, strings.NewReader(data), int64(len(data)), minio.PutObjectPartOptions{})
+		if err != nil {
+			t.Fatalf("PutObjectPart(%d) unexpected error: %v", i+1, err)
+		}
+		parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	// Uploads in progress are listed until they are completed or aborted
+	abandoned, err := client.NewMultipartUpload(ctx, "bucket", "abandoned.bin", minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatalf("NewMultipartUpload() unexpected error: %v", err)
+	}
+	var incomplete []string
+	for upload := range client.ListIncompleteUploads(ctx, "bucket", "", true) {
+		incomplete = append(incomplete, upload.Key+"="+upload.UploadID)
+	}
+	if want := []string{"abandoned.bin=" + abandoned, "big.bin=" + uploadID}; !reflect.DeepEqual(incomplete, want) {
+		t.Errorf("ListIncompleteUploads() = %v, want %v", incomplete, want)
+	}
+	if err := client.RemoveIncompleteUpload(ctx, "bucket", "abandoned.bin"); err != nil {
+		t.Errorf("RemoveIncompleteUpload() unexpected error: %v", err)
+	}
+
+	if _, err := client.CompleteMultipartUpload(ctx, "bucket", "big.bin", uploadID, parts, minio.PutObjectOptions{}); err != nil {
+		t.Fatalf("CompleteMultipartUpload() unexpected error: %v", err)
+	}
+	object, ok := fake.Object("bucket", "big.bin")
+	if !ok || string(object.Data) != "first second" || object.Header.Get("Content-Type") != "application/x-big" {
+		t.Errorf("completed object = %+v, %t", object, ok)
+	}
+	if !strings.HasSuffix(object.ETag, "-2") {
+		t.Errorf("multipart ETag = %s, want the ETag of 2 parts", object.ETag)
+	}
+	if keys := fake.Keys("bucket"); !reflect.DeepEqual(keys, []string{"big.bin"}) {
+		t.Errorf("keys = %v, want only the completed upload", keys)
+	}
+
+	// Part uploads fail once the configured number of parts was stored
+	fake.FailPartsAfter(2)
+	uploadID, err = client.NewMultipartUpload(ctx, "bucket", "failing.bin", minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatalf("NewMultipartUpload() unexpected error: %v", err)
+	}
+	if _, err := client.PutObjectPart(ctx, "bucket", "failing.bin", uploadID, 1, bytes.NewReader([]byte("x")), 1, minio.PutObjectPartOptions{}); minio.ToErrorResponse(err).Code != "AccessDenied" {
+		t.Errorf("PutObjectPart() error = %v, want injected failure", err)
+	}
+}
+
+func TestDecodeAWSChunked(t *testing.T) {
+	body := "5;chunk-signature=abc\r\nhello\r\n6;chunk-signature=def\r\n world\r\n0;chunk-signature=ghi\r\n\r\n"
+	if got := string(decodeAWSChunked([]byte(body))); got != "hello world" {
+		t.Errorf("decodeAWSChunked() = %q, want \"hello world\"", got)
+	}
+	if got := string(decodeAWSChunked([]byte("ff\r\nshort"))); got != "" {
+		t.Errorf("decodeAWSChunked() of a truncated body = %q, want nothing", got)
+	}
+}